@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"geo-alert-core/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func (a *app) importFile(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "validate and report without saving")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: geoalertctl import [--dry-run] FILE")
+	}
+	path := positional[0]
+
+	var contentType string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".geojson", ".json":
+		contentType = "application/geo+json"
+	case ".csv":
+		contentType = "text/csv"
+	default:
+		return fmt.Errorf("unknown file type %q, expected .geojson, .json or .csv", filepath.Ext(path))
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	report, err := a.client.ImportIncidents(ctx, file, contentType, *dryRun)
+	if err != nil {
+		return err
+	}
+	if a.json {
+		return a.printJSON(report)
+	}
+
+	if report.DryRun {
+		fmt.Fprintln(a.out, "dry run, nothing saved")
+	}
+	fmt.Fprintf(a.out, "created: %d, updated: %d, rejected: %d\n", report.Created, report.Updated, report.Rejected)
+	if report.Rejected == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(a.out)
+	w := a.table("INDEX", "ERROR")
+	for _, result := range report.Results {
+		if result.Status == domain.ImportRejected {
+			w.row(strconv.Itoa(result.Index), result.Error)
+		}
+	}
+	return w.flush()
+}
+
+func (a *app) check(ctx context.Context, args []string) error {
+	var req domain.LocationCheckRequest
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	fs.StringVar(&req.UserID, "user", "geoalertctl", "user id saved with the check")
+	fs.Float64Var(&req.Latitude, "lat", 0, "latitude (required)")
+	fs.Float64Var(&req.Longitude, "lon", 0, "longitude (required)")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	// проверка сохраняется и при попадании в зону отправляет вебхук, как от мобильного клиента
+	response, err := a.client.CheckLocation(ctx, &req)
+	if err != nil {
+		return err
+	}
+	if a.json {
+		return a.printJSON(response)
+	}
+
+	if !response.HasDanger {
+		fmt.Fprintln(a.out, "no danger zones at this point")
+		return nil
+	}
+	fmt.Fprintf(a.out, "inside %d danger zone(s):\n\n", len(response.Incidents))
+	incidents := make([]*domain.Incident, len(response.Incidents))
+	for i := range response.Incidents {
+		incidents[i] = &response.Incidents[i]
+	}
+	return a.printIncidents(incidents)
+}
+
+func (a *app) webhooks(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: geoalertctl webhooks status|deliveries")
+	}
+
+	switch args[0] {
+	case "status":
+		return a.webhookStatus(ctx, args[1:])
+	case "deliveries":
+		return a.webhookDeliveries(ctx, args[1:])
+	default:
+		return fmt.Errorf("unknown webhooks command %q", args[0])
+	}
+}
+
+// webhookStatus - очередь из /system/ready и счетчики из /metrics
+func (a *app) webhookStatus(ctx context.Context, args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("usage: geoalertctl webhooks status")
+	}
+
+	report, err := a.client.Ready(ctx)
+	if err != nil {
+		return err
+	}
+	metricsText, err := a.client.Metrics(ctx)
+	if err != nil {
+		return err
+	}
+
+	status := map[string]any{}
+	for _, dep := range report.Dependencies {
+		if dep.Name == "webhooks" {
+			status["backlog"] = dep.Details
+			status["status"] = dep.Status
+		}
+	}
+	for name, value := range parseWebhookMetrics(metricsText) {
+		status[name] = value
+	}
+
+	if a.json {
+		return a.printJSON(status)
+	}
+
+	w := a.table("METRIC", "VALUE")
+	if backlog, ok := status["backlog"].(map[string]any); ok {
+		w.row("pending", fmt.Sprint(backlog["pending"]))
+		w.row("backlog limit", fmt.Sprint(backlog["limit"]))
+	}
+	for _, name := range []string{"attempts", "delivered", "failed"} {
+		w.row(name, fmt.Sprint(status[name]))
+	}
+	return w.flush()
+}
+
+// webhookDeliveries - последние доставки из журнала сервера
+func (a *app) webhookDeliveries(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("webhooks deliveries", flag.ContinueOnError)
+	status := fs.String("status", "", "only pending, delivered or failed")
+	limit := fs.Int("limit", 0, "max deliveries (server default 50, at most 500)")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	deliveries, err := a.client.ListWebhookDeliveries(ctx, *status, *limit)
+	if err != nil {
+		return err
+	}
+	if a.json {
+		return a.printJSON(deliveries)
+	}
+
+	w := a.table("ID", "CREATED_AT", "USER", "INCIDENTS", "STATUS", "ATTEMPTS", "LAST_ERROR")
+	for _, delivery := range deliveries {
+		w.row(
+			delivery.ID.String(),
+			delivery.CreatedAt.UTC().Format(time.RFC3339),
+			delivery.UserID,
+			strconv.Itoa(len(delivery.IncidentIDs)),
+			delivery.Status,
+			strconv.Itoa(delivery.Attempts),
+			// ошибка может содержать тело ответа получателя с переводами строк
+			strings.Join(strings.Fields(delivery.LastError), " "),
+		)
+	}
+	return w.flush()
+}
+
+// parseWebhookMetrics достает счетчики вебхуков из текстового формата Prometheus
+func parseWebhookMetrics(text string) map[string]float64 {
+	names := map[string]string{
+		"geoalert_webhook_attempts_total":                     "attempts",
+		`geoalert_webhook_deliveries_total{result="success"}`: "delivered",
+		`geoalert_webhook_deliveries_total{result="failure"}`: "failed",
+	}
+
+	values := map[string]float64{"attempts": 0, "delivered": 0, "failed": 0}
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		if name, ok := names[fields[0]]; ok {
+			if value, err := strconv.ParseFloat(fields[1], 64); err == nil {
+				values[name] = value
+			}
+		}
+	}
+	return values
+}
+
+func (a *app) apikey(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: geoalertctl apikey create|list|revoke")
+	}
+
+	switch args[0] {
+	case "create":
+		return a.createAPIKey(ctx, args[1:])
+	case "list":
+		return a.listAPIKeys(ctx, args[1:])
+	case "revoke":
+		return a.revokeAPIKey(ctx, args[1:])
+	default:
+		return fmt.Errorf("unknown apikey command %q", args[0])
+	}
+}
+
+func (a *app) createAPIKey(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("apikey create", flag.ContinueOnError)
+	name := fs.String("name", "", "who or what the key is for (required)")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if *name == "" {
+		return fmt.Errorf("--name is required")
+	}
+
+	issued, err := a.client.IssueAPIKey(ctx, *name)
+	if err != nil {
+		return err
+	}
+	if a.json {
+		return a.printJSON(issued)
+	}
+
+	fmt.Fprintln(a.out, issued.Key)
+	fmt.Fprintf(os.Stderr, "key %s issued; it is shown only once, revoke with: geoalertctl apikey revoke %s\n", issued.Prefix, issued.ID)
+	return nil
+}
+
+func (a *app) listAPIKeys(ctx context.Context, args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("usage: geoalertctl apikey list")
+	}
+
+	keys, err := a.client.ListAPIKeys(ctx)
+	if err != nil {
+		return err
+	}
+	if a.json {
+		return a.printJSON(keys)
+	}
+
+	w := a.table("ID", "NAME", "PREFIX", "CREATED_AT", "REVOKED_AT")
+	for _, key := range keys {
+		revokedAt := ""
+		if key.RevokedAt != nil {
+			revokedAt = key.RevokedAt.UTC().Format(time.RFC3339)
+		}
+		w.row(key.ID.String(), key.Name, key.Prefix, key.CreatedAt.UTC().Format(time.RFC3339), revokedAt)
+	}
+	return w.flush()
+}
+
+func (a *app) revokeAPIKey(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: geoalertctl apikey revoke ID")
+	}
+	id, err := uuid.Parse(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid api key id %q", args[0])
+	}
+
+	if err := a.client.RevokeAPIKey(ctx, id); err != nil {
+		return err
+	}
+	fmt.Fprintln(a.out, "revoked", id)
+	return nil
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWebhookMetrics(t *testing.T) {
+	text := `# HELP geoalert_webhook_attempts_total Webhook send attempts, retries included.
+# TYPE geoalert_webhook_attempts_total counter
+geoalert_webhook_attempts_total 7
+# TYPE geoalert_webhook_deliveries_total counter
+geoalert_webhook_deliveries_total{result="failure"} 1
+geoalert_webhook_deliveries_total{result="success"} 4
+geoalert_webhook_attempt_duration_seconds_sum 0.5
+geoalert_http_requests_total{method="GET",route="/metrics",status="200"} 3
+`
+
+	assert.Equal(t, map[string]float64{"attempts": 7, "delivered": 4, "failed": 1}, parseWebhookMetrics(text))
+}
+
+func TestParseWebhookMetrics_Missing(t *testing.T) {
+	// до первого вебхука счетчиков с результатом в выводе нет, показываем нули
+	values := parseWebhookMetrics("geoalert_webhook_attempts_total 0\ngarbage line here\n")
+	assert.Equal(t, map[string]float64{"attempts": 0, "delivered": 0, "failed": 0}, values)
+}
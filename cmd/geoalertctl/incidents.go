@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"geo-alert-core/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func (a *app) incidents(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: geoalertctl incidents list|get|create|update|deactivate|delete|restore|purge")
+	}
+
+	switch args[0] {
+	case "list":
+		return a.listIncidents(ctx, args[1:])
+	case "get":
+		return a.getIncident(ctx, args[1:])
+	case "create":
+		return a.createIncident(ctx, args[1:])
+	case "update":
+		return a.updateIncident(ctx, args[1:])
+	case "deactivate":
+		return a.deactivateIncident(ctx, args[1:])
+	case "delete":
+		return a.deleteIncident(ctx, args[1:])
+	case "restore":
+		return a.restoreIncident(ctx, args[1:])
+	case "purge":
+		return a.purgeIncidents(ctx, args[1:])
+	default:
+		return fmt.Errorf("unknown incidents command %q", args[0])
+	}
+}
+
+func (a *app) listIncidents(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("incidents list", flag.ContinueOnError)
+	active := fs.String("active", "", "true or false, all incidents by default")
+	category := fs.String("category", "", "category")
+	severity := fs.String("severity", "", "comma-separated severities")
+	search := fs.String("q", "", "full-text search in title and description")
+	limit := fs.Int("limit", 20, "page size")
+	cursor := fs.String("cursor", "", "next_cursor from the previous page")
+	deleted := fs.Bool("deleted", false, "deleted incidents that are not purged yet")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	query.Set("limit", strconv.Itoa(*limit))
+	if *deleted {
+		query.Set("deleted", "true")
+	}
+	for key, value := range map[string]string{
+		"is_active": *active,
+		"category":  *category,
+		"severity":  *severity,
+		"q":         *search,
+		"cursor":    *cursor,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+
+	list, err := a.client.ListIncidents(ctx, query)
+	if err != nil {
+		return err
+	}
+	if a.json {
+		return a.printJSON(list)
+	}
+
+	printList := a.printIncidents
+	if *deleted {
+		printList = a.printDeletedIncidents
+	}
+	if err := printList(list.Data); err != nil {
+		return err
+	}
+	if list.NextCursor != "" {
+		fmt.Fprintf(a.out, "\nnext page: --cursor %s\n", list.NextCursor)
+	}
+	return nil
+}
+
+func (a *app) getIncident(ctx context.Context, args []string) error {
+	id, err := incidentID(args)
+	if err != nil {
+		return err
+	}
+
+	incident, _, err := a.client.GetIncident(ctx, id)
+	if err != nil {
+		return err
+	}
+	return a.printIncident(incident)
+}
+
+func (a *app) createIncident(ctx context.Context, args []string) error {
+	var req domain.CreateIncidentRequest
+	fs := flag.NewFlagSet("incidents create", flag.ContinueOnError)
+	fs.StringVar(&req.Title, "title", "", "title (required)")
+	fs.StringVar(&req.Description, "description", "", "description")
+	fs.Float64Var(&req.Latitude, "lat", 0, "center latitude (required)")
+	fs.Float64Var(&req.Longitude, "lon", 0, "center longitude (required)")
+	fs.Float64Var(&req.Radius, "radius", 0, "radius in meters (required)")
+	fs.StringVar(&req.Category, "category", "", "category")
+	fs.StringVar(&req.Severity, "severity", "", "low, medium, high or critical (medium by default)")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if req.Title == "" || req.Radius == 0 {
+		return fmt.Errorf("--title, --lat, --lon and --radius are required")
+	}
+
+	incident, err := a.client.CreateIncident(ctx, &req)
+	if err != nil {
+		return err
+	}
+	return a.printIncident(incident)
+}
+
+func (a *app) updateIncident(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("incidents update", flag.ContinueOnError)
+	title := fs.String("title", "", "title")
+	description := fs.String("description", "", "description")
+	lat := fs.Float64("lat", 0, "center latitude")
+	lon := fs.Float64("lon", 0, "center longitude")
+	radius := fs.Float64("radius", 0, "radius in meters")
+	category := fs.String("category", "", "category")
+	severity := fs.String("severity", "", "low, medium, high or critical")
+	active := fs.Bool("active", false, "activate (true) or deactivate (false)")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	id, err := incidentID(positional)
+	if err != nil {
+		return err
+	}
+
+	// в запрос попадают только заданные флаги
+	var req domain.UpdateIncidentRequest
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "title":
+			req.Title = title
+		case "description":
+			req.Description = description
+		case "lat":
+			req.Latitude = lat
+		case "lon":
+			req.Longitude = lon
+		case "radius":
+			req.Radius = radius
+		case "category":
+			req.Category = category
+		case "severity":
+			req.Severity = severity
+		case "active":
+			req.IsActive = active
+		}
+	})
+
+	// If-Match с текущей версией: чужое изменение между чтением и записью вернет 412
+	_, etag, err := a.client.GetIncident(ctx, id)
+	if err != nil {
+		return err
+	}
+	incident, err := a.client.UpdateIncident(ctx, id, &req, etag)
+	if err != nil {
+		return err
+	}
+	return a.printIncident(incident)
+}
+
+// deactivateIncident выключает зону, она остается в списках (в отличие от delete)
+func (a *app) deactivateIncident(ctx context.Context, args []string) error {
+	id, err := incidentID(args)
+	if err != nil {
+		return err
+	}
+
+	_, etag, err := a.client.GetIncident(ctx, id)
+	if err != nil {
+		return err
+	}
+	inactive := false
+	if _, err := a.client.UpdateIncident(ctx, id, &domain.UpdateIncidentRequest{IsActive: &inactive}, etag); err != nil {
+		return err
+	}
+	if a.json {
+		return a.printJSON(map[string]string{"id": id.String(), "status": "deactivated"})
+	}
+	fmt.Fprintf(a.out, "incident %s deactivated\n", id)
+	return nil
+}
+
+func (a *app) deleteIncident(ctx context.Context, args []string) error {
+	id, err := incidentID(args)
+	if err != nil {
+		return err
+	}
+
+	if err := a.client.DeleteIncident(ctx, id); err != nil {
+		return err
+	}
+	if a.json {
+		return a.printJSON(map[string]string{"id": id.String(), "status": "deleted"})
+	}
+	fmt.Fprintf(a.out, "incident %s deleted, undo with: geoalertctl incidents restore %s\n", id, id)
+	return nil
+}
+
+func (a *app) restoreIncident(ctx context.Context, args []string) error {
+	id, err := incidentID(args)
+	if err != nil {
+		return err
+	}
+
+	incident, err := a.client.RestoreIncident(ctx, id)
+	if err != nil {
+		return err
+	}
+	return a.printIncident(incident)
+}
+
+func (a *app) purgeIncidents(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("incidents purge", flag.ContinueOnError)
+	retentionDays := fs.Int("retention-days", 0, "purge incidents deleted more than N days ago (server setting by default)")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	var retention *int
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "retention-days" {
+			retention = retentionDays
+		}
+	})
+
+	result, err := a.client.PurgeIncidents(ctx, retention)
+	if err != nil {
+		return err
+	}
+	if a.json {
+		return a.printJSON(result)
+	}
+	fmt.Fprintf(a.out, "purged %d incidents deleted before %s\n", result.Purged, result.DeletedBefore.Format(time.RFC3339))
+	return nil
+}
+
+func incidentID(args []string) (uuid.UUID, error) {
+	if len(args) != 1 {
+		return uuid.Nil, fmt.Errorf("expected one incident ID")
+	}
+	id, err := uuid.Parse(args[0])
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid incident ID %q", args[0])
+	}
+	return id, nil
+}
@@ -0,0 +1,124 @@
+// geoalertctl - утилита оператора: управление зонами, импорт, проверка координат
+// и состояние вебхуков через HTTP API сервера.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"geo-alert-core/internal/apiclient"
+)
+
+const usage = `usage: geoalertctl [global flags] <command> [flags] [args]
+
+commands:
+  incidents list        list incidents (filters: --active, --category, --severity, --q, --limit)
+  incidents get ID      show incident
+  incidents create      create incident (--title, --lat, --lon, --radius, ...)
+  incidents update ID   change fields given as flags (--title, --radius, --active, ...)
+  incidents deactivate ID  switch incident off, it stays in the list
+  incidents delete ID   delete incident, it can be restored until purged
+  incidents restore ID  undo delete
+  incidents purge       remove incidents deleted earlier than retention (--retention-days)
+  import FILE           import incidents from .geojson/.json or .csv (--dry-run)
+  check                 run a location check (--user, --lat, --lon)
+  webhooks status       webhook backlog and delivery counters
+  webhooks deliveries   recent deliveries with attempts and errors (--status, --limit)
+  apikey create         issue an API key (--name), the key is printed once
+  apikey list           issued keys with prefixes, revoked ones included
+  apikey revoke ID      revoke an issued key
+
+global flags:
+`
+
+// app - общие для всех команд клиент и формат вывода
+type app struct {
+	client *apiclient.Client
+	out    io.Writer
+	json   bool
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdout); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
+		}
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, out io.Writer) error {
+	global := flag.NewFlagSet("geoalertctl", flag.ContinueOnError)
+	global.Usage = func() {
+		fmt.Fprint(global.Output(), usage)
+		global.PrintDefaults()
+	}
+	baseURL := global.String("url", envOr("GEOALERT_URL", "http://localhost:8080"), "server URL (env GEOALERT_URL)")
+	apiKey := global.String("api-key", envOr("GEOALERT_API_KEY", os.Getenv("API_KEY")), "API key (env GEOALERT_API_KEY or API_KEY)")
+	output := global.String("o", "table", "output format: table or json")
+	if err := global.Parse(args); err != nil {
+		return err
+	}
+	if *output != "table" && *output != "json" {
+		return fmt.Errorf("unknown output format %q", *output)
+	}
+
+	a := &app{
+		client: apiclient.New(*baseURL, *apiKey),
+		out:    out,
+		json:   *output == "json",
+	}
+
+	args = global.Args()
+	if len(args) == 0 {
+		global.Usage()
+		return flag.ErrHelp
+	}
+
+	switch args[0] {
+	case "incidents":
+		return a.incidents(ctx, args[1:])
+	case "import":
+		return a.importFile(ctx, args[1:])
+	case "check":
+		return a.check(ctx, args[1:])
+	case "webhooks":
+		return a.webhooks(ctx, args[1:])
+	case "apikey":
+		return a.apikey(ctx, args[1:])
+	default:
+		global.Usage()
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// parseArgs разбирает флаги вперемешку с позиционными аргументами: update ID --title x
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+func envOr(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
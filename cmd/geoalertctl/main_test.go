@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseArgs(t *testing.T) {
+	newFlags := func() (*flag.FlagSet, *string, *bool) {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		return fs, fs.String("title", "", ""), fs.Bool("active", false, "")
+	}
+
+	// флаги до, после и между позиционными аргументами
+	fs, title, active := newFlags()
+	positional, err := parseArgs(fs, []string{"ID", "--title", "Пожар", "extra", "--active"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ID", "extra"}, positional)
+	assert.Equal(t, "Пожар", *title)
+	assert.True(t, *active)
+
+	fs, title, _ = newFlags()
+	positional, err = parseArgs(fs, []string{"--title=x", "ID"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ID"}, positional)
+	assert.Equal(t, "x", *title)
+
+	// без позиционных аргументов
+	fs, _, _ = newFlags()
+	positional, err = parseArgs(fs, nil)
+	require.NoError(t, err)
+	assert.Empty(t, positional)
+
+	// после -- все остальное позиционное
+	fs, title, _ = newFlags()
+	positional, err = parseArgs(fs, []string{"--", "--title", "x"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"--title", "x"}, positional)
+	assert.Empty(t, *title)
+
+	fs, _, _ = newFlags()
+	_, err = parseArgs(fs, []string{"ID", "--unknown"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"geo-alert-core/internal/domain"
+)
+
+func (a *app) printJSON(v any) error {
+	encoder := json.NewEncoder(a.out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// tableWriter - таблица с выравниванием по колонкам
+type tableWriter struct {
+	w *tabwriter.Writer
+}
+
+func (a *app) table(columns ...string) *tableWriter {
+	t := &tableWriter{w: tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)}
+	t.row(columns...)
+	return t
+}
+
+func (t *tableWriter) row(values ...string) {
+	fmt.Fprintln(t.w, strings.Join(values, "\t"))
+}
+
+func (t *tableWriter) flush() error {
+	return t.w.Flush()
+}
+
+func (a *app) printIncidents(incidents []*domain.Incident) error {
+	if a.json {
+		return a.printJSON(incidents)
+	}
+
+	w := a.table("ID", "TITLE", "SEVERITY", "ACTIVE", "LAT", "LON", "RADIUS_M", "VERSION")
+	for _, incident := range incidents {
+		w.row(
+			incident.ID.String(),
+			incident.Title,
+			incident.Severity,
+			strconv.FormatBool(incident.IsActive),
+			strconv.FormatFloat(incident.Latitude, 'f', 6, 64),
+			strconv.FormatFloat(incident.Longitude, 'f', 6, 64),
+			strconv.FormatFloat(incident.Radius, 'f', 0, 64),
+			strconv.Itoa(incident.Version),
+		)
+	}
+	return w.flush()
+}
+
+// printDeletedIncidents - таблица удаленных зон со временем удаления
+func (a *app) printDeletedIncidents(incidents []*domain.Incident) error {
+	w := a.table("ID", "TITLE", "SEVERITY", "ACTIVE", "DELETED_AT", "VERSION")
+	for _, incident := range incidents {
+		deletedAt := ""
+		if incident.DeletedAt != nil {
+			deletedAt = incident.DeletedAt.UTC().Format(time.RFC3339)
+		}
+		w.row(
+			incident.ID.String(),
+			incident.Title,
+			incident.Severity,
+			strconv.FormatBool(incident.IsActive),
+			deletedAt,
+			strconv.Itoa(incident.Version),
+		)
+	}
+	return w.flush()
+}
+
+func (a *app) printIncident(incident *domain.Incident) error {
+	if a.json {
+		return a.printJSON(incident)
+	}
+
+	shape := "circle"
+	if incident.Polygon != nil {
+		shape = fmt.Sprintf("polygon, %d vertices", len(incident.Polygon))
+	}
+
+	w := a.table("FIELD", "VALUE")
+	w.row("id", incident.ID.String())
+	w.row("title", incident.Title)
+	w.row("description", incident.Description)
+	w.row("category", incident.Category)
+	w.row("severity", incident.Severity)
+	w.row("active", strconv.FormatBool(incident.IsActive))
+	w.row("shape", shape)
+	w.row("center", fmt.Sprintf("%.6f, %.6f", incident.Latitude, incident.Longitude))
+	w.row("radius_m", strconv.FormatFloat(incident.Radius, 'f', 0, 64))
+	w.row("source", incident.Source)
+	w.row("version", strconv.Itoa(incident.Version))
+	w.row("updated_at", incident.UpdatedAt.Format("2006-01-02 15:04:05Z07:00"))
+	return w.flush()
+}
@@ -2,7 +2,7 @@ package main
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -14,9 +14,13 @@ import (
 	"geo-alert-core/internal/infrastructure/postgres"
 	"geo-alert-core/internal/infrastructure/redis"
 	"geo-alert-core/internal/infrastructure/webhook"
+	"geo-alert-core/internal/logging"
+	"geo-alert-core/internal/metrics"
 	"geo-alert-core/internal/middleware"
 	"geo-alert-core/internal/repository"
 	"geo-alert-core/internal/service"
+	"geo-alert-core/internal/tracing"
+	"geo-alert-core/migrations"
 
 	"github.com/gin-gonic/gin"
 )
@@ -25,22 +29,103 @@ func main() {
 	// Загружаем конфигурацию
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		slog.Error("failed to load config", "error", err)
+		os.Exit(1)
 	}
 
-	// Подключаемся к PostgreSQL
-	db, err := postgres.NewDB(cfg.GetPostgresDSN())
-	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+	// Структурированные логи в JSON
+	if _, err := logging.Setup(os.Stdout, cfg.LogLevel); err != nil {
+		slog.Error("failed to set up logging", "error", err)
+		os.Exit(1)
 	}
-	defer db.Close()
 
-	// Подключаемся к Redis
-	redisClient, err := redis.NewClient(cfg.GetRedisAddr(), cfg.RedisPassword, cfg.RedisDB)
+	// Трассировка OpenTelemetry
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.OTLPEndpoint, cfg.OTelServiceName)
 	if err != nil {
-		log.Fatalf("Failed to connect to Redis: %v", err)
+		slog.Error("failed to set up tracing", "error", err)
+		os.Exit(1)
 	}
+
+	// Проверки зависимостей для /system/ready
+	healthService := service.NewHealthService("geo-alert-core", service.DefaultHealthCheckTimeout)
+
+	// Хранилище: PostgreSQL с PostGIS или память процесса
+	var (
+		incidentRepo      repository.IncidentRepository
+		locationCheckRepo repository.LocationCheckRepository
+		apiKeyRepo        repository.APIKeyRepository
+		deliveryRepo      repository.WebhookDeliveryRepository
+	)
+	migrate := len(os.Args) > 1 && os.Args[1] == "migrate"
+	switch {
+	case cfg.Storage == config.StorageMemory && migrate:
+		slog.Error("migrate requires STORAGE=postgres")
+		os.Exit(1)
+	case cfg.Storage == config.StorageMemory:
+		slog.Warn("using in-memory storage, data is lost on restart")
+		store := repository.NewMemoryStore()
+		incidentRepo = repository.NewMemoryIncidentRepository(store)
+		locationCheckRepo = repository.NewMemoryLocationCheckRepository(store)
+		apiKeyRepo = repository.NewMemoryAPIKeyRepository(store)
+		deliveryRepo = repository.NewMemoryWebhookDeliveryRepository(store)
+	default:
+		db, err := postgres.NewDB(cfg.GetPostgresDSN())
+		if err != nil {
+			slog.Error("failed to connect to database", "error", err)
+			os.Exit(1)
+		}
+		defer db.Close()
+
+		// Подкоманда migrate: server migrate up|down|to N|status|baseline N
+		if migrate {
+			if err := runMigrate(context.Background(), db, os.Args[2:], os.Stdout); err != nil {
+				slog.Error("migration failed", "error", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		// Миграции встроены в бинарник
+		migrator, err := postgres.NewMigrator(db, migrations.FS)
+		if err != nil {
+			slog.Error("failed to load migrations", "error", err)
+			os.Exit(1)
+		}
+		if cfg.AutoMigrate {
+			if err := migrator.Up(context.Background()); err != nil {
+				slog.Error("failed to apply migrations", "error", err)
+				os.Exit(1)
+			}
+		}
+
+		incidentRepo = repository.NewPostgresIncidentRepository(db)
+		locationCheckRepo = repository.NewPostgresLocationCheckRepository(db)
+		apiKeyRepo = repository.NewPostgresAPIKeyRepository(db)
+		deliveryRepo = repository.NewPostgresWebhookDeliveryRepository(db)
+
+		healthService.AddCheck("postgres", true, postgres.PingCheck(db))
+		healthService.AddCheck("postgis", true, postgres.PostGISCheck(db))
+		healthService.AddCheck("migrations", false, migrator.HealthCheck)
+	}
+
+	// Фоновые задачи останавливаются вместе с сервером
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	// Подключаемся к Redis. Он не обязателен: без него кэш держится в памяти процесса,
+	// а клиент сам переподключится, когда Redis появится
+	redisClient := redis.NewClient(
+		cfg.GetRedisAddr(),
+		cfg.RedisPassword,
+		cfg.RedisDB,
+		cfg.RedisTimeout,
+		cfg.RedisBreakerCooldown,
+	)
 	defer redisClient.Close()
+	if err := redisClient.Ping(context.Background()); err != nil {
+		slog.Warn("redis is unavailable, starting with in-process cache", "error", err)
+	}
+	go redisClient.Run(bgCtx)
 
 	// Создаем вебхук отправитель
 	webhookSender := webhook.NewSender(
@@ -48,37 +133,56 @@ func main() {
 		cfg.WebhookRetryAttempts,
 		cfg.WebhookRetryDelaySec,
 	)
-
-	// Создаем репозитории
-	incidentRepo := repository.NewPostgresIncidentRepository(db)
-	locationCheckRepo := repository.NewPostgresLocationCheckRepository(db)
+	webhookSender.SetDeliveryLog(deliveryRepo)
 
 	// Создаем сервисы
 	incidentService := service.NewIncidentService(incidentRepo)
 	locationService := service.NewLocationService(
 		incidentRepo,
 		locationCheckRepo,
-		redisClient.GetClient(),
 		webhookSender,
 	)
-	statsService := service.NewStatsService(incidentRepo)
+	statsService := service.NewStatsService(incidentRepo, cfg.StatsTimeWindowMinutes, cfg.StatsTimezone)
+	tileService := service.NewTileService(incidentRepo, redisClient.GetClient())
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo)
+	deliveryService := service.NewWebhookDeliveryService(deliveryRepo, cfg.WebhookDeliveryRetention)
 
 	// Связываем сервисы для инвалидации кэша
-	incidentService.SetLocationService(locationService)
+	incidentService.AddCacheInvalidator(tileService)
+	incidentService.SetDeletedRetention(cfg.DeletedIncidentRetention)
+
+	healthService.AddCheck("redis", false, redisClient.HealthCheck)
+	healthService.AddCheck("webhooks", false, webhookSender.BacklogCheck(int64(cfg.WebhookBacklogLimit)))
 
 	// Создаем handlers
-	healthHandler := handler.NewHealthHandler()
+	healthHandler := handler.NewHealthHandler(healthService)
 	incidentHandler := handler.NewIncidentHandler(incidentService)
 	locationHandler := handler.NewLocationHandler(locationService)
 	statsHandler := handler.NewStatsHandler(statsService)
+	feedHandler := handler.NewFeedHandler(incidentService, cfg.CAPSender)
+	tileHandler := handler.NewTileHandler(tileService)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+	webhookHandler := handler.NewWebhookHandler(deliveryService)
+
+	// Ключи идемпотентности для повторов от мобильных клиентов
+	idempotency := middleware.Idempotency(
+		redis.NewIdempotencyStore(redisClient.GetClient()),
+		cfg.IdempotencyTTL,
+	)
 
 	// Настраиваем роутер
 	router := setupRouter(
 		cfg.APIKey,
+		apiKeyService,
+		idempotency,
 		healthHandler,
 		incidentHandler,
 		locationHandler,
 		statsHandler,
+		feedHandler,
+		tileHandler,
+		apiKeyHandler,
+		webhookHandler,
 	)
 
 	// Создаем HTTP сервер
@@ -87,11 +191,34 @@ func main() {
 		Handler: router,
 	}
 
+	// Опрос внешней CAP-ленты с предупреждениями
+	if cfg.CAPFeedURL != "" {
+		capPoller := service.NewCAPPoller(cfg.CAPFeedURL, cfg.CAPPollInterval, incidentService)
+		go capPoller.Run(bgCtx)
+		slog.Info("cap feed polling enabled", "url", cfg.CAPFeedURL, "interval", cfg.CAPPollInterval.String())
+	}
+
+	// Очистка журнала доставки вебхуков
+	go deliveryService.Run(bgCtx)
+
+	// Предварительные агрегаты статистики по зонам
+	if cfg.StatsRollupInterval > 0 {
+		statsRollup := service.NewStatsRollup(
+			locationCheckRepo,
+			redis.NewStatsRollupStore(redisClient.GetClient()),
+			cfg.StatsRollupInterval,
+		)
+		statsRollup.SetSettleDelay(cfg.StatsRollupSettle)
+		statsService.SetRollup(statsRollup)
+		go statsRollup.Run(bgCtx)
+	}
+
 	// Запускаем сервер в горутине
 	go func() {
-		log.Printf("Server starting on port %s", cfg.ServerPort)
+		slog.Info("server starting", "port", cfg.ServerPort)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start server: %v", err)
+			slog.Error("failed to start server", "error", err)
+			os.Exit(1)
 		}
 	}()
 
@@ -100,50 +227,110 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("Shutting down server...")
+	slog.Info("shutting down server")
+	stopBackground()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		slog.Error("server forced to shutdown", "error", err)
+		os.Exit(1)
+	}
+
+	// дописываем накопленные спаны
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("failed to flush traces", "error", err)
 	}
 
-	log.Println("Server exited")
+	slog.Info("server exited")
 }
 
 func setupRouter(
 	apiKey string,
+	issuedAPIKeys middleware.APIKeyValidator,
+	idempotency gin.HandlerFunc,
 	healthHandler *handler.HealthHandler,
 	incidentHandler *handler.IncidentHandler,
 	locationHandler *handler.LocationHandler,
 	statsHandler *handler.StatsHandler,
+	feedHandler *handler.FeedHandler,
+	tileHandler *handler.TileHandler,
+	apiKeyHandler *handler.APIKeyHandler,
+	webhookHandler *handler.WebhookHandler,
 ) *gin.Engine {
-	router := gin.Default()
+	// вместо стандартного логгера gin - JSON-строка на запрос с request_id
+	router := gin.New()
+	router.Use(
+		gin.Recovery(),
+		middleware.RequestID(),
+		tracing.Middleware(),
+		metrics.Middleware(),
+		middleware.RequestLogger(),
+	)
+
+	// Метрики Prometheus (без API key, как и health check)
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
 
 	// Публичные эндпоинты (без API key)
 	public := router.Group("/api/v1")
 	{
 		public.GET("/system/health", healthHandler.Health)
-		public.POST("/location/check", locationHandler.CheckLocation)
+		public.GET("/system/live", healthHandler.Live)
+		public.GET("/system/ready", healthHandler.Ready)
+		public.POST("/location/check", idempotency, locationHandler.CheckLocation)
+
+		// Ленты активных зон для партнеров (CAP 1.2 в Atom и GeoRSS)
+		public.GET("/feeds/cap.atom", feedHandler.CAPFeed)
+		public.GET("/feeds/georss.xml", feedHandler.GeoRSSFeed)
 	}
 
 	// Защищенные эндпоинты
 	protected := router.Group("/api/v1")
-	protected.Use(middleware.APIKeyAuth(apiKey))
+	protected.Use(middleware.APIKeyAuth(apiKey, issuedAPIKeys))
 	{
 		// Управление инцидентами
 		incidents := protected.Group("/incidents")
 		{
-			incidents.POST("", incidentHandler.Create)
+			incidents.POST("", idempotency, incidentHandler.Create)
 			incidents.GET("", incidentHandler.GetAll)
+			incidents.POST("/import", incidentHandler.Import)
+			incidents.POST("/purge", incidentHandler.Purge)
 			incidents.GET("/:id", incidentHandler.GetByID)
+			incidents.GET("/:id/versions", incidentHandler.GetVersions)
+			incidents.GET("/:id/stats/timeseries", statsHandler.GetTimeSeries)
 			incidents.PUT("/:id", incidentHandler.Update)
 			incidents.DELETE("/:id", incidentHandler.Delete)
+			incidents.POST("/:id/restore", incidentHandler.Restore)
 		}
 
+		// Обмен данными с ГИС
+		protected.GET("/incidents.geojson", incidentHandler.ExportGeoJSON)
+		protected.GET("/incidents.kml", incidentHandler.ExportKML)
+		protected.GET("/location/checks.gpx", locationHandler.ExportGPX)
+		protected.GET("/incidents.csv", incidentHandler.ExportCSV)
+		protected.GET("/location/checks.csv", locationHandler.ExportChecksCSV)
+
+		// Векторные тайлы для веб-карты
+		protected.GET("/tiles/:z/:x/:y", tileHandler.GetTile)
+
 		// Статистика
 		protected.GET("/incidents/stats", statsHandler.GetStats)
+		protected.GET("/location/heatmap", locationHandler.Heatmap)
+
+		// Проверка координат по исторической геометрии зон
+		protected.POST("/location/replay", locationHandler.ReplayLocation)
+
+		// Прием предупреждений CAP 1.2
+		protected.POST("/cap/alerts", incidentHandler.IngestCAP)
+
+		// Выданные ключи API (ключ из API_KEY через API не отзывается)
+		protected.POST("/apikeys", apiKeyHandler.Create)
+		protected.GET("/apikeys", apiKeyHandler.List)
+		protected.DELETE("/apikeys/:id", apiKeyHandler.Revoke)
+
+		// Журнал доставки вебхуков
+		protected.GET("/webhooks/deliveries", webhookHandler.ListDeliveries)
 	}
 
 	return router
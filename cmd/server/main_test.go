@@ -0,0 +1,348 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"geo-alert-core/internal/domain"
+	"geo-alert-core/internal/handler"
+	"geo-alert-core/internal/infrastructure/webhook"
+	"geo-alert-core/internal/repository"
+	"geo-alert-core/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAPIKey = "test-key"
+
+// newTestRouter собирает сервер как main, но на in-memory хранилище и без Redis
+func newTestRouter(t *testing.T, webhookURL string) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	store := repository.NewMemoryStore()
+	incidentRepo := repository.NewMemoryIncidentRepository(store)
+	locationCheckRepo := repository.NewMemoryLocationCheckRepository(store)
+	apiKeyService := service.NewAPIKeyService(repository.NewMemoryAPIKeyRepository(store))
+	deliveryRepo := repository.NewMemoryWebhookDeliveryRepository(store)
+	deliveryService := service.NewWebhookDeliveryService(deliveryRepo, 0)
+	webhookSender := webhook.NewSender(webhookURL, 1, time.Millisecond)
+	webhookSender.SetDeliveryLog(deliveryRepo)
+
+	incidentService := service.NewIncidentService(incidentRepo)
+	locationService := service.NewLocationService(incidentRepo, locationCheckRepo, webhookSender)
+	statsService := service.NewStatsService(incidentRepo, 60, time.UTC)
+	tileService := service.NewTileService(incidentRepo, nil)
+	incidentService.AddCacheInvalidator(tileService)
+
+	healthService := service.NewHealthService("geo-alert-core", service.DefaultHealthCheckTimeout)
+
+	return setupRouter(
+		testAPIKey,
+		apiKeyService,
+		func(c *gin.Context) { c.Next() },
+		handler.NewHealthHandler(healthService),
+		handler.NewIncidentHandler(incidentService),
+		handler.NewLocationHandler(locationService),
+		handler.NewStatsHandler(statsService),
+		handler.NewFeedHandler(incidentService, "geo-alert-core"),
+		handler.NewTileHandler(tileService),
+		handler.NewAPIKeyHandler(apiKeyService),
+		handler.NewWebhookHandler(deliveryService),
+	)
+}
+
+func doJSON(t *testing.T, router http.Handler, method, path string, body any, out any) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var data []byte
+	if body != nil {
+		var err error
+		data, err = json.Marshal(body)
+		require.NoError(t, err)
+	}
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", testAPIKey)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if out != nil {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), out), w.Body.String())
+	}
+	return w
+}
+
+func TestServerWithMemoryStorage(t *testing.T) {
+	webhooks := make(chan webhook.WebhookPayload, 1)
+	webhookServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload webhook.WebhookPayload
+		if json.NewDecoder(r.Body).Decode(&payload) == nil {
+			webhooks <- payload
+		}
+	}))
+	defer webhookServer.Close()
+
+	router := newTestRouter(t, webhookServer.URL)
+
+	var incident domain.Incident
+	w := doJSON(t, router, http.MethodPost, "/api/v1/incidents", domain.CreateIncidentRequest{
+		Title: "Пожар", Latitude: 55.75, Longitude: 37.61, Radius: 500, Severity: domain.SeverityHigh,
+	}, &incident)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, 1, incident.Version)
+
+	var list struct {
+		Data []*domain.Incident `json:"data"`
+	}
+	w = doJSON(t, router, http.MethodGet, "/api/v1/incidents?q=пожар", nil, &list)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Len(t, list.Data, 1)
+	assert.Equal(t, incident.ID, list.Data[0].ID)
+
+	var response domain.LocationCheckResponse
+	w = doJSON(t, router, http.MethodPost, "/api/v1/location/check", domain.LocationCheckRequest{
+		UserID: "user-1", Latitude: 55.751, Longitude: 37.611,
+	}, &response)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.True(t, response.HasDanger)
+	require.Len(t, response.Incidents, 1)
+
+	select {
+	case payload := <-webhooks:
+		assert.Equal(t, "user-1", payload.UserID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not sent")
+	}
+
+	w = doJSON(t, router, http.MethodPost, "/api/v1/location/check", domain.LocationCheckRequest{
+		UserID: "user-2", Latitude: 10, Longitude: 10,
+	}, &response)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.False(t, response.HasDanger)
+
+	w = doJSON(t, router, http.MethodDelete, "/api/v1/incidents/"+incident.ID.String(), nil, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var versions struct {
+		Data []*domain.IncidentVersion `json:"data"`
+	}
+	w = doJSON(t, router, http.MethodGet, "/api/v1/incidents/"+incident.ID.String()+"/versions", nil, &versions)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Len(t, versions.Data, 2)
+	assert.NotNil(t, versions.Data[1].Incident.DeletedAt)
+
+	w = doJSON(t, router, http.MethodGet, "/api/v1/incidents/"+incident.ID.String(), nil, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+
+	var restored domain.Incident
+	w = doJSON(t, router, http.MethodPost, "/api/v1/incidents/"+incident.ID.String()+"/restore", nil, &restored)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Nil(t, restored.DeletedAt)
+	assert.Equal(t, 3, restored.Version)
+
+	w = doJSON(t, router, http.MethodDelete, "/api/v1/incidents/"+incident.ID.String(), nil, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var purge domain.PurgeResult
+	w = doJSON(t, router, http.MethodPost, "/api/v1/incidents/purge", nil, &purge)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Zero(t, purge.Purged, "deleted incidents are kept for the retention period")
+
+	w = doJSON(t, router, http.MethodPost, "/api/v1/incidents/purge?retention_days=0", nil, &purge)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, 1, purge.Purged)
+
+	w = doJSON(t, router, http.MethodPost, "/api/v1/incidents/"+incident.ID.String()+"/restore", nil, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+
+	w = doJSON(t, router, http.MethodGet, "/api/v1/system/ready", nil, nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func TestImportDeletedIncident(t *testing.T) {
+	router := newTestRouter(t, "http://127.0.0.1:0")
+
+	var incident domain.Incident
+	w := doJSON(t, router, http.MethodPost, "/api/v1/incidents", domain.CreateIncidentRequest{
+		Title: "Пожар", Latitude: 55.75, Longitude: 37.61, Radius: 500,
+	}, &incident)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/incidents.geojson", nil)
+	req.Header.Set("X-API-Key", testAPIKey)
+	export := httptest.NewRecorder()
+	router.ServeHTTP(export, req)
+	require.Equal(t, http.StatusOK, export.Code, export.Body.String())
+
+	w = doJSON(t, router, http.MethodDelete, "/api/v1/incidents/"+incident.ID.String(), nil, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	importExport := func(path string) domain.ImportReport {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(export.Body.Bytes()))
+		req.Header.Set("Content-Type", "application/geo+json")
+		req.Header.Set("X-API-Key", testAPIKey)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var report domain.ImportReport
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+		return report
+	}
+
+	// удаленный инцидент импортом не восстанавливается и не пересоздается - ни в dry run, ни на самом деле
+	for _, path := range []string{"/api/v1/incidents/import?dry_run=true", "/api/v1/incidents/import"} {
+		report := importExport(path)
+		assert.Equal(t, 1, report.Rejected, path)
+		assert.Zero(t, report.Created, path)
+		require.Len(t, report.Results, 1)
+		assert.Contains(t, report.Results[0].Error, "restore it before import")
+	}
+
+	w = doJSON(t, router, http.MethodPost, "/api/v1/incidents/"+incident.ID.String()+"/restore", nil, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	report := importExport("/api/v1/incidents/import")
+	assert.Equal(t, 1, report.Updated)
+}
+
+func TestIssuedAPIKeys(t *testing.T) {
+	router := newTestRouter(t, "http://127.0.0.1:0")
+
+	var issued domain.IssuedAPIKey
+	w := doJSON(t, router, http.MethodPost, "/api/v1/apikeys", domain.CreateAPIKeyRequest{Name: "operator"}, &issued)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	require.NotEmpty(t, issued.Key)
+
+	listWithKey := func(key string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/incidents", nil)
+		req.Header.Set("Authorization", "Bearer "+key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusOK, listWithKey(issued.Key))
+
+	// в списке сам ключ не показывается
+	w = doJSON(t, router, http.MethodGet, "/api/v1/apikeys", nil, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NotContains(t, w.Body.String(), issued.Key)
+	assert.Contains(t, w.Body.String(), issued.Prefix)
+
+	w = doJSON(t, router, http.MethodDelete, "/api/v1/apikeys/"+issued.ID.String(), nil, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusUnauthorized, listWithKey(issued.Key))
+	assert.Equal(t, http.StatusOK, listWithKey(testAPIKey))
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	webhookServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer webhookServer.Close()
+
+	router := newTestRouter(t, webhookServer.URL)
+
+	var incident domain.Incident
+	w := doJSON(t, router, http.MethodPost, "/api/v1/incidents", domain.CreateIncidentRequest{
+		Title: "Пожар", Latitude: 55.75, Longitude: 37.61, Radius: 500, Severity: domain.SeverityHigh,
+	}, &incident)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = doJSON(t, router, http.MethodPost, "/api/v1/location/check", domain.LocationCheckRequest{
+		UserID: "user-1", Latitude: 55.751, Longitude: 37.611,
+	}, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// вебхук отправляется в фоне, ждем, пока доставка завершится
+	var list struct {
+		Data []*domain.WebhookDelivery `json:"data"`
+	}
+	require.Eventually(t, func() bool {
+		w = doJSON(t, router, http.MethodGet, "/api/v1/webhooks/deliveries?status=failed", nil, &list)
+		return w.Code == http.StatusOK && len(list.Data) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	delivery := list.Data[0]
+	assert.Equal(t, "user-1", delivery.UserID)
+	assert.Equal(t, []string{incident.ID.String()}, delivery.IncidentIDs)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Contains(t, delivery.LastError, "status 500")
+	assert.NotNil(t, delivery.FinishedAt)
+
+	w = doJSON(t, router, http.MethodGet, "/api/v1/webhooks/deliveries?status=delivered", nil, &list)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Empty(t, list.Data)
+
+	w = doJSON(t, router, http.MethodGet, "/api/v1/webhooks/deliveries?status=lost", nil, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+}
+
+func TestUpdateIfMatch(t *testing.T) {
+	router := newTestRouter(t, "http://127.0.0.1:0")
+
+	var incident domain.Incident
+	w := doJSON(t, router, http.MethodPost, "/api/v1/incidents", domain.CreateIncidentRequest{
+		Title: "Пожар", Latitude: 55.75, Longitude: 37.61, Radius: 500,
+	}, &incident)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	update := func(ifMatch string) int {
+		body := strings.NewReader(`{"title":"Пожар на складе"}`)
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/incidents/"+incident.ID.String(), body)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", testAPIKey)
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusPreconditionRequired, update(""))
+	// версия должна быть ровно в одной паре кавычек
+	for _, header := range []string{`1`, `""1""`, `"1`, `1"`, `"+1"`, `""`, `W/"1"`, `"1", "2"`} {
+		assert.Equal(t, http.StatusBadRequest, update(header), header)
+	}
+	assert.Equal(t, http.StatusPreconditionFailed, update(`"2"`))
+	assert.Equal(t, http.StatusOK, update(`"1"`))
+	assert.Equal(t, http.StatusOK, update(`*`))
+}
+
+func TestListPagination(t *testing.T) {
+	router := newTestRouter(t, "http://127.0.0.1:0")
+
+	for _, title := range []string{"Первая", "Вторая", "Третья"} {
+		w := doJSON(t, router, http.MethodPost, "/api/v1/incidents", domain.CreateIncidentRequest{
+			Title: title, Latitude: 55.75, Longitude: 37.61, Radius: 500,
+		}, nil)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	var list struct {
+		Data     []domain.Incident `json:"data"`
+		Page     int               `json:"page"`
+		PageSize int               `json:"page_size"`
+	}
+	w := doJSON(t, router, http.MethodGet, "/api/v1/incidents?limit=2&page=2", nil, &list)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Len(t, list.Data, 1)
+	assert.Equal(t, 2, list.Page)
+	assert.Equal(t, 2, list.PageSize)
+
+	// неверный размер страницы не подменяется значением по умолчанию
+	for _, query := range []string{"limit=abc&page=2", "limit=0", "limit=101", "page_size=x", "page=0", "page=abc", "as_of=2024-05-01T00:00:00Z&limit=abc"} {
+		w := doJSON(t, router, http.MethodGet, "/api/v1/incidents?"+query, nil, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"geo-alert-core/internal/infrastructure/postgres"
+	"geo-alert-core/migrations"
+)
+
+const migrateUsage = `usage: server migrate <command>
+
+commands:
+  up            apply all pending migrations
+  down          roll back the last applied migration
+  to N          apply or roll back migrations to version N (0 rolls back everything)
+  status        list migrations and whether they are applied
+  baseline N    mark migrations up to N as applied without running them
+                (for databases migrated with scripts/migrate.sh)`
+
+// runMigrate выполняет подкоманду migrate
+func runMigrate(ctx context.Context, db *sql.DB, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%s", migrateUsage)
+	}
+
+	migrator, err := postgres.NewMigrator(db, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	switch command := args[0]; {
+	case command == "up" && len(args) == 1:
+		err = migrator.Up(ctx)
+	case command == "down" && len(args) == 1:
+		err = migrator.Down(ctx)
+	case (command == "to" || command == "baseline") && len(args) == 2:
+		version, convErr := strconv.Atoi(args[1])
+		if convErr != nil || version < 0 {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		if command == "to" {
+			err = migrator.To(ctx, version)
+		} else {
+			err = migrator.Baseline(ctx, version)
+		}
+	case command == "status" && len(args) == 1:
+	default:
+		return fmt.Errorf("%s", migrateUsage)
+	}
+	if err != nil {
+		return err
+	}
+
+	return printMigrationStatus(ctx, migrator, out)
+}
+
+func printMigrationStatus(ctx context.Context, migrator *postgres.Migrator, out io.Writer) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, status := range statuses {
+		state, appliedAt := "pending", ""
+		switch {
+		case status.Applied && status.Name == "":
+			state = "applied, missing in binary"
+		case status.Modified:
+			state = "applied, modified"
+		case status.Applied:
+			state = "applied"
+		}
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.UTC().Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%03d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,274 @@
+// Package apiclient - HTTP-клиент API geo-alert-core для утилиты geoalertctl.
+// Запросы и ответы - те же доменные типы, что отдают handlers.
+package apiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"geo-alert-core/internal/domain"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIError - ответ API с кодом 4xx/5xx
+type APIError struct {
+	StatusCode int
+	Message    string `json:"error"`
+	Details    string `json:"details"`
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	if e.Details != "" {
+		msg += ": " + e.Details
+	}
+	return fmt.Sprintf("api error %d: %s", e.StatusCode, msg)
+}
+
+// IncidentList - страница списка инцидентов
+type IncidentList struct {
+	Data       []*domain.Incident `json:"data"`
+	Page       int                `json:"page"`
+	PageSize   int                `json:"page_size"`
+	NextCursor string             `json:"next_cursor"`
+	Total      *int               `json:"total,omitempty"`
+}
+
+type Client struct {
+	baseURL string
+	apiKey  string
+	http    *http.Client
+}
+
+func New(baseURL, apiKey string) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		http:    &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+func (c *Client) ListIncidents(ctx context.Context, query url.Values) (*IncidentList, error) {
+	var list IncidentList
+	if _, err := c.do(ctx, http.MethodGet, "/api/v1/incidents?"+query.Encode(), nil, nil, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// GetIncident возвращает инцидент и его ETag для последующего обновления
+func (c *Client) GetIncident(ctx context.Context, id uuid.UUID) (*domain.Incident, string, error) {
+	var incident domain.Incident
+	header, err := c.do(ctx, http.MethodGet, "/api/v1/incidents/"+id.String(), nil, nil, &incident)
+	if err != nil {
+		return nil, "", err
+	}
+	return &incident, header.Get("ETag"), nil
+}
+
+func (c *Client) CreateIncident(ctx context.Context, req *domain.CreateIncidentRequest) (*domain.Incident, error) {
+	var incident domain.Incident
+	if _, err := c.doJSON(ctx, http.MethodPost, "/api/v1/incidents", req, nil, &incident); err != nil {
+		return nil, err
+	}
+	return &incident, nil
+}
+
+// UpdateIncident меняет инцидент, если его версия все еще совпадает с etag
+func (c *Client) UpdateIncident(ctx context.Context, id uuid.UUID, req *domain.UpdateIncidentRequest, etag string) (*domain.Incident, error) {
+	var incident domain.Incident
+	header := http.Header{"If-Match": []string{etag}}
+	if _, err := c.doJSON(ctx, http.MethodPut, "/api/v1/incidents/"+id.String(), req, header, &incident); err != nil {
+		return nil, err
+	}
+	return &incident, nil
+}
+
+// DeleteIncident помечает зону удаленной, до очистки ее можно вернуть RestoreIncident
+func (c *Client) DeleteIncident(ctx context.Context, id uuid.UUID) error {
+	_, err := c.do(ctx, http.MethodDelete, "/api/v1/incidents/"+id.String(), nil, nil, nil)
+	return err
+}
+
+func (c *Client) RestoreIncident(ctx context.Context, id uuid.UUID) (*domain.Incident, error) {
+	var incident domain.Incident
+	if _, err := c.do(ctx, http.MethodPost, "/api/v1/incidents/"+id.String()+"/restore", nil, nil, &incident); err != nil {
+		return nil, err
+	}
+	return &incident, nil
+}
+
+// PurgeIncidents физически удаляет зоны, удаленные больше retentionDays дней назад.
+// retentionDays == nil - срок из настроек сервера.
+func (c *Client) PurgeIncidents(ctx context.Context, retentionDays *int) (*domain.PurgeResult, error) {
+	query := url.Values{}
+	if retentionDays != nil {
+		query.Set("retention_days", strconv.Itoa(*retentionDays))
+	}
+
+	var result domain.PurgeResult
+	if _, err := c.do(ctx, http.MethodPost, "/api/v1/incidents/purge?"+query.Encode(), nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ImportIncidents загружает GeoJSON (application/geo+json) или CSV (text/csv)
+func (c *Client) ImportIncidents(ctx context.Context, body io.Reader, contentType string, dryRun bool) (*domain.ImportReport, error) {
+	query := url.Values{}
+	query.Set("dry_run", strconv.FormatBool(dryRun))
+	query.Set("full_report", "true")
+
+	var report domain.ImportReport
+	header := http.Header{"Content-Type": []string{contentType}}
+	if _, err := c.do(ctx, http.MethodPost, "/api/v1/incidents/import?"+query.Encode(), body, header, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (c *Client) CheckLocation(ctx context.Context, req *domain.LocationCheckRequest) (*domain.LocationCheckResponse, error) {
+	var response domain.LocationCheckResponse
+	if _, err := c.doJSON(ctx, http.MethodPost, "/api/v1/location/check", req, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// IssueAPIKey выдает ключ API; сам ключ есть только в этом ответе
+func (c *Client) IssueAPIKey(ctx context.Context, name string) (*domain.IssuedAPIKey, error) {
+	var issued domain.IssuedAPIKey
+	if _, err := c.doJSON(ctx, http.MethodPost, "/api/v1/apikeys", domain.CreateAPIKeyRequest{Name: name}, nil, &issued); err != nil {
+		return nil, err
+	}
+	return &issued, nil
+}
+
+func (c *Client) ListAPIKeys(ctx context.Context) ([]*domain.APIKey, error) {
+	var list struct {
+		Data []*domain.APIKey `json:"data"`
+	}
+	if _, err := c.do(ctx, http.MethodGet, "/api/v1/apikeys", nil, nil, &list); err != nil {
+		return nil, err
+	}
+	return list.Data, nil
+}
+
+func (c *Client) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	_, err := c.do(ctx, http.MethodDelete, "/api/v1/apikeys/"+id.String(), nil, nil, nil)
+	return err
+}
+
+// ListWebhookDeliveries возвращает журнал доставки вебхуков, новые первыми.
+// status == "" - все состояния, limit == 0 - размер выборки по умолчанию.
+func (c *Client) ListWebhookDeliveries(ctx context.Context, status string, limit int) ([]*domain.WebhookDelivery, error) {
+	query := url.Values{}
+	if status != "" {
+		query.Set("status", status)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	var list struct {
+		Data []*domain.WebhookDelivery `json:"data"`
+	}
+	if _, err := c.do(ctx, http.MethodGet, "/api/v1/webhooks/deliveries?"+query.Encode(), nil, nil, &list); err != nil {
+		return nil, err
+	}
+	return list.Data, nil
+}
+
+// Ready возвращает отчет /system/ready, в том числе при коде 503
+func (c *Client) Ready(ctx context.Context) (*domain.HealthReport, error) {
+	var report domain.HealthReport
+	_, err := c.do(ctx, http.MethodGet, "/api/v1/system/ready", nil, nil, &report)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusServiceUnavailable && report.Status != "" {
+		return &report, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// Metrics возвращает метрики в текстовом формате Prometheus
+func (c *Client) Metrics(ctx context.Context) (string, error) {
+	var buf bytes.Buffer
+	if _, err := c.do(ctx, http.MethodGet, "/metrics", nil, nil, &buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (c *Client) doJSON(ctx context.Context, method, path string, body any, header http.Header, out any) (http.Header, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("Content-Type", "application/json")
+	return c.do(ctx, method, path, bytes.NewReader(data), header, out)
+}
+
+// do выполняет запрос и разбирает ответ в out: *bytes.Buffer получает тело как есть, остальное - JSON
+func (c *Client) do(ctx context.Context, method, path string, body io.Reader, header http.Header, out any) (http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var apiErr *APIError
+	if resp.StatusCode >= 400 {
+		apiErr = &APIError{StatusCode: resp.StatusCode}
+		_ = json.Unmarshal(data, apiErr)
+	}
+
+	switch out := out.(type) {
+	case nil:
+	case *bytes.Buffer:
+		out.Write(data)
+	default:
+		// тело ошибки тоже разбираем: /system/ready отвечает отчетом и при 503
+		if err := json.Unmarshal(data, out); err != nil && apiErr == nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+
+	if apiErr != nil {
+		return resp.Header, apiErr
+	}
+	return resp.Header, nil
+}
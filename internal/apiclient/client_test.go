@@ -0,0 +1,144 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"geo-alert-core/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_UpdateIncident(t *testing.T) {
+	id := uuid.New()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("X-API-Key"))
+		assert.Equal(t, "/api/v1/incidents/"+id.String(), r.URL.Path)
+
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("ETag", `"3"`)
+			json.NewEncoder(w).Encode(domain.Incident{ID: id, Title: "old", Version: 3})
+		case http.MethodPut:
+			assert.Equal(t, `"3"`, r.Header.Get("If-Match"))
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+			var req domain.UpdateIncidentRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Nil(t, req.Radius)
+			json.NewEncoder(w).Encode(domain.Incident{ID: id, Title: *req.Title, Version: 4})
+		}
+	}))
+	defer server.Close()
+
+	client := New(server.URL+"/", "secret")
+	_, etag, err := client.GetIncident(context.Background(), id)
+	require.NoError(t, err)
+
+	title := "new"
+	incident, err := client.UpdateIncident(context.Background(), id, &domain.UpdateIncidentRequest{Title: &title}, etag)
+	require.NoError(t, err)
+	assert.Equal(t, "new", incident.Title)
+	assert.Equal(t, 4, incident.Version)
+}
+
+func TestClient_APIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"Validation failed","details":"radius must be positive"}`))
+	}))
+	defer server.Close()
+
+	_, err := New(server.URL, "").CreateIncident(context.Background(), &domain.CreateIncidentRequest{Title: "zone"})
+
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	assert.Equal(t, "api error 400: Validation failed: radius must be positive", err.Error())
+}
+
+func TestClient_ReadyUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(domain.HealthReport{
+			Status:       domain.HealthUnavailable,
+			Dependencies: []*domain.DependencyHealth{{Name: "postgres", Status: domain.DependencyDown}},
+		})
+	}))
+	defer server.Close()
+
+	// 503 с отчетом - это ответ, а не ошибка
+	report, err := New(server.URL, "").Ready(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, domain.HealthUnavailable, report.Status)
+	require.Len(t, report.Dependencies, 1)
+}
+
+func TestClient_ImportIncidents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "text/csv", r.Header.Get("Content-Type"))
+		assert.Equal(t, "true", r.URL.Query().Get("dry_run"))
+		json.NewEncoder(w).Encode(domain.ImportReport{DryRun: true, Created: 2})
+	}))
+	defer server.Close()
+
+	report, err := New(server.URL, "").ImportIncidents(context.Background(), strings.NewReader("title\n"), "text/csv", true)
+	require.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 2, report.Created)
+}
+
+func TestClient_APIKeys(t *testing.T) {
+	id := uuid.New()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/apikeys":
+			var req domain.CreateAPIKeyRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(domain.IssuedAPIKey{APIKey: domain.APIKey{ID: id, Name: req.Name, Prefix: "abcd1234"}, Key: "abcd1234ffff"})
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/apikeys":
+			w.Write([]byte(`{"data":[{"id":"` + id.String() + `","name":"operator","prefix":"abcd1234"}]}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/api/v1/apikeys/"+id.String():
+			w.Write([]byte(`{"message":"API key revoked successfully"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := New(server.URL, "secret")
+	issued, err := client.IssueAPIKey(context.Background(), "operator")
+	require.NoError(t, err)
+	assert.Equal(t, "abcd1234ffff", issued.Key)
+	assert.Equal(t, "operator", issued.Name)
+
+	keys, err := client.ListAPIKeys(context.Background())
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, id, keys[0].ID)
+
+	require.NoError(t, client.RevokeAPIKey(context.Background(), id))
+}
+
+func TestClient_ListWebhookDeliveries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/webhooks/deliveries", r.URL.Path)
+		assert.Equal(t, "failed", r.URL.Query().Get("status"))
+		assert.Equal(t, "10", r.URL.Query().Get("limit"))
+		w.Write([]byte(`{"data":[{"id":"` + uuid.NewString() + `","user_id":"user1","status":"failed","attempts":3}]}`))
+	}))
+	defer server.Close()
+
+	deliveries, err := New(server.URL, "secret").ListWebhookDeliveries(context.Background(), "failed", 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, "user1", deliveries[0].UserID)
+	assert.Equal(t, 3, deliveries[0].Attempts)
+}
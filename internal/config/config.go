@@ -5,14 +5,26 @@ import (
 	"os"
 	"strconv"
 	"time"
+	// baza chasovyh poyasov vnutri binarnika, v minimalnom obraze ee net
+	_ "time/tzdata"
 
 	"github.com/joho/godotenv"
 )
 
+// hranilischa dannyh
+const (
+	StoragePostgres = "postgres"
+	// dannye v pamyati processa, teryayutsya pri perezapuske; dlya razrabotki i testov
+	StorageMemory = "memory"
+)
+
 type Config struct {
 	// servernye nastroyki
 	ServerPort string
 
+	// hranilische: postgres ili memory
+	Storage string
+
 	// postgres
 	PostgresHost     string
 	PostgresPort     string
@@ -20,12 +32,18 @@ type Config struct {
 	PostgresPassword string
 	PostgresDB       string
 	PostgresSSLMode  string
+	// primenyat' migracii pri starte servera
+	AutoMigrate bool
 
 	// redis
 	RedisHost     string
 	RedisPort     string
 	RedisPassword string
 	RedisDB       int
+	// Redis neobyazatelen: pri nedostupnosti komandy ne otpravlyayutsya RedisBreakerCooldown,
+	// kesh derzhitsya v pamyati processa
+	RedisTimeout         time.Duration
+	RedisBreakerCooldown time.Duration
 
 	// api
 	APIKey string
@@ -34,9 +52,38 @@ type Config struct {
 	WebhookURL           string
 	WebhookRetryAttempts int
 	WebhookRetryDelaySec time.Duration
+	// bol'she stol'kih neotpravlennyh vebhukov - /system/ready v sostoyanii degraded
+	WebhookBacklogLimit int
+	// skol'ko hranitsya zhurnal dostavki vebhukov (0 - ne chistitsya)
+	WebhookDeliveryRetention time.Duration
 
-	// statistika
+	// statistika (0 - agregaty ne sobirayutsya, vse schitaetsya po proverkam)
 	StatsTimeWindowMinutes int
+	StatsRollupInterval    time.Duration
+	// naskol'ko agregaty otstayut ot tekushchego momenta: proverki, zakommichennye pozzhe, v nih ne popadut
+	StatsRollupSettle time.Duration
+	// chasovoy poyas dlya okon today/this_week
+	StatsTimezone *time.Location
+
+	// idempotentnost
+	IdempotencyTTL time.Duration
+
+	// skol'ko udalennye incidenty hranyatsya do ochistki (POST /incidents/purge)
+	DeletedIncidentRetention time.Duration
+
+	// CAP lenta (pustoy URL - opros vyklyuchen)
+	CAPFeedURL      string
+	CAPPollInterval time.Duration
+
+	// otpravitel nashih CAP preduprezhdeniy v publichnoy lente
+	CAPSender string
+
+	// trassirovka OpenTelemetry (pustoy endpoint - spany ne eksportiruyutsya)
+	OTLPEndpoint    string
+	OTelServiceName string
+
+	// uroven' logov: debug, info, warn, error
+	LogLevel string
 }
 
 func Load() (*Config, error) {
@@ -45,31 +92,93 @@ func Load() (*Config, error) {
 	cfg := &Config{
 		ServerPort: getEnv("SERVER_PORT", "8080"),
 
+		Storage: getEnv("STORAGE", StoragePostgres),
+
 		PostgresHost:     getEnv("POSTGRES_HOST", "localhost"),
 		PostgresPort:     getEnv("POSTGRES_PORT", "5432"),
 		PostgresUser:     getEnv("POSTGRES_USER", "postgres"),
 		PostgresPassword: getEnv("POSTGRES_PASSWORD", "postgres"),
 		PostgresDB:       getEnv("POSTGRES_DB", "geo_alert"),
 		PostgresSSLMode:  getEnv("POSTGRES_SSL_MODE", "disable"),
+		AutoMigrate:      getEnvAsBool("AUTO_MIGRATE", false),
 
 		RedisHost:     getEnv("REDIS_HOST", "localhost"),
 		RedisPort:     getEnv("REDIS_PORT", "6379"),
 		RedisPassword: getEnv("REDIS_PASSWORD", ""),
 		RedisDB:       getEnvAsInt("REDIS_DB", 0),
 
+		RedisTimeout:         time.Duration(getEnvAsInt("REDIS_TIMEOUT_MS", 200)) * time.Millisecond,
+		RedisBreakerCooldown: time.Duration(getEnvAsInt("REDIS_BREAKER_COOLDOWN_SECONDS", 10)) * time.Second,
+
 		APIKey: getEnv("API_KEY", ""),
 
 		WebhookURL:           getEnv("WEBHOOK_URL", "http://localhost:9090/webhook"),
 		WebhookRetryAttempts: getEnvAsInt("WEBHOOK_RETRY_ATTEMPTS", 3),
 		WebhookRetryDelaySec: time.Duration(getEnvAsInt("WEBHOOK_RETRY_DELAY_SECONDS", 5)) * time.Second,
+		WebhookBacklogLimit:  getEnvAsInt("WEBHOOK_BACKLOG_LIMIT", 1000),
+
+		WebhookDeliveryRetention: time.Duration(getEnvAsInt("WEBHOOK_DELIVERY_RETENTION_DAYS", 7)) * 24 * time.Hour,
 
 		StatsTimeWindowMinutes: getEnvAsInt("STATS_TIME_WINDOW_MINUTES", 60),
+		StatsRollupInterval:    time.Duration(getEnvAsInt("STATS_ROLLUP_INTERVAL_SECONDS", 30)) * time.Second,
+		StatsRollupSettle:      time.Duration(getEnvAsInt("STATS_ROLLUP_SETTLE_SECONDS", 10)) * time.Second,
+
+		IdempotencyTTL: time.Duration(getEnvAsInt("IDEMPOTENCY_TTL_HOURS", 24)) * time.Hour,
+
+		DeletedIncidentRetention: time.Duration(getEnvAsInt("DELETED_INCIDENT_RETENTION_DAYS", 30)) * 24 * time.Hour,
+
+		CAPFeedURL:      getEnv("CAP_FEED_URL", ""),
+		CAPPollInterval: time.Duration(getEnvAsInt("CAP_POLL_INTERVAL_SECONDS", 60)) * time.Second,
+		CAPSender:       getEnv("CAP_SENDER", "geo-alert-core"),
+
+		OTLPEndpoint:    getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		OTelServiceName: getEnv("OTEL_SERVICE_NAME", "geo-alert-core"),
+
+		LogLevel: getEnv("LOG_LEVEL", "info"),
 	}
 
 	if cfg.APIKey == "" {
 		return nil, fmt.Errorf("API_KEY is not set")
 	}
 
+	if cfg.Storage != StoragePostgres && cfg.Storage != StorageMemory {
+		return nil, fmt.Errorf("STORAGE must be %s or %s", StoragePostgres, StorageMemory)
+	}
+
+	if cfg.RedisTimeout <= 0 {
+		return nil, fmt.Errorf("REDIS_TIMEOUT_MS must be positive")
+	}
+
+	if cfg.RedisBreakerCooldown <= 0 {
+		return nil, fmt.Errorf("REDIS_BREAKER_COOLDOWN_SECONDS must be positive")
+	}
+
+	if cfg.CAPPollInterval <= 0 {
+		return nil, fmt.Errorf("CAP_POLL_INTERVAL_SECONDS must be positive")
+	}
+
+	statsTimezone, err := time.LoadLocation(getEnv("STATS_TIMEZONE", "UTC"))
+	if err != nil {
+		return nil, fmt.Errorf("STATS_TIMEZONE is invalid: %w", err)
+	}
+	cfg.StatsTimezone = statsTimezone
+
+	if cfg.StatsRollupInterval < 0 {
+		return nil, fmt.Errorf("STATS_ROLLUP_INTERVAL_SECONDS must not be negative")
+	}
+
+	if cfg.StatsRollupSettle < 0 {
+		return nil, fmt.Errorf("STATS_ROLLUP_SETTLE_SECONDS must not be negative")
+	}
+
+	if cfg.DeletedIncidentRetention < 0 {
+		return nil, fmt.Errorf("DELETED_INCIDENT_RETENTION_DAYS must not be negative")
+	}
+
+	if cfg.WebhookDeliveryRetention < 0 {
+		return nil, fmt.Errorf("WEBHOOK_DELIVERY_RETENTION_DAYS must not be negative")
+	}
+
 	return cfg, nil
 }
 
@@ -94,10 +203,19 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return value
 }
 
-// vozvrashyaem podkluchenie k postgres
+func getEnvAsBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// vozvrashyaem podkluchenie k postgres. Sessiya v UTC: kolonki TIMESTAMP hranyat vremya UTC,
+// i NOW() i CURRENT_TIMESTAMP v sravneniyah i defoltah dolzhny davat' to zhe vremya
 func (c *Config) GetPostgresDSN() string {
 	return fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s timezone=UTC",
 		c.PostgresHost,
 		c.PostgresPort,
 		c.PostgresUser,
@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey - выданный ключ API. Сам ключ показывается только при выдаче
+type APIKey struct {
+	ID        uuid.UUID  `json:"id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"` // первые символы ключа, чтобы узнать его в списке
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// IssuedAPIKey - ответ на выдачу ключа, единственный раз, когда виден сам ключ
+type IssuedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// CreateAPIKeyRequest - запрос на выдачу ключа
+type CreateAPIKeyRequest struct {
+	Name string `json:"name" binding:"required"` // кому или для чего выдан
+}
@@ -0,0 +1,21 @@
+package domain
+
+import "github.com/google/uuid"
+
+// CAPIngestResult - итог обработки одного CAP-предупреждения
+type CAPIngestResult struct {
+	Identifier  string      `json:"identifier"`
+	MsgType     string      `json:"msg_type"`
+	Created     []uuid.UUID `json:"created"`
+	Updated     []uuid.UUID `json:"updated"`
+	Deactivated []uuid.UUID `json:"deactivated"`
+	Unchanged   int         `json:"unchanged"`
+	Ignored     bool        `json:"ignored,omitempty"`
+	Reason      string      `json:"reason,omitempty"` // почему предупреждение пропущено
+	Error       string      `json:"error,omitempty"`  // предупреждение отклонено
+}
+
+// Changed - изменились ли зоны после обработки
+func (r *CAPIngestResult) Changed() bool {
+	return len(r.Created)+len(r.Updated)+len(r.Deactivated) > 0
+}
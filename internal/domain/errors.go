@@ -6,4 +6,19 @@ var (
 	ErrIncidentNotFound   = errors.New("incident not found")
 	ErrInvalidCoordinates = errors.New("invalid coordinates")
 	ErrInvalidRadius      = errors.New("radius must be positive")
+	ErrVersionConflict    = errors.New("incident was modified concurrently")
+	ErrInvalidSeverity    = errors.New("severity must be one of low, medium, high, critical")
+	ErrInvalidFilter      = errors.New("invalid filter")
+	ErrInvalidIncident    = errors.New("invalid incident data")
+	ErrInvalidTile        = errors.New("invalid tile coordinates")
+
+	ErrLocationCheckNotFound = errors.New("location check not found")
+
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrInvalidAPIKey  = errors.New("invalid api key data")
+
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+
+	// ErrRollupConflict - тот же период агрегатов статистики уже обработал другой экземпляр сервиса
+	ErrRollupConflict = errors.New("stats rollup watermark moved concurrently")
 )
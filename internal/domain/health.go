@@ -0,0 +1,35 @@
+package domain
+
+import "time"
+
+// Состояние отдельной зависимости
+const (
+	DependencyUp   = "up"
+	DependencyDown = "down"
+)
+
+// Общее состояние сервиса: degraded - отказала необязательная зависимость,
+// unavailable - обязательная, сервис не готов принимать запросы
+const (
+	HealthOK          = "ok"
+	HealthDegraded    = "degraded"
+	HealthUnavailable = "unavailable"
+)
+
+// DependencyHealth - результат проверки одной зависимости
+type DependencyHealth struct {
+	Name      string         `json:"name"`
+	Status    string         `json:"status"`
+	Required  bool           `json:"required"`
+	LatencyMs float64        `json:"latency_ms"`
+	Error     string         `json:"error,omitempty"`
+	Details   map[string]any `json:"details,omitempty"`
+}
+
+// HealthReport - результат проверки готовности
+type HealthReport struct {
+	Status       string              `json:"status"`
+	Service      string              `json:"service"`
+	CheckedAt    time.Time           `json:"checked_at"`
+	Dependencies []*DependencyHealth `json:"dependencies"`
+}
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Виды сетки тепловой карты
+const (
+	GridGeohash = "geohash"
+	GridHex     = "hex"
+)
+
+// HeatmapFilter - какие проверки координат и в какую сетку агрегировать
+type HeatmapFilter struct {
+	Grid      string
+	Precision int     // длина geohash (1-12) для GridGeohash
+	CellSize  float64 // длина стороны шестиугольника в метрах Web Mercator для GridHex
+	BBox      BoundingBox
+	From      time.Time
+	To        time.Time
+	// OnlyHits - только проверки, попавшие хотя бы в одну зону (или в IncidentID, если задан)
+	OnlyHits   bool
+	IncidentID *uuid.UUID
+}
+
+// HeatmapCell - ячейка сетки с числом проверок и уникальных пользователей
+type HeatmapCell struct {
+	Cell      string       `json:"cell"` // geohash или "i,j" шестиугольника
+	Latitude  float64      `json:"latitude"`
+	Longitude float64      `json:"longitude"`
+	Checks    int          `json:"checks"`
+	Users     int          `json:"users"`
+	Polygon   [][2]float64 `json:"polygon"`
+}
+
+// Heatmap - ответ с заполненными ячейками, пустые ячейки не возвращаются
+type Heatmap struct {
+	Grid      string         `json:"grid"`
+	Precision int            `json:"precision,omitempty"`
+	CellSize  float64        `json:"cell_size,omitempty"`
+	From      time.Time      `json:"from"`
+	To        time.Time      `json:"to"`
+	Cells     []*HeatmapCell `json:"cells"`
+}
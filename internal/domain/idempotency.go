@@ -0,0 +1,10 @@
+package domain
+
+// IdempotencyRecord - сохраненный результат запроса с ключом идемпотентности
+type IdempotencyRecord struct {
+	BodyHash    string `json:"body_hash"`
+	Completed   bool   `json:"completed"` // false - исходный запрос еще выполняется
+	StatusCode  int    `json:"status_code"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
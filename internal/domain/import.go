@@ -0,0 +1,67 @@
+package domain
+
+import "github.com/google/uuid"
+
+// Результат импорта одного объекта
+const (
+	ImportCreated  = "created"
+	ImportUpdated  = "updated"
+	ImportRejected = "rejected"
+)
+
+// ImportItem - разобранный объект из файла импорта.
+// Err заполнен, если объект не удалось разобрать, тогда Incident == nil.
+type ImportItem struct {
+	Index    int // номер объекта во входных данных (для CSV - номер строки)
+	Incident *Incident
+	Err      error
+}
+
+type ImportResult struct {
+	Index  int        `json:"index"`
+	ID     *uuid.UUID `json:"id,omitempty"`
+	Status string     `json:"status"`
+	Error  string     `json:"error,omitempty"`
+}
+
+// ImportOptions - режим импорта
+type ImportOptions struct {
+	DryRun bool
+	// RejectedOnly - в отчет попадают только отклоненные объекты, остальные лишь считаются.
+	// Нужно для больших CSV, где построчный отчет сам по себе занял бы много памяти.
+	RejectedOnly bool
+}
+
+// ImportReport - итог импорта. В режиме dry-run статусы показывают, что было бы сделано.
+type ImportReport struct {
+	DryRun       bool           `json:"dry_run"`
+	RejectedOnly bool           `json:"rejected_only,omitempty"`
+	Created      int            `json:"created"`
+	Updated      int            `json:"updated"`
+	Rejected     int            `json:"rejected"`
+	Results      []ImportResult `json:"results"`
+}
+
+func (r *ImportReport) Add(index int, id uuid.UUID, status string, err error) {
+	result := ImportResult{Index: index, Status: status}
+	if id != uuid.Nil {
+		result.ID = &id
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	switch status {
+	case ImportCreated:
+		r.Created++
+	case ImportUpdated:
+		r.Updated++
+	case ImportRejected:
+		r.Rejected++
+	}
+
+	if r.RejectedOnly && status != ImportRejected {
+		return
+	}
+	r.Results = append(r.Results, result)
+}
@@ -7,16 +7,66 @@ import (
 	"github.com/google/uuid"
 )
 
+// Incident - опасная зона: круг (центр + радиус) или полигон.
+// Для полигона Latitude/Longitude - его центр, а Radius - радиус описанной окружности.
 type Incident struct {
-	ID          uuid.UUID `json:"id" db:"id"`
-	Title       string    `json:"title" db:"title"`
-	Description string    `json:"description" db:"description"`
-	Latitude    float64   `json:"latitude" db:"latitude"`
-	Longitude   float64   `json:"longitude" db:"longitude"`
-	Radius      float64   `json:"radius" db:"radius"` // радиус в метрах
-	IsActive    bool      `json:"is_active" db:"is_active"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID          uuid.UUID    `json:"id" db:"id"`
+	Title       string       `json:"title" db:"title"`
+	Description string       `json:"description" db:"description"`
+	Latitude    float64      `json:"latitude" db:"latitude"`
+	Longitude   float64      `json:"longitude" db:"longitude"`
+	Radius      float64      `json:"radius" db:"radius"`          // радиус в метрах
+	Polygon     [][2]float64 `json:"polygon,omitempty" db:"area"` // внешнее кольцо [долгота, широта], nil - круг
+	Category    string       `json:"category" db:"category"`
+	Severity    string       `json:"severity" db:"severity"`
+	IsActive    bool         `json:"is_active" db:"is_active"`
+	Source      string       `json:"source" db:"source"`                       // manual, cap, ...
+	ExternalID  string       `json:"external_id,omitempty" db:"external_id"`   // ключ во внешнем источнике
+	EffectiveAt *time.Time   `json:"effective_at,omitempty" db:"effective_at"` // зона действует с этого момента
+	ExpiresAt   *time.Time   `json:"expires_at,omitempty" db:"expires_at"`     // и до этого момента
+	Urgency     string       `json:"urgency,omitempty" db:"urgency"`
+	Certainty   string       `json:"certainty,omitempty" db:"certainty"`
+	Version     int          `json:"version" db:"version"` // растет при каждом изменении, отдается как ETag
+	CreatedAt   time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at" db:"updated_at"`
+	DeletedAt   *time.Time   `json:"deleted_at,omitempty" db:"deleted_at"` // удалена, но еще не очищена
+}
+
+// Источники инцидентов
+const (
+	SourceManual = "manual"
+	SourceCAP    = "cap"
+)
+
+// Уровни опасности инцидента
+const (
+	SeverityLow      = "low"
+	SeverityMedium   = "medium"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+)
+
+// IsValidSeverity проверяет, что уровень опасности из допустимого списка
+func IsValidSeverity(severity string) bool {
+	switch severity {
+	case SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical:
+		return true
+	}
+	return false
+}
+
+// IncidentVersion - снимок инцидента, действовавший в интервале [ValidFrom, ValidTo)
+type IncidentVersion struct {
+	Version   int        `json:"version"`
+	ValidFrom time.Time  `json:"valid_from"`
+	ValidTo   *time.Time `json:"valid_to"` // nil - текущая версия
+	Incident  Incident   `json:"incident"`
+}
+
+// PurgeResult - итог очистки удаленных инцидентов
+type PurgeResult struct {
+	Purged        int       `json:"purged"`
+	DeletedBefore time.Time `json:"deleted_before"` // очищены удаленные раньше этого момента
 }
 
 // CreateIncidentRequest - запрос на создание инцидента
@@ -26,6 +76,8 @@ type CreateIncidentRequest struct {
 	Latitude    float64 `json:"latitude" binding:"required"`
 	Longitude   float64 `json:"longitude" binding:"required"`
 	Radius      float64 `json:"radius" binding:"required"`
+	Category    string  `json:"category"`
+	Severity    string  `json:"severity"` // по умолчанию medium
 }
 
 // UpdateIncidentRequest - запрос на обновление инцидента
@@ -35,5 +87,20 @@ type UpdateIncidentRequest struct {
 	Latitude    *float64 `json:"latitude"`
 	Longitude   *float64 `json:"longitude"`
 	Radius      *float64 `json:"radius"`
+	Category    *string  `json:"category"`
+	Severity    *string  `json:"severity"`
 	IsActive    *bool    `json:"is_active"`
 }
+
+// FeedEntry - зона для публичной ленты: действующая или снятая недавно
+type FeedEntry struct {
+	Incident *Incident
+	Previous *PreviousVersion // nil - первая версия
+}
+
+// PreviousVersion - предыдущая версия зоны, на которую ссылается сообщение об изменении или отмене
+type PreviousVersion struct {
+	Version   int
+	UpdatedAt time.Time
+	Published bool // зона была включена и не удалена
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Поля, по которым можно сортировать список инцидентов
+const (
+	SortByCreatedAt = "created_at"
+	SortByUpdatedAt = "updated_at"
+	SortByTitle     = "title"
+	SortByRadius    = "radius"
+	SortBySeverity  = "severity"
+	SortByDistance  = "distance"  // только вместе с Near
+	SortByRelevance = "relevance" // только вместе с Query
+)
+
+// IncidentFilter - фильтры, сортировка и пагинация списка инцидентов
+type IncidentFilter struct {
+	Query       string // полнотекстовый поиск по названию и описанию
+	IsActive    *bool
+	Category    string
+	Severities  []string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	UpdatedFrom *time.Time
+	UpdatedTo   *time.Time
+	BBox        *BoundingBox
+	Near        *NearPoint
+	Deleted     bool // true - только удаленные зоны (до очистки), иначе только неудаленные
+
+	SortBy   string
+	SortDesc bool
+
+	// Cursor имеет приоритет над Offset
+	Cursor       *IncidentCursor
+	Limit        int
+	Offset       int
+	IncludeTotal bool
+}
+
+// BoundingBox - прямоугольник в градусах, центр зоны должен попадать внутрь
+type BoundingBox struct {
+	MinLongitude float64
+	MinLatitude  float64
+	MaxLongitude float64
+	MaxLatitude  float64
+}
+
+// NearPoint - зоны, граница которых ближе Meters метров к точке
+type NearPoint struct {
+	Latitude  float64
+	Longitude float64
+	Meters    float64
+}
+
+// IncidentCursor - позиция keyset-пагинации: значение сортировки и id последней записи
+type IncidentCursor struct {
+	SortBy   string    `json:"s"`
+	SortDesc bool      `json:"d"`
+	Value    string    `json:"v"`
+	ID       uuid.UUID `json:"id"`
+}
+
+// IncidentPage - страница списка инцидентов
+type IncidentPage struct {
+	Items      []*Incident
+	Total      *int            // заполняется, только если запрошен IncludeTotal
+	NextCursor *IncidentCursor // nil - это последняя страница
+}
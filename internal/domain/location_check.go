@@ -14,6 +14,16 @@ type LocationCheck struct {
 	Longitude   float64   `json:"longitude" db:"longitude"`
 	CheckedAt   time.Time `json:"checked_at" db:"checked_at"`
 	WebhookSent bool      `json:"webhook_sent" db:"webhook_sent"`
+
+	// zony, v kotorye popala proverka (zapolnyaetsya tolko pri vygruzke)
+	IncidentIDs []uuid.UUID `json:"incident_ids,omitempty" db:"-"`
+}
+
+// filtr vygruzki proverok za period [From, To)
+type LocationCheckFilter struct {
+	UserID string // pusto - vse polzovateli
+	From   time.Time
+	To     time.Time
 }
 
 // zapros
@@ -29,7 +39,28 @@ type LocationCheckResponse struct {
 	Incidents []Incident `json:"incidents"`
 }
 
+// replay proverki na moment vremeni
+// esli koordinaty ne peredany, beretsya poslednyaya proverka polzovatelya do as_of
+type LocationReplayRequest struct {
+	UserID    string    `json:"user_id"`
+	Latitude  *float64  `json:"latitude"`
+	Longitude *float64  `json:"longitude"`
+	AsOf      time.Time `json:"as_of" binding:"required"`
+}
+
+type LocationReplayResponse struct {
+	AsOf      time.Time  `json:"as_of"`
+	UserID    string     `json:"user_id,omitempty"`
+	Latitude  float64    `json:"latitude"`
+	Longitude float64    `json:"longitude"`
+	CheckedAt *time.Time `json:"checked_at,omitempty"` // vremya ispolzovannoy proverki
+	HasDanger bool       `json:"has_danger"`
+	Incidents []Incident `json:"incidents"`
+}
+
 type IncidentStats struct {
-	ZoneID    uuid.UUID `json:"zone_id"`
-	UserCount int       `json:"user_count"`
+	ZoneID     uuid.UUID `json:"zone_id"`
+	Title      string    `json:"title"`
+	UserCount  int       `json:"user_count"`
+	CheckCount int       `json:"check_count"`
 }
@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Именованные периоды статистики, границы дней и недель - в часовом поясе статистики
+const (
+	StatsWindowLast15m  = "last_15m"
+	StatsWindowLastHour = "last_1h"
+	StatsWindowLast24h  = "last_24h"
+	StatsWindowToday    = "today"
+	StatsWindowThisWeek = "this_week"
+)
+
+// Сроки хранения агрегатов статистики: минутные нужны только для краев периода, часовые - для середины
+const (
+	RollupMinuteRetention = 48 * time.Hour
+	RollupHourRetention   = 35 * 24 * time.Hour
+)
+
+// StatsQuery - период статистики: Window, Minutes или From/To; без них - окно по умолчанию
+type StatsQuery struct {
+	Window  string
+	Minutes int
+	From    *time.Time
+	To      *time.Time
+}
+
+// StatsReport - статистика по активным зонам за период [From, To)
+type StatsReport struct {
+	Window string           `json:"window,omitempty"`
+	From   time.Time        `json:"from"`
+	To     time.Time        `json:"to"`
+	Data   []*IncidentStats `json:"data"`
+}
+
+// Шаг временного ряда статистики
+const (
+	BucketMinute = "minute"
+	BucketHour   = "hour"
+	BucketDay    = "day"
+)
+
+// BucketDuration возвращает длину шага или 0 для неизвестного шага
+func BucketDuration(bucket string) time.Duration {
+	switch bucket {
+	case BucketMinute:
+		return time.Minute
+	case BucketHour:
+		return time.Hour
+	case BucketDay:
+		return 24 * time.Hour
+	}
+	return 0
+}
+
+// TimeSeriesFilter - временной ряд по одной зоне, границы шагов считаются в UTC
+type TimeSeriesFilter struct {
+	IncidentID uuid.UUID
+	From       time.Time
+	To         time.Time
+	Bucket     string
+}
+
+// TimeSeriesPoint - значения за шаг, начинающийся в Time
+type TimeSeriesPoint struct {
+	Time    time.Time `json:"time"`
+	Users   int       `json:"users"`   // уникальные пользователи внутри зоны
+	Checks  int       `json:"checks"`  // проверки, попавшие в зону
+	Entries int       `json:"entries"` // входы: проверка в зоне, предыдущая проверка пользователя - вне ее
+}
+
+type IncidentTimeSeries struct {
+	IncidentID uuid.UUID          `json:"incident_id"`
+	Bucket     string             `json:"bucket"`
+	From       time.Time          `json:"from"`
+	To         time.Time          `json:"to"`
+	Points     []*TimeSeriesPoint `json:"points"`
+}
+
+// IncidentHit - проверка координат, попавшая в зону
+type IncidentHit struct {
+	IncidentID uuid.UUID
+	UserID     string
+	CheckedAt  time.Time
+}
+
+// StatsBucket - агрегат попаданий в зону за одну минуту
+type StatsBucket struct {
+	IncidentID uuid.UUID
+	Start      time.Time
+	Users      []string
+	Checks     int
+}
+
+// StatsCount - число уникальных пользователей (приблизительно) и проверок за период
+type StatsCount struct {
+	Users  int
+	Checks int
+}
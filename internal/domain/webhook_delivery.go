@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Состояния доставки вебхука
+const (
+	DeliveryPending   = "pending"   // отправляется или ждет повтора
+	DeliveryDelivered = "delivered" // получатель ответил 2xx
+	DeliveryFailed    = "failed"    // попытки кончились или отправку прервали
+)
+
+// WebhookDelivery - одна отправка вебхука о попадании в зоны, со всеми ее попытками
+type WebhookDelivery struct {
+	ID          uuid.UUID  `json:"id"`
+	UserID      string     `json:"user_id"`
+	IncidentIDs []string   `json:"incident_ids"`
+	URL         string     `json:"url"`
+	Status      string     `json:"status"`
+	Attempts    int        `json:"attempts"`
+	LastError   string     `json:"last_error,omitempty"` // ошибка последней неудачной попытки
+	CreatedAt   time.Time  `json:"created_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+// WebhookDeliveryFilter - выборка журнала доставок, новые первыми
+type WebhookDeliveryFilter struct {
+	Status string // пусто - все состояния
+	Limit  int
+}
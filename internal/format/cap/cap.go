@@ -0,0 +1,392 @@
+// Package cap - разбор предупреждений в формате Common Alerting Protocol 1.2
+// (OASIS CAP-V1.2) и Atom-лент с такими предупреждениями
+package cap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"geo-alert-core/internal/domain"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Namespace - пространство имен CAP 1.2
+const Namespace = "urn:oasis:names:tc:emergency:cap:1.2"
+
+// Значения status и msgType из спецификации
+const (
+	StatusActual = "Actual"
+
+	MsgTypeAlert  = "Alert"
+	MsgTypeUpdate = "Update"
+	MsgTypeCancel = "Cancel"
+)
+
+// ErrInvalidAlert - документ не является корректным CAP-предупреждением
+var ErrInvalidAlert = errors.New("invalid CAP alert")
+
+type Alert struct {
+	XMLName    xml.Name `xml:"urn:oasis:names:tc:emergency:cap:1.2 alert"`
+	Identifier string   `xml:"identifier"`
+	Sender     string   `xml:"sender"`
+	Sent       string   `xml:"sent"`
+	Status     string   `xml:"status"`
+	MsgType    string   `xml:"msgType"`
+	Scope      string   `xml:"scope"`
+	References string   `xml:"references,omitempty"`
+	Infos      []Info   `xml:"info"`
+}
+
+type Info struct {
+	Language    string   `xml:"language,omitempty"`
+	Categories  []string `xml:"category"`
+	Event       string   `xml:"event"`
+	Urgency     string   `xml:"urgency"`
+	Severity    string   `xml:"severity"`
+	Certainty   string   `xml:"certainty"`
+	Effective   string   `xml:"effective,omitempty"`
+	Onset       string   `xml:"onset,omitempty"`
+	Expires     string   `xml:"expires,omitempty"`
+	SenderName  string   `xml:"senderName,omitempty"`
+	Headline    string   `xml:"headline,omitempty"`
+	Description string   `xml:"description,omitempty"`
+	Instruction string   `xml:"instruction,omitempty"`
+	Areas       []Area   `xml:"area"`
+}
+
+type Area struct {
+	AreaDesc string   `xml:"areaDesc"`
+	Polygons []string `xml:"polygon"`
+	Circles  []string `xml:"circle"`
+}
+
+// Severity в CAP -> уровень опасности инцидента
+var severityMap = map[string]string{
+	"Extreme":  domain.SeverityCritical,
+	"Severe":   domain.SeverityHigh,
+	"Moderate": domain.SeverityMedium,
+	"Minor":    domain.SeverityLow,
+	"Unknown":  domain.SeverityMedium,
+}
+
+// MapSeverity переводит severity из CAP в уровень опасности инцидента
+func MapSeverity(severity string) string {
+	if mapped, ok := severityMap[severity]; ok {
+		return mapped
+	}
+	return domain.SeverityMedium
+}
+
+// Parse читает одно CAP-предупреждение
+func Parse(r io.Reader) (*Alert, error) {
+	var alert Alert
+	if err := xml.NewDecoder(r).Decode(&alert); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAlert, err)
+	}
+	if err := alert.validate(); err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+func (a *Alert) validate() error {
+	if strings.TrimSpace(a.Identifier) == "" {
+		return fmt.Errorf("%w: identifier is required", ErrInvalidAlert)
+	}
+	if a.Sender == "" {
+		return fmt.Errorf("%w: sender is required", ErrInvalidAlert)
+	}
+	switch a.MsgType {
+	case MsgTypeAlert, MsgTypeUpdate, MsgTypeCancel, "Ack", "Error":
+	default:
+		return fmt.Errorf("%w: unknown msgType %q", ErrInvalidAlert, a.MsgType)
+	}
+	if a.Sent != "" {
+		if _, err := parseTime(a.Sent); err != nil {
+			return fmt.Errorf("%w: sent: %v", ErrInvalidAlert, err)
+		}
+	}
+	return nil
+}
+
+// Key - ключ предупреждения "sender,identifier". По спецификации CAP identifier уникален
+// только в пределах отправителя, а запятых и пробелов в обоих полях быть не может.
+func (a *Alert) Key() string {
+	return a.Sender + "," + a.Identifier
+}
+
+// SentTime возвращает время отправки; нулевое, если sent не указан
+func (a *Alert) SentTime() time.Time {
+	// формат sent проверен при разборе
+	sent, _ := parseTime(a.Sent)
+	return sent
+}
+
+// Reference - ссылка на ранее выпущенное предупреждение
+type Reference struct {
+	Sender     string
+	Identifier string
+	Sent       string
+}
+
+// Key - ключ предупреждения, на которое ссылается references, в том же виде, что Alert.Key
+func (r Reference) Key() string {
+	return r.Sender + "," + r.Identifier
+}
+
+// ReferencedAlerts разбирает поле references: "sender,identifier,sent" через пробел
+func (a *Alert) ReferencedAlerts() ([]Reference, error) {
+	var refs []Reference
+	for _, token := range strings.Fields(a.References) {
+		parts := strings.Split(token, ",")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("%w: malformed reference %q", ErrInvalidAlert, token)
+		}
+		refs = append(refs, Reference{Sender: parts[0], Identifier: parts[1], Sent: parts[2]})
+	}
+	return refs, nil
+}
+
+// Incidents строит по предупреждению инциденты: по одному на каждый полигон и круг.
+// Первая зона получает ключ Key(), следующие - Key()#2, Key()#3 и т.д.
+// Берется первый блок info, остальные обычно дублируют его на других языках.
+func (a *Alert) Incidents() ([]*domain.Incident, error) {
+	if len(a.Infos) == 0 {
+		return nil, fmt.Errorf("%w: alert has no info block", ErrInvalidAlert)
+	}
+	info := a.Infos[0]
+
+	effective, err := firstTime(info.Effective, info.Onset, a.Sent)
+	if err != nil {
+		return nil, err
+	}
+	expires, err := firstTime(info.Expires)
+	if err != nil {
+		return nil, err
+	}
+
+	title := info.Headline
+	if title == "" {
+		title = info.Event
+	}
+	category := ""
+	if len(info.Categories) > 0 {
+		category = strings.ToLower(info.Categories[0])
+	}
+
+	var incidents []*domain.Incident
+	newIncident := func(area Area) *domain.Incident {
+		description := info.Description
+		if area.AreaDesc != "" {
+			description = strings.TrimSpace(area.AreaDesc + "\n" + description)
+		}
+		key := a.Key()
+		if n := len(incidents) + 1; n > 1 {
+			key += "#" + strconv.Itoa(n)
+		}
+		return &domain.Incident{
+			Title:       title,
+			Description: description,
+			Category:    category,
+			Severity:    MapSeverity(info.Severity),
+			IsActive:    true,
+			Source:      domain.SourceCAP,
+			ExternalID:  key,
+			EffectiveAt: effective,
+			ExpiresAt:   expires,
+			Urgency:     info.Urgency,
+			Certainty:   info.Certainty,
+		}
+	}
+
+	for _, area := range info.Areas {
+		for _, raw := range area.Polygons {
+			ring, err := parsePolygon(raw)
+			if err != nil {
+				return nil, err
+			}
+			incident := newIncident(area)
+			incident.Polygon = ring
+			incidents = append(incidents, incident)
+		}
+		for _, raw := range area.Circles {
+			lat, lon, radiusKm, err := parseCircle(raw)
+			if err != nil {
+				return nil, err
+			}
+			incident := newIncident(area)
+			incident.Latitude, incident.Longitude, incident.Radius = lat, lon, radiusKm*1000
+			incidents = append(incidents, incident)
+		}
+	}
+
+	if len(incidents) == 0 {
+		return nil, fmt.Errorf("%w: alert has no polygon or circle areas", ErrInvalidAlert)
+	}
+	return incidents, nil
+}
+
+// parsePolygon разбирает "lat,lon lat,lon ..." в кольцо вершин [lon, lat]
+func parsePolygon(raw string) ([][2]float64, error) {
+	pairs := strings.Fields(raw)
+	ring := make([][2]float64, 0, len(pairs))
+	for _, pair := range pairs {
+		lat, lon, err := parsePoint(pair)
+		if err != nil {
+			return nil, fmt.Errorf("%w: polygon: %v", ErrInvalidAlert, err)
+		}
+		ring = append(ring, [2]float64{lon, lat})
+	}
+	return ring, nil
+}
+
+// parseCircle разбирает "lat,lon radius", радиус в километрах
+func parseCircle(raw string) (lat, lon, radiusKm float64, err error) {
+	fields := strings.Fields(raw)
+	if len(fields) != 2 {
+		return 0, 0, 0, fmt.Errorf("%w: malformed circle %q", ErrInvalidAlert, raw)
+	}
+	lat, lon, err = parsePoint(fields[0])
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("%w: circle: %v", ErrInvalidAlert, err)
+	}
+	radiusKm, err = strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("%w: circle radius %q", ErrInvalidAlert, fields[1])
+	}
+	return lat, lon, radiusKm, nil
+}
+
+func parsePoint(pair string) (lat, lon float64, err error) {
+	latStr, lonStr, ok := strings.Cut(pair, ",")
+	if !ok {
+		return 0, 0, fmt.Errorf("malformed point %q", pair)
+	}
+	if lat, err = strconv.ParseFloat(latStr, 64); err != nil {
+		return 0, 0, fmt.Errorf("malformed latitude %q", latStr)
+	}
+	if lon, err = strconv.ParseFloat(lonStr, 64); err != nil {
+		return 0, 0, fmt.Errorf("malformed longitude %q", lonStr)
+	}
+	return lat, lon, nil
+}
+
+// firstTime возвращает первое непустое время из списка
+func firstTime(values ...string) (*time.Time, error) {
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		t, err := parseTime(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidAlert, err)
+		}
+		return &t, nil
+	}
+	return nil, nil
+}
+
+// В CAP время всегда с часовым поясом, формат "2003-06-17T14:57:00-07:00"
+func parseTime(value string) (time.Time, error) {
+	return time.Parse(time.RFC3339, strings.TrimSpace(value))
+}
+
+// Feed - содержимое Atom-ленты: ссылки на предупреждения и предупреждения внутри entry
+type Feed struct {
+	Links  []string
+	Alerts []*Alert
+}
+
+type atomFeed struct {
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID      string     `xml:"id"`
+	Links   []atomLink `xml:"link"`
+	Content struct {
+		Type  string `xml:"type,attr"`
+		Alert *Alert `xml:"urn:oasis:names:tc:emergency:cap:1.2 alert"`
+	} `xml:"content"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+// ParseDocument разбирает либо одиночное предупреждение, либо Atom-ленту
+func ParseDocument(data []byte) (*Feed, error) {
+	root, err := rootElement(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch root.Local {
+	case "alert":
+		alert, err := Parse(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		return &Feed{Alerts: []*Alert{alert}}, nil
+	case "feed":
+		return parseFeed(data)
+	default:
+		return nil, fmt.Errorf("%w: unexpected root element %q", ErrInvalidAlert, root.Local)
+	}
+}
+
+func parseFeed(data []byte) (*Feed, error) {
+	var atom atomFeed
+	if err := xml.Unmarshal(data, &atom); err != nil {
+		return nil, fmt.Errorf("%w: feed: %v", ErrInvalidAlert, err)
+	}
+
+	feed := &Feed{}
+	for _, entry := range atom.Entries {
+		if entry.Content.Alert != nil {
+			if err := entry.Content.Alert.validate(); err != nil {
+				return nil, err
+			}
+			feed.Alerts = append(feed.Alerts, entry.Content.Alert)
+			continue
+		}
+		if href := alertLink(entry.Links); href != "" {
+			feed.Links = append(feed.Links, href)
+		}
+	}
+	return feed, nil
+}
+
+// alertLink выбирает ссылку на CAP-документ: явный тип cap+xml, иначе alternate/первая
+func alertLink(links []atomLink) string {
+	for _, link := range links {
+		if strings.Contains(link.Type, "cap+xml") {
+			return link.Href
+		}
+	}
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return link.Href
+		}
+	}
+	return ""
+}
+
+func rootElement(data []byte) (xml.Name, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return xml.Name{}, fmt.Errorf("%w: %v", ErrInvalidAlert, err)
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return start.Name, nil
+		}
+	}
+}
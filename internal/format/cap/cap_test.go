@@ -0,0 +1,129 @@
+package cap
+
+import (
+	"geo-alert-core/internal/domain"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	require.NoError(t, err)
+	return data
+}
+
+func TestParse_Alert(t *testing.T) {
+	alert, err := Parse(strings.NewReader(string(readFixture(t, "alert.xml"))))
+	require.NoError(t, err)
+
+	assert.Equal(t, "MCHS-2024-0001", alert.Identifier)
+	assert.Equal(t, MsgTypeAlert, alert.MsgType)
+	require.Len(t, alert.Infos, 2)
+
+	incidents, err := alert.Incidents()
+	require.NoError(t, err)
+	require.Len(t, incidents, 2)
+
+	polygon := incidents[0]
+	assert.Equal(t, "alerts@mchs.example,MCHS-2024-0001", polygon.ExternalID)
+	assert.Equal(t, domain.SourceCAP, polygon.Source)
+	assert.Equal(t, "Подтопление набережной", polygon.Title)
+	assert.Equal(t, domain.SeverityHigh, polygon.Severity)
+	assert.Equal(t, "met", polygon.Category)
+	assert.Equal(t, "Immediate", polygon.Urgency)
+	assert.Equal(t, "Observed", polygon.Certainty)
+	require.Len(t, polygon.Polygon, 5)
+	// CAP пишет "lat,lon", у нас [lon, lat]
+	assert.Equal(t, [2]float64{37.60, 55.75}, polygon.Polygon[0])
+	require.NotNil(t, polygon.EffectiveAt)
+	require.NotNil(t, polygon.ExpiresAt)
+	assert.Equal(t, 24*time.Hour, polygon.ExpiresAt.Sub(*polygon.EffectiveAt))
+
+	circle := incidents[1]
+	assert.Equal(t, "alerts@mchs.example,MCHS-2024-0001#2", circle.ExternalID)
+	assert.Nil(t, circle.Polygon)
+	assert.InDelta(t, 55.70, circle.Latitude, 1e-9)
+	assert.InDelta(t, 37.50, circle.Longitude, 1e-9)
+	assert.InDelta(t, 1500, circle.Radius, 1e-9)
+}
+
+func TestParse_UpdateReferences(t *testing.T) {
+	alert, err := Parse(strings.NewReader(string(readFixture(t, "update.xml"))))
+	require.NoError(t, err)
+
+	refs, err := alert.ReferencedAlerts()
+	require.NoError(t, err)
+	require.Len(t, refs, 1)
+	assert.Equal(t, "MCHS-2024-0001", refs[0].Identifier)
+	assert.Equal(t, "alerts@mchs.example", refs[0].Sender)
+	assert.Equal(t, "alerts@mchs.example,MCHS-2024-0001", refs[0].Key())
+
+	incidents, err := alert.Incidents()
+	require.NoError(t, err)
+	require.Len(t, incidents, 1)
+	assert.Equal(t, domain.SeverityCritical, incidents[0].Severity)
+	// без effective действует с момента отправки
+	require.NotNil(t, incidents[0].EffectiveAt)
+	assert.Equal(t, "2024-05-01T14:00:00+03:00", incidents[0].EffectiveAt.Format(time.RFC3339))
+}
+
+func TestParse_Cancel(t *testing.T) {
+	alert, err := Parse(strings.NewReader(string(readFixture(t, "cancel.xml"))))
+	require.NoError(t, err)
+	assert.Equal(t, MsgTypeCancel, alert.MsgType)
+
+	_, err = alert.Incidents()
+	assert.ErrorIs(t, err, ErrInvalidAlert)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		xml  string
+	}{
+		{"not xml", "hello"},
+		{"no identifier", `<alert xmlns="` + Namespace + `"><sender>s</sender><msgType>Alert</msgType></alert>`},
+		{"bad msgType", `<alert xmlns="` + Namespace + `"><identifier>1</identifier><sender>s</sender><msgType>Foo</msgType></alert>`},
+		{"wrong namespace", `<alert xmlns="urn:oasis:names:tc:emergency:cap:1.1"><identifier>1</identifier></alert>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.xml))
+			assert.ErrorIs(t, err, ErrInvalidAlert)
+		})
+	}
+}
+
+func TestIncidents_MalformedArea(t *testing.T) {
+	alert := &Alert{
+		Identifier: "x",
+		Infos: []Info{{
+			Areas: []Area{{Circles: []string{"55.7;37.5 1"}}},
+		}},
+	}
+	_, err := alert.Incidents()
+	assert.ErrorIs(t, err, ErrInvalidAlert)
+}
+
+func TestParseDocument(t *testing.T) {
+	feed, err := ParseDocument(readFixture(t, "feed.xml"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/alerts/MCHS-2024-0001.xml"}, feed.Links)
+	require.Len(t, feed.Alerts, 1)
+	assert.Equal(t, "MCHS-2024-0002", feed.Alerts[0].Identifier)
+
+	single, err := ParseDocument(readFixture(t, "alert.xml"))
+	require.NoError(t, err)
+	assert.Empty(t, single.Links)
+	require.Len(t, single.Alerts, 1)
+
+	_, err = ParseDocument([]byte(`<rss></rss>`))
+	assert.ErrorIs(t, err, ErrInvalidAlert)
+}
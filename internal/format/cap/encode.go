@@ -0,0 +1,246 @@
+package cap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"geo-alert-core/internal/domain"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Категории из спецификации CAP 1.2, остальные категории инцидентов выгружаются как Other
+var categories = []string{"Geo", "Met", "Safety", "Security", "Rescue", "Fire", "Health", "Env", "Transport", "Infra", "CBRNE", "Other"}
+
+// уровень опасности инцидента -> severity в CAP
+var capSeverity = map[string]string{
+	domain.SeverityCritical: "Extreme",
+	domain.SeverityHigh:     "Severe",
+	domain.SeverityMedium:   "Moderate",
+	domain.SeverityLow:      "Minor",
+}
+
+// FromFeedEntry строит сообщение ленты о зоне с учетом ее предыдущей версии:
+//   - первая версия или зона, включенная снова, - Alert;
+//   - изменение опубликованной зоны - Update со ссылкой на сообщение о предыдущей версии;
+//   - выключение или удаление опубликованной зоны - Cancel со ссылкой на предыдущую версию;
+//   - истечение срока действия - Cancel со ссылкой на текущую версию.
+//
+// Возвращает nil, если подписчикам сообщать нечего: зону сняли, когда она уже не публиковалась.
+func FromFeedEntry(entry *domain.FeedEntry, sender string, now time.Time) *Alert {
+	incident := entry.Incident
+	alert := FromIncident(incident, sender)
+	published := incident.IsActive && incident.DeletedAt == nil
+	prevPublished := entry.Previous != nil && entry.Previous.Published
+
+	switch {
+	case published && incident.ExpiresAt != nil && !incident.ExpiresAt.After(now):
+		alert.References = reference(sender, alert.Identifier, incident.UpdatedAt)
+		alert.Identifier += "-expired"
+		alert.Sent = FormatTime(*incident.ExpiresAt)
+		alert.MsgType = MsgTypeCancel
+	case published:
+		if prevPublished {
+			alert.MsgType = MsgTypeUpdate
+			alert.References = previousReference(incident, entry.Previous, sender)
+		}
+	case prevPublished:
+		alert.MsgType = MsgTypeCancel
+		alert.References = previousReference(incident, entry.Previous, sender)
+	default:
+		return nil
+	}
+
+	return alert
+}
+
+func previousReference(incident *domain.Incident, previous *domain.PreviousVersion, sender string) string {
+	return reference(sender, alertIdentifier(incident.ID.String(), previous.Version), previous.UpdatedAt)
+}
+
+// reference - элемент references: "sender,identifier,sent"
+func reference(sender, identifier string, sent time.Time) string {
+	return sender + "," + identifier + "," + FormatTime(sent)
+}
+
+func alertIdentifier(id string, version int) string {
+	return fmt.Sprintf("%s-v%d", id, version)
+}
+
+// FromIncident строит CAP-предупреждение по инциденту.
+// Identifier включает версию: каждое изменение зоны - новое сообщение для подписчиков.
+func FromIncident(incident *domain.Incident, sender string) *Alert {
+	category := "Other"
+	for _, c := range categories {
+		if strings.EqualFold(c, incident.Category) {
+			category = c
+			break
+		}
+	}
+
+	severity, ok := capSeverity[incident.Severity]
+	if !ok {
+		severity = "Unknown"
+	}
+
+	event := incident.Category
+	if event == "" {
+		event = incident.Title
+	}
+
+	info := Info{
+		Categories:  []string{category},
+		Event:       event,
+		Urgency:     valueOr(incident.Urgency, "Unknown"),
+		Severity:    severity,
+		Certainty:   valueOr(incident.Certainty, "Unknown"),
+		Headline:    incident.Title,
+		Description: incident.Description,
+	}
+	if incident.EffectiveAt != nil {
+		info.Effective = FormatTime(*incident.EffectiveAt)
+	}
+	if incident.ExpiresAt != nil {
+		info.Expires = FormatTime(*incident.ExpiresAt)
+	}
+
+	area := Area{AreaDesc: incident.Title}
+	if incident.Polygon != nil {
+		points := make([]string, len(incident.Polygon))
+		for i, p := range incident.Polygon {
+			points[i] = formatPoint(p[1], p[0])
+		}
+		area.Polygons = []string{strings.Join(points, " ")}
+	} else {
+		area.Circles = []string{formatPoint(incident.Latitude, incident.Longitude) + " " + strconv.FormatFloat(incident.Radius/1000, 'f', -1, 64)}
+	}
+	info.Areas = []Area{area}
+
+	return &Alert{
+		Identifier: alertIdentifier(incident.ID.String(), incident.Version),
+		Sender:     sender,
+		Sent:       FormatTime(incident.UpdatedAt),
+		Status:     StatusActual,
+		MsgType:    MsgTypeAlert,
+		Scope:      "Public",
+		Infos:      []Info{info},
+	}
+}
+
+// FormatTime - время в формате CAP: всегда со смещением, UTC записывается как -00:00
+func FormatTime(t time.Time) string {
+	s := t.Format("2006-01-02T15:04:05-07:00")
+	if strings.HasSuffix(s, "+00:00") {
+		s = strings.TrimSuffix(s, "+00:00") + "-00:00"
+	}
+	return s
+}
+
+func formatPoint(lat, lon float64) string {
+	return strconv.FormatFloat(lat, 'f', -1, 64) + "," + strconv.FormatFloat(lon, 'f', -1, 64)
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// FeedInfo - заголовок Atom-ленты
+type FeedInfo struct {
+	ID      string
+	Title   string
+	Link    string // адрес самой ленты
+	Updated time.Time
+}
+
+type atomOutEntry struct {
+	XMLName xml.Name       `xml:"entry"`
+	ID      string         `xml:"id"`
+	Title   string         `xml:"title"`
+	Updated string         `xml:"updated"`
+	Summary string         `xml:"summary,omitempty"`
+	Content atomOutContent `xml:"content"`
+}
+
+type atomOutContent struct {
+	Type  string `xml:"type,attr"`
+	Alert *Alert `xml:"alert"`
+}
+
+// FeedWriter пишет Atom-ленту с CAP-предупреждениями внутри entry
+type FeedWriter struct {
+	w       io.Writer
+	info    FeedInfo
+	started bool
+}
+
+func NewFeedWriter(w io.Writer, info FeedInfo) *FeedWriter {
+	return &FeedWriter{w: w, info: info}
+}
+
+func (w *FeedWriter) start() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+
+	if _, err := io.WriteString(w.w, xml.Header+`<feed xmlns="http://www.w3.org/2005/Atom">`); err != nil {
+		return err
+	}
+
+	type link struct {
+		Rel  string `xml:"rel,attr"`
+		Href string `xml:"href,attr"`
+	}
+	type author struct {
+		Name string `xml:"name"`
+	}
+
+	// элементы заголовка пишутся прямо внутрь feed, записи идут следом
+	enc := xml.NewEncoder(w.w)
+	for _, el := range []struct {
+		name  string
+		value any
+	}{
+		{"id", w.info.ID},
+		{"title", w.info.Title},
+		{"updated", w.info.Updated.UTC().Format(time.RFC3339)},
+		{"link", link{Rel: "self", Href: w.info.Link}},
+		{"author", author{Name: w.info.Title}},
+	} {
+		if err := enc.EncodeElement(el.value, xml.StartElement{Name: xml.Name{Local: el.name}}); err != nil {
+			return err
+		}
+	}
+	return enc.Flush()
+}
+
+// Write добавляет в ленту предупреждение по инциденту; время записи - время отправки предупреждения
+func (w *FeedWriter) Write(incident *domain.Incident, alert *Alert) error {
+	if err := w.start(); err != nil {
+		return err
+	}
+
+	entry := atomOutEntry{
+		ID:      "urn:uuid:" + incident.ID.String(),
+		Title:   incident.Title,
+		Updated: alert.SentTime().UTC().Format(time.RFC3339),
+		Summary: incident.Description,
+		Content: atomOutContent{Type: "application/cap+xml", Alert: alert},
+	}
+	if err := xml.NewEncoder(w.w).Encode(entry); err != nil {
+		return fmt.Errorf("failed to encode feed entry: %w", err)
+	}
+	return nil
+}
+
+func (w *FeedWriter) Close() error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w.w, `</feed>`)
+	return err
+}
@@ -0,0 +1,163 @@
+package cap
+
+import (
+	"bytes"
+	"geo-alert-core/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatTime(t *testing.T) {
+	utc := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, "2024-05-01T10:00:00-00:00", FormatTime(utc))
+
+	msk := time.FixedZone("MSK", 3*3600)
+	assert.Equal(t, "2024-05-01T13:00:00+03:00", FormatTime(utc.In(msk)))
+}
+
+func TestFeedWriter_RoundTrip(t *testing.T) {
+	updated := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	expires := updated.Add(6 * time.Hour)
+
+	circle := &domain.Incident{
+		ID:        uuid.New(),
+		Title:     "Пожар на складе",
+		Latitude:  55.75,
+		Longitude: 37.61,
+		Radius:    1500,
+		Category:  "fire",
+		Severity:  domain.SeverityCritical,
+		IsActive:  true,
+		ExpiresAt: &expires,
+		Version:   3,
+		UpdatedAt: updated,
+	}
+	polygon := &domain.Incident{
+		ID:        uuid.New(),
+		Title:     "Перекрытие <моста>",
+		Polygon:   [][2]float64{{37.60, 55.74}, {37.62, 55.74}, {37.62, 55.76}, {37.60, 55.74}},
+		Category:  "roadworks",
+		Severity:  domain.SeverityLow,
+		Urgency:   "Expected",
+		Version:   1,
+		UpdatedAt: updated,
+	}
+
+	var buf bytes.Buffer
+	w := NewFeedWriter(&buf, FeedInfo{ID: "urn:test:feed", Title: "Test", Link: "http://example.com/feed", Updated: updated})
+	for _, incident := range []*domain.Incident{circle, polygon} {
+		require.NoError(t, w.Write(incident, FromIncident(incident, "geo-alert")))
+	}
+	require.NoError(t, w.Close())
+
+	// лента должна читаться нашим же разбором CAP
+	feed, err := ParseDocument(buf.Bytes())
+	require.NoError(t, err)
+	require.Len(t, feed.Alerts, 2)
+
+	first := feed.Alerts[0]
+	assert.Equal(t, circle.ID.String()+"-v3", first.Identifier)
+	assert.Equal(t, "geo-alert", first.Sender)
+	assert.Equal(t, "2024-05-01T10:00:00-00:00", first.Sent)
+	assert.Equal(t, []string{"Fire"}, first.Infos[0].Categories)
+	assert.Equal(t, "Extreme", first.Infos[0].Severity)
+	assert.Equal(t, "Unknown", first.Infos[0].Urgency)
+
+	incidents, err := first.Incidents()
+	require.NoError(t, err)
+	require.Len(t, incidents, 1)
+	assert.InDelta(t, 1500, incidents[0].Radius, 1e-9)
+	assert.Equal(t, domain.SeverityCritical, incidents[0].Severity)
+	require.NotNil(t, incidents[0].ExpiresAt)
+	assert.True(t, expires.Equal(*incidents[0].ExpiresAt))
+
+	second := feed.Alerts[1]
+	assert.Equal(t, []string{"Other"}, second.Infos[0].Categories)
+	incidents, err = second.Incidents()
+	require.NoError(t, err)
+	assert.Equal(t, polygon.Polygon, incidents[0].Polygon)
+	assert.Equal(t, "Перекрытие <моста>", incidents[0].Title)
+}
+
+func TestFromFeedEntry(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	firstSent := now.Add(-2 * time.Hour)
+	updated := now.Add(-time.Hour)
+	id := uuid.New()
+
+	zone := func() *domain.Incident {
+		return &domain.Incident{ID: id, Title: "Пожар", Latitude: 55.75, Longitude: 37.61, Radius: 500, IsActive: true, Version: 2, UpdatedAt: updated}
+	}
+	published := &domain.PreviousVersion{Version: 1, UpdatedAt: firstSent, Published: true}
+	reference := "geo-alert," + id.String() + "-v1,2024-05-01T10:00:00-00:00"
+
+	t.Run("first version", func(t *testing.T) {
+		incident := zone()
+		incident.Version = 1
+		alert := FromFeedEntry(&domain.FeedEntry{Incident: incident}, "geo-alert", now)
+		require.NotNil(t, alert)
+		assert.Equal(t, MsgTypeAlert, alert.MsgType)
+		assert.Empty(t, alert.References)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		alert := FromFeedEntry(&domain.FeedEntry{Incident: zone(), Previous: published}, "geo-alert", now)
+		require.NotNil(t, alert)
+		assert.Equal(t, MsgTypeUpdate, alert.MsgType)
+		assert.Equal(t, id.String()+"-v2", alert.Identifier)
+		assert.Equal(t, reference, alert.References)
+	})
+
+	t.Run("enabled again", func(t *testing.T) {
+		alert := FromFeedEntry(&domain.FeedEntry{Incident: zone(), Previous: &domain.PreviousVersion{Version: 1, UpdatedAt: firstSent}}, "geo-alert", now)
+		require.NotNil(t, alert)
+		assert.Equal(t, MsgTypeAlert, alert.MsgType)
+	})
+
+	t.Run("deactivated", func(t *testing.T) {
+		incident := zone()
+		incident.IsActive = false
+		alert := FromFeedEntry(&domain.FeedEntry{Incident: incident, Previous: published}, "geo-alert", now)
+		require.NotNil(t, alert)
+		assert.Equal(t, MsgTypeCancel, alert.MsgType)
+		assert.Equal(t, id.String()+"-v2", alert.Identifier)
+		assert.Equal(t, reference, alert.References)
+	})
+
+	t.Run("deleted", func(t *testing.T) {
+		incident := zone()
+		incident.DeletedAt = &updated
+		alert := FromFeedEntry(&domain.FeedEntry{Incident: incident, Previous: published}, "geo-alert", now)
+		require.NotNil(t, alert)
+		assert.Equal(t, MsgTypeCancel, alert.MsgType)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		incident := zone()
+		expires := now.Add(-time.Minute)
+		incident.ExpiresAt = &expires
+		alert := FromFeedEntry(&domain.FeedEntry{Incident: incident, Previous: published}, "geo-alert", now)
+		require.NotNil(t, alert)
+		assert.Equal(t, MsgTypeCancel, alert.MsgType)
+		assert.Equal(t, id.String()+"-v2-expired", alert.Identifier)
+		assert.Equal(t, "2024-05-01T11:59:00-00:00", alert.Sent)
+		assert.Equal(t, "geo-alert,"+id.String()+"-v2,2024-05-01T11:00:00-00:00", alert.References)
+	})
+
+	t.Run("removed while not published", func(t *testing.T) {
+		incident := zone()
+		incident.IsActive = false
+		assert.Nil(t, FromFeedEntry(&domain.FeedEntry{Incident: incident}, "geo-alert", now))
+		assert.Nil(t, FromFeedEntry(&domain.FeedEntry{Incident: incident, Previous: &domain.PreviousVersion{Version: 1}}, "geo-alert", now))
+	})
+
+	// ссылки читаются нашим разбором
+	alert := FromFeedEntry(&domain.FeedEntry{Incident: zone(), Previous: published}, "geo-alert", now)
+	refs, err := alert.ReferencedAlerts()
+	require.NoError(t, err)
+	assert.Equal(t, []Reference{{Sender: "geo-alert", Identifier: id.String() + "-v1", Sent: "2024-05-01T10:00:00-00:00"}}, refs)
+}
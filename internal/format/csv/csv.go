@@ -0,0 +1,366 @@
+// Package csv - обмен инцидентами и проверками координат в CSV для табличных редакторов.
+// Полигон передается в колонке polygon в виде WKT: POLYGON((lon lat, lon lat, ...)).
+package csv
+
+import (
+	stdcsv "encoding/csv"
+	"errors"
+	"fmt"
+	"geo-alert-core/internal/domain"
+	"geo-alert-core/internal/geo"
+	"io"
+	"iter"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IncidentColumns - колонки выгрузки инцидентов. При импорте служебные колонки
+// (source, external_id, version, created_at, updated_at) игнорируются, так что выгрузку
+// можно отредактировать и загрузить обратно.
+var IncidentColumns = []string{
+	"id", "title", "description", "latitude", "longitude", "radius", "polygon",
+	"category", "severity", "is_active", "source", "external_id",
+	"effective_at", "expires_at", "urgency", "certainty",
+	"version", "created_at", "updated_at",
+}
+
+// CheckColumns - колонки выгрузки проверок координат
+var CheckColumns = []string{"id", "user_id", "latitude", "longitude", "checked_at", "webhook_sent", "incident_ids"}
+
+// IncidentWriter пишет инциденты построчно
+type IncidentWriter struct {
+	w       *stdcsv.Writer
+	started bool
+}
+
+func NewIncidentWriter(w io.Writer) *IncidentWriter {
+	return &IncidentWriter{w: stdcsv.NewWriter(w)}
+}
+
+func (w *IncidentWriter) Write(incident *domain.Incident) error {
+	if !w.started {
+		w.started = true
+		if err := w.w.Write(IncidentColumns); err != nil {
+			return err
+		}
+	}
+
+	polygon := ""
+	if incident.Polygon != nil {
+		polygon = formatWKT(incident.Polygon)
+	}
+
+	return w.w.Write([]string{
+		incident.ID.String(),
+		escapeFormula(incident.Title),
+		escapeFormula(incident.Description),
+		formatFloat(incident.Latitude),
+		formatFloat(incident.Longitude),
+		formatFloat(incident.Radius),
+		polygon,
+		escapeFormula(incident.Category),
+		escapeFormula(incident.Severity),
+		strconv.FormatBool(incident.IsActive),
+		escapeFormula(incident.Source),
+		escapeFormula(incident.ExternalID),
+		formatTime(incident.EffectiveAt),
+		formatTime(incident.ExpiresAt),
+		escapeFormula(incident.Urgency),
+		escapeFormula(incident.Certainty),
+		strconv.Itoa(incident.Version),
+		formatTime(&incident.CreatedAt),
+		formatTime(&incident.UpdatedAt),
+	})
+}
+
+// Close дописывает заголовок для пустой выгрузки и сбрасывает буфер
+func (w *IncidentWriter) Close() error {
+	if !w.started {
+		w.started = true
+		if err := w.w.Write(IncidentColumns); err != nil {
+			return err
+		}
+	}
+	w.w.Flush()
+	return w.w.Error()
+}
+
+// CheckWriter пишет проверки координат со связанными инцидентами
+type CheckWriter struct {
+	w       *stdcsv.Writer
+	started bool
+}
+
+func NewCheckWriter(w io.Writer) *CheckWriter {
+	return &CheckWriter{w: stdcsv.NewWriter(w)}
+}
+
+func (w *CheckWriter) Write(check *domain.LocationCheck) error {
+	if !w.started {
+		w.started = true
+		if err := w.w.Write(CheckColumns); err != nil {
+			return err
+		}
+	}
+
+	// id зон через ";", чтобы колонка не конфликтовала с разделителем CSV
+	ids := make([]string, len(check.IncidentIDs))
+	for i, id := range check.IncidentIDs {
+		ids[i] = id.String()
+	}
+
+	return w.w.Write([]string{
+		check.ID.String(),
+		escapeFormula(check.UserID),
+		formatFloat(check.Latitude),
+		formatFloat(check.Longitude),
+		formatTime(&check.CheckedAt),
+		strconv.FormatBool(check.WebhookSent),
+		strings.Join(ids, ";"),
+	})
+}
+
+func (w *CheckWriter) Close() error {
+	if !w.started {
+		w.started = true
+		if err := w.w.Write(CheckColumns); err != nil {
+			return err
+		}
+	}
+	w.w.Flush()
+	return w.w.Error()
+}
+
+// ReadIncidents читает инциденты построчно, не загружая файл в память.
+// Первая строка - заголовок, колонки ищутся по имени, неизвестные пропускаются.
+// Index каждого объекта - номер строки в файле. Ошибка строки попадает в ImportItem.Err,
+// после ошибки чтения самого файла (не формата строки) перебор останавливается.
+func ReadIncidents(r io.Reader) iter.Seq[domain.ImportItem] {
+	return func(yield func(domain.ImportItem) bool) {
+		reader := stdcsv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.ReuseRecord = true
+
+		header, err := reader.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = errors.New("file is empty")
+			}
+			yield(domain.ImportItem{Index: 1, Err: fmt.Errorf("%w: header: %v", domain.ErrInvalidIncident, err)})
+			return
+		}
+
+		columns := make(map[string]int, len(header))
+		for i, name := range header {
+			// Excel добавляет BOM в начало файла
+			name = strings.TrimPrefix(name, "\ufeff")
+			columns[strings.ToLower(strings.TrimSpace(name))] = i
+		}
+		if _, ok := columns["title"]; !ok {
+			yield(domain.ImportItem{Index: 1, Err: fmt.Errorf("%w: header must contain title column", domain.ErrInvalidIncident)})
+			return
+		}
+
+		for {
+			record, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+
+			var parseErr *stdcsv.ParseError
+			if errors.As(err, &parseErr) {
+				// битая строка: сообщаем и читаем дальше
+				if !yield(domain.ImportItem{Index: parseErr.StartLine, Err: fmt.Errorf("%w: %v", domain.ErrInvalidIncident, parseErr.Err)}) {
+					return
+				}
+				continue
+			}
+
+			line, _ := reader.FieldPos(0)
+			if err != nil {
+				yield(domain.ImportItem{Index: line, Err: err})
+				return
+			}
+
+			incident, err := parseIncident(row{columns: columns, record: record})
+			if !yield(domain.ImportItem{Index: line, Incident: incident, Err: err}) {
+				return
+			}
+		}
+	}
+}
+
+type row struct {
+	columns map[string]int
+	record  []string
+}
+
+func (r row) get(name string) string {
+	i, ok := r.columns[name]
+	if !ok || i >= len(r.record) {
+		return ""
+	}
+	return strings.TrimSpace(r.record[i])
+}
+
+func parseIncident(r row) (*domain.Incident, error) {
+	incident := &domain.Incident{
+		Title:       unescapeFormula(r.get("title")),
+		Description: unescapeFormula(r.get("description")),
+		Category:    unescapeFormula(r.get("category")),
+		Severity:    strings.ToLower(unescapeFormula(r.get("severity"))),
+		Urgency:     unescapeFormula(r.get("urgency")),
+		Certainty:   unescapeFormula(r.get("certainty")),
+		IsActive:    true,
+	}
+	if incident.Title == "" {
+		return nil, fmt.Errorf("%w: title is required", domain.ErrInvalidIncident)
+	}
+
+	if value := r.get("id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: id must be a UUID", domain.ErrInvalidIncident)
+		}
+		incident.ID = id
+	}
+
+	if value := r.get("is_active"); value != "" {
+		isActive, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: is_active must be true or false", domain.ErrInvalidIncident)
+		}
+		incident.IsActive = isActive
+	}
+
+	var err error
+	if incident.EffectiveAt, err = parseTime(r.get("effective_at")); err != nil {
+		return nil, fmt.Errorf("%w: effective_at: %v", domain.ErrInvalidIncident, err)
+	}
+	if incident.ExpiresAt, err = parseTime(r.get("expires_at")); err != nil {
+		return nil, fmt.Errorf("%w: expires_at: %v", domain.ErrInvalidIncident, err)
+	}
+
+	// полигон важнее круга: центр и радиус для него считает сервис
+	if value := r.get("polygon"); value != "" {
+		ring, err := parseWKT(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: polygon: %v", domain.ErrInvalidIncident, err)
+		}
+		incident.Polygon = ring
+		return incident, nil
+	}
+
+	for _, field := range []struct {
+		name string
+		dst  *float64
+	}{
+		{"latitude", &incident.Latitude},
+		{"longitude", &incident.Longitude},
+		{"radius", &incident.Radius},
+	} {
+		value := r.get(field.name)
+		if value == "" {
+			return nil, fmt.Errorf("%w: %s is required without polygon", domain.ErrInvalidIncident, field.name)
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s must be a number", domain.ErrInvalidIncident, field.name)
+		}
+		*field.dst = v
+	}
+
+	return incident, nil
+}
+
+// formatWKT - POLYGON((lon lat, lon lat, ...))
+func formatWKT(ring [][2]float64) string {
+	points := make([]string, len(ring))
+	for i, p := range ring {
+		points[i] = formatFloat(p[0]) + " " + formatFloat(p[1])
+	}
+	return "POLYGON((" + strings.Join(points, ", ") + "))"
+}
+
+// parseWKT разбирает WKT-полигон, используется только внешнее кольцо
+func parseWKT(value string) ([][2]float64, error) {
+	upper := strings.ToUpper(value)
+	if !strings.HasPrefix(upper, "POLYGON") {
+		return nil, errors.New("expected WKT POLYGON")
+	}
+	body := strings.TrimSpace(value[len("POLYGON"):])
+	if !strings.HasPrefix(body, "((") || !strings.HasSuffix(body, "))") {
+		return nil, errors.New("malformed WKT polygon")
+	}
+	body = body[2 : len(body)-2]
+	if outer, _, found := strings.Cut(body, "),"); found {
+		body = outer
+	}
+
+	var ring [][2]float64
+	for _, pair := range strings.Split(body, ",") {
+		fields := strings.Fields(pair)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("malformed point %q", strings.TrimSpace(pair))
+		}
+		lon, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed longitude %q", fields[0])
+		}
+		lat, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed latitude %q", fields[1])
+		}
+		ring = append(ring, [2]float64{lon, lat})
+	}
+
+	return geo.CloseRing(ring)
+}
+
+// escapeFormula защищает от CSV-инъекций: Excel и LibreOffice выполняют ячейку,
+// начинающуюся с = + - @ (а также табуляции и перевода строки), как формулу.
+// К такой ячейке добавляется апостроф. Значение, которое уже начинается с апострофов
+// перед таким символом, тоже получает еще один, чтобы импорт вернул его без изменений.
+func escapeFormula(value string) string {
+	if startsWithFormula(strings.TrimLeft(value, "'")) {
+		return "'" + value
+	}
+	return value
+}
+
+// unescapeFormula снимает апостроф, добавленный escapeFormula
+func unescapeFormula(value string) string {
+	if strings.HasPrefix(value, "'") && startsWithFormula(strings.TrimLeft(value, "'")) {
+		return value[1:]
+	}
+	return value
+}
+
+func startsWithFormula(value string) bool {
+	return value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0]))
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func parseTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -0,0 +1,175 @@
+package csv
+
+import (
+	"bytes"
+	stdcsv "encoding/csv"
+	"geo-alert-core/internal/domain"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func collect(t *testing.T, data string) []domain.ImportItem {
+	t.Helper()
+	var items []domain.ImportItem
+	for item := range ReadIncidents(strings.NewReader(data)) {
+		items = append(items, item)
+	}
+	return items
+}
+
+func TestIncidentWriter_RoundTrip(t *testing.T) {
+	expires := time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC)
+	incidents := []*domain.Incident{
+		{
+			ID: uuid.New(), Title: "Пожар, склад", Description: "строка 1\nстрока 2",
+			Latitude: 55.75, Longitude: 37.61, Radius: 200,
+			Category: "fire", Severity: domain.SeverityHigh, IsActive: true,
+			Source: domain.SourceManual, ExpiresAt: &expires, Version: 2,
+		},
+		{
+			ID: uuid.New(), Title: "Мост",
+			Polygon:  [][2]float64{{37.60, 55.74}, {37.62, 55.74}, {37.62, 55.76}, {37.60, 55.74}},
+			Latitude: 55.75, Longitude: 37.61, Radius: 1200,
+			Severity: domain.SeverityLow,
+		},
+	}
+
+	var buf bytes.Buffer
+	w := NewIncidentWriter(&buf)
+	for _, incident := range incidents {
+		require.NoError(t, w.Write(incident))
+	}
+	require.NoError(t, w.Close())
+
+	items := collect(t, buf.String())
+	require.Len(t, items, 2)
+
+	first := items[0]
+	require.NoError(t, first.Err)
+	// многострочное описание занимает две строки файла, следующая запись начинается с 4-й
+	assert.Equal(t, 2, first.Index)
+	assert.Equal(t, incidents[0].ID, first.Incident.ID)
+	assert.Equal(t, "Пожар, склад", first.Incident.Title)
+	assert.Equal(t, "строка 1\nстрока 2", first.Incident.Description)
+	assert.Equal(t, 200.0, first.Incident.Radius)
+	require.NotNil(t, first.Incident.ExpiresAt)
+	assert.True(t, expires.Equal(*first.Incident.ExpiresAt))
+
+	second := items[1]
+	require.NoError(t, second.Err)
+	assert.Equal(t, 4, second.Index)
+	assert.Equal(t, incidents[1].Polygon, second.Incident.Polygon)
+	assert.False(t, second.Incident.IsActive)
+}
+
+func TestReadIncidents_RowErrors(t *testing.T) {
+	data := "\ufeffTitle,Latitude,Longitude,Radius,Severity,Polygon\n" +
+		"ok,55.7,37.6,100,HIGH,\n" +
+		",55.7,37.6,100,,\n" +
+		"no radius,55.7,37.6,,,\n" +
+		"bad number,55.7,abc,100,,\n" +
+		"bad \"quote,55.7,37.6,100,,\n" +
+		"polygon,,,,,\"POLYGON((37.6 55.74, 37.62 55.74, 37.62 55.76))\"\n" +
+		"bad polygon,,,,,POINT(37.6 55.7)\n"
+
+	items := collect(t, data)
+	require.Len(t, items, 7)
+
+	assert.NoError(t, items[0].Err)
+	assert.Equal(t, domain.SeverityHigh, items[0].Incident.Severity)
+
+	for _, i := range []int{1, 2, 3, 4, 6} {
+		assert.ErrorIs(t, items[i].Err, domain.ErrInvalidIncident, "row %d", items[i].Index)
+	}
+	assert.Equal(t, 3, items[1].Index)
+	assert.Contains(t, items[2].Err.Error(), "radius")
+	assert.Equal(t, 6, items[4].Index)
+
+	require.NoError(t, items[5].Err)
+	// кольцо замыкается автоматически
+	assert.Len(t, items[5].Incident.Polygon, 4)
+}
+
+func TestReadIncidents_Header(t *testing.T) {
+	items := collect(t, "")
+	require.Len(t, items, 1)
+	assert.ErrorIs(t, items[0].Err, domain.ErrInvalidIncident)
+
+	items = collect(t, "name,latitude\nx,1\n")
+	require.Len(t, items, 1)
+	assert.Contains(t, items[0].Err.Error(), "title")
+}
+
+func TestCheckWriter(t *testing.T) {
+	first, second := uuid.New(), uuid.New()
+
+	var buf bytes.Buffer
+	w := NewCheckWriter(&buf)
+	require.NoError(t, w.Write(&domain.LocationCheck{
+		ID: uuid.New(), UserID: "user1", Latitude: 55.75, Longitude: 37.61,
+		CheckedAt:   time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		IncidentIDs: []uuid.UUID{first, second},
+	}))
+	require.NoError(t, w.Write(&domain.LocationCheck{ID: uuid.New(), UserID: "user2"}))
+	require.NoError(t, w.Close())
+
+	records, err := stdcsv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, CheckColumns, records[0])
+	assert.Equal(t, "2024-05-01T10:00:00Z", records[1][4])
+	assert.Equal(t, first.String()+";"+second.String(), records[1][6])
+	assert.Equal(t, "", records[2][6])
+}
+
+func TestWriters_EscapeFormulas(t *testing.T) {
+	incident := &domain.Incident{
+		ID: uuid.New(), Title: "=HYPERLINK(\"http://evil\")", Description: "+7 (495) 000-00-00",
+		Category: "@SUM(A1)", Source: "-1", ExternalID: "'=1+2",
+		Latitude: -33.9, Longitude: 18.4, Radius: 100,
+	}
+
+	var buf bytes.Buffer
+	w := NewIncidentWriter(&buf)
+	require.NoError(t, w.Write(incident))
+	require.NoError(t, w.Close())
+
+	records, err := stdcsv.NewReader(bytes.NewReader(buf.Bytes())).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "'=HYPERLINK(\"http://evil\")", records[1][1])
+	assert.Equal(t, "'+7 (495) 000-00-00", records[1][2])
+	assert.Equal(t, "-33.9", records[1][3], "numbers are not escaped")
+	assert.Equal(t, "'@SUM(A1)", records[1][7])
+	assert.Equal(t, "'-1", records[1][10])
+	assert.Equal(t, "''=1+2", records[1][11])
+
+	// импорт снимает апостроф и возвращает исходные значения
+	items := collect(t, buf.String())
+	require.Len(t, items, 1)
+	require.NoError(t, items[0].Err)
+	assert.Equal(t, incident.Title, items[0].Incident.Title)
+	assert.Equal(t, incident.Description, items[0].Incident.Description)
+	assert.Equal(t, incident.Category, items[0].Incident.Category)
+	// апостроф перед обычным текстом остается
+	assert.Equal(t, "'text", unescapeFormula(escapeFormula("'text")))
+
+	buf.Reset()
+	checks := NewCheckWriter(&buf)
+	require.NoError(t, checks.Write(&domain.LocationCheck{ID: uuid.New(), UserID: "=cmd|'/c calc'!A1"}))
+	require.NoError(t, checks.Close())
+	records, err = stdcsv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, "'=cmd|'/c calc'!A1", records[1][1])
+}
+
+func TestWriters_EmptyOutputHasHeader(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, NewIncidentWriter(&buf).Close())
+	assert.Equal(t, strings.Join(IncidentColumns, ",")+"\n", buf.String())
+}
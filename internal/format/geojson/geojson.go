@@ -0,0 +1,205 @@
+// Package geojson - обмен инцидентами в формате GeoJSON (RFC 7946)
+package geojson
+
+import (
+	"encoding/json"
+	"fmt"
+	"geo-alert-core/internal/domain"
+	"geo-alert-core/internal/geo"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type FeatureCollection struct {
+	Type     string     `json:"type"`
+	Features []*Feature `json:"features"`
+}
+
+type Feature struct {
+	Type       string     `json:"type"`
+	ID         string     `json:"id,omitempty"`
+	Geometry   *Geometry  `json:"geometry"`
+	Properties Properties `json:"properties"`
+}
+
+type Geometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// Properties - свойства инцидента. Круг передается как Point с radius в метрах.
+type Properties struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	Radius      *float64   `json:"radius,omitempty"`
+	Category    string     `json:"category,omitempty"`
+	Severity    string     `json:"severity,omitempty"`
+	IsActive    *bool      `json:"is_active,omitempty"`
+	Version     int        `json:"version,omitempty"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
+
+// Options - настройки экспорта
+type Options struct {
+	// CirclesAsPolygons - отдавать круги многоугольниками для ГИС без поддержки radius
+	CirclesAsPolygons bool
+	Vertices          int
+}
+
+// FromIncident конвертирует инцидент в Feature
+func FromIncident(incident *domain.Incident, opts Options) *Feature {
+	isActive := incident.IsActive
+	createdAt, updatedAt := incident.CreatedAt, incident.UpdatedAt
+
+	feature := &Feature{
+		Type: "Feature",
+		ID:   incident.ID.String(),
+		Properties: Properties{
+			Title:       incident.Title,
+			Description: incident.Description,
+			Category:    incident.Category,
+			Severity:    incident.Severity,
+			IsActive:    &isActive,
+			Version:     incident.Version,
+			CreatedAt:   &createdAt,
+			UpdatedAt:   &updatedAt,
+		},
+	}
+
+	switch {
+	case incident.Polygon != nil:
+		feature.Geometry = polygonGeometry(incident.Polygon)
+	case opts.CirclesAsPolygons:
+		vertices := opts.Vertices
+		if vertices <= 0 {
+			vertices = 64
+		}
+		feature.Geometry = polygonGeometry(geo.CirclePolygon(incident.Latitude, incident.Longitude, incident.Radius, vertices))
+		radius := incident.Radius
+		feature.Properties.Radius = &radius
+	default:
+		coordinates, _ := json.Marshal(geo.Position{incident.Longitude, incident.Latitude})
+		feature.Geometry = &Geometry{Type: "Point", Coordinates: coordinates}
+		radius := incident.Radius
+		feature.Properties.Radius = &radius
+	}
+
+	return feature
+}
+
+func polygonGeometry(ring [][2]float64) *Geometry {
+	coordinates, _ := json.Marshal([][][2]float64{ring})
+	return &Geometry{Type: "Polygon", Coordinates: coordinates}
+}
+
+// ToIncident разбирает Feature в инцидент. Point требует radius в свойствах,
+// для Polygon используется только внешнее кольцо. Центр полигона считает сервис.
+func ToIncident(feature *Feature) (*domain.Incident, error) {
+	if feature.Type != "Feature" {
+		return nil, fmt.Errorf("%w: expected type Feature, got %q", domain.ErrInvalidIncident, feature.Type)
+	}
+	if feature.Geometry == nil {
+		return nil, fmt.Errorf("%w: geometry is required", domain.ErrInvalidIncident)
+	}
+
+	props := feature.Properties
+	if props.Title == "" {
+		return nil, fmt.Errorf("%w: properties.title is required", domain.ErrInvalidIncident)
+	}
+
+	incident := &domain.Incident{
+		Title:       props.Title,
+		Description: props.Description,
+		Category:    props.Category,
+		Severity:    props.Severity,
+		IsActive:    true,
+	}
+	if props.IsActive != nil {
+		incident.IsActive = *props.IsActive
+	}
+
+	if feature.ID != "" {
+		id, err := uuid.Parse(feature.ID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: feature id must be a UUID", domain.ErrInvalidIncident)
+		}
+		incident.ID = id
+	}
+
+	switch feature.Geometry.Type {
+	case "Point":
+		var point geo.Position
+		if err := json.Unmarshal(feature.Geometry.Coordinates, &point); err != nil {
+			return nil, fmt.Errorf("%w: invalid Point coordinates", domain.ErrInvalidIncident)
+		}
+		if props.Radius == nil {
+			return nil, fmt.Errorf("%w: properties.radius is required for Point geometry", domain.ErrInvalidIncident)
+		}
+		incident.Longitude, incident.Latitude = point[0], point[1]
+		incident.Radius = *props.Radius
+	case "Polygon":
+		var rings [][]geo.Position
+		if err := json.Unmarshal(feature.Geometry.Coordinates, &rings); err != nil || len(rings) == 0 {
+			return nil, fmt.Errorf("%w: invalid Polygon coordinates", domain.ErrInvalidIncident)
+		}
+		ring, err := geo.CloseRing(rings[0])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrInvalidIncident, err)
+		}
+		incident.Polygon = ring
+	default:
+		return nil, fmt.Errorf("%w: unsupported geometry type %q", domain.ErrInvalidIncident, feature.Geometry.Type)
+	}
+
+	return incident, nil
+}
+
+// Writer пишет FeatureCollection потоком, не держа все объекты в памяти
+type Writer struct {
+	w       io.Writer
+	count   int
+	started bool
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+func (w *Writer) Write(feature *Feature) error {
+	if !w.started {
+		if _, err := io.WriteString(w.w, `{"type":"FeatureCollection","features":[`); err != nil {
+			return err
+		}
+		w.started = true
+	}
+
+	if w.count > 0 {
+		if _, err := io.WriteString(w.w, ","); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(feature)
+	if err != nil {
+		return fmt.Errorf("failed to marshal feature: %w", err)
+	}
+	if _, err := w.w.Write(data); err != nil {
+		return err
+	}
+
+	w.count++
+	return nil
+}
+
+// Close завершает коллекцию, пустая коллекция тоже валидна
+func (w *Writer) Close() error {
+	if !w.started {
+		_, err := io.WriteString(w.w, `{"type":"FeatureCollection","features":[]}`)
+		return err
+	}
+	_, err := io.WriteString(w.w, "]}")
+	return err
+}
@@ -0,0 +1,93 @@
+package geojson
+
+import (
+	"bytes"
+	"encoding/json"
+	"geo-alert-core/internal/domain"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundTrip(t *testing.T) {
+	circle := &domain.Incident{
+		ID:        uuid.New(),
+		Title:     "Утечка газа",
+		Latitude:  55.75,
+		Longitude: 37.61,
+		Radius:    300,
+		Severity:  domain.SeverityHigh,
+		IsActive:  true,
+	}
+	polygon := &domain.Incident{
+		ID:       uuid.New(),
+		Title:    "Closed bridge",
+		Polygon:  [][2]float64{{37.60, 55.74}, {37.62, 55.74}, {37.62, 55.76}, {37.60, 55.74}},
+		IsActive: false,
+	}
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	require.NoError(t, w.Write(FromIncident(circle, Options{})))
+	require.NoError(t, w.Write(FromIncident(polygon, Options{})))
+	require.NoError(t, w.Close())
+
+	var collection FeatureCollection
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &collection))
+	require.Len(t, collection.Features, 2)
+	assert.Equal(t, "Point", collection.Features[0].Geometry.Type)
+	assert.Equal(t, "Polygon", collection.Features[1].Geometry.Type)
+
+	got, err := ToIncident(collection.Features[0])
+	require.NoError(t, err)
+	assert.Equal(t, circle.ID, got.ID)
+	assert.Equal(t, circle.Latitude, got.Latitude)
+	assert.Equal(t, circle.Longitude, got.Longitude)
+	assert.Equal(t, circle.Radius, got.Radius)
+	assert.Equal(t, circle.Severity, got.Severity)
+	assert.Nil(t, got.Polygon)
+
+	got, err = ToIncident(collection.Features[1])
+	require.NoError(t, err)
+	assert.Equal(t, polygon.Polygon, got.Polygon)
+	assert.False(t, got.IsActive)
+}
+
+func TestEmptyCollection(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, NewWriter(&buf).Close())
+	assert.JSONEq(t, `{"type":"FeatureCollection","features":[]}`, buf.String())
+}
+
+func TestCircleAsPolygon(t *testing.T) {
+	feature := FromIncident(&domain.Incident{Latitude: 55.75, Longitude: 37.61, Radius: 100}, Options{CirclesAsPolygons: true, Vertices: 16})
+
+	assert.Equal(t, "Polygon", feature.Geometry.Type)
+	assert.Equal(t, 100.0, *feature.Properties.Radius)
+}
+
+func TestToIncident_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		feature string
+	}{
+		{"no title", `{"type":"Feature","geometry":{"type":"Point","coordinates":[37.6,55.7]},"properties":{"radius":10}}`},
+		{"point without radius", `{"type":"Feature","geometry":{"type":"Point","coordinates":[37.6,55.7]},"properties":{"title":"x"}}`},
+		{"bad id", `{"type":"Feature","id":"42","geometry":{"type":"Point","coordinates":[37.6,55.7]},"properties":{"title":"x","radius":10}}`},
+		{"degenerate polygon", `{"type":"Feature","geometry":{"type":"Polygon","coordinates":[[[37.6,55.7],[37.7,55.7]]]},"properties":{"title":"x"}}`},
+		{"line", `{"type":"Feature","geometry":{"type":"LineString","coordinates":[[37.6,55.7],[37.7,55.7]]},"properties":{"title":"x"}}`},
+		{"no geometry", `{"type":"Feature","geometry":null,"properties":{"title":"x"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var feature Feature
+			require.NoError(t, json.Unmarshal([]byte(tt.feature), &feature))
+
+			_, err := ToIncident(&feature)
+			assert.ErrorIs(t, err, domain.ErrInvalidIncident)
+		})
+	}
+}
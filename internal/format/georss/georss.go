@@ -0,0 +1,131 @@
+// Package georss - лента инцидентов RSS 2.0 с геометрией GeoRSS Simple
+package georss
+
+import (
+	"encoding/xml"
+	"fmt"
+	"geo-alert-core/internal/domain"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Namespace - пространство имен GeoRSS Simple
+const Namespace = "http://www.georss.org/georss"
+
+// ChannelInfo - заголовок ленты
+type ChannelInfo struct {
+	Title       string
+	Link        string
+	Description string
+	Updated     time.Time
+}
+
+type item struct {
+	XMLName     xml.Name   `xml:"item"`
+	Title       string     `xml:"title"`
+	Description string     `xml:"description,omitempty"`
+	GUID        guid       `xml:"guid"`
+	PubDate     string     `xml:"pubDate"`
+	Categories  []category `xml:"category"`
+	// круг передается точкой с радиусом, полигон - списком "lat lon"
+	Point   string `xml:"georss:point,omitempty"`
+	Radius  string `xml:"georss:radius,omitempty"`
+	Polygon string `xml:"georss:polygon,omitempty"`
+}
+
+type category struct {
+	Domain string `xml:"domain,attr,omitempty"`
+	Value  string `xml:",chardata"`
+}
+
+type guid struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// Writer пишет RSS-ленту потоком
+type Writer struct {
+	w       io.Writer
+	info    ChannelInfo
+	started bool
+}
+
+func NewWriter(w io.Writer, info ChannelInfo) *Writer {
+	return &Writer{w: w, info: info}
+}
+
+func (w *Writer) start() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+
+	if _, err := io.WriteString(w.w, xml.Header+`<rss version="2.0" xmlns:georss="`+Namespace+`"><channel>`); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w.w)
+	for _, el := range []struct {
+		name  string
+		value string
+	}{
+		{"title", w.info.Title},
+		{"link", w.info.Link},
+		{"description", w.info.Description},
+		{"lastBuildDate", w.info.Updated.UTC().Format(time.RFC1123Z)},
+	} {
+		if err := enc.EncodeElement(el.value, xml.StartElement{Name: xml.Name{Local: el.name}}); err != nil {
+			return err
+		}
+	}
+	return enc.Flush()
+}
+
+func (w *Writer) Write(incident *domain.Incident) error {
+	if err := w.start(); err != nil {
+		return err
+	}
+
+	it := item{
+		Title:       incident.Title,
+		Description: incident.Description,
+		GUID:        guid{Value: "urn:uuid:" + incident.ID.String()},
+		PubDate:     incident.UpdatedAt.UTC().Format(time.RFC1123Z),
+	}
+	if incident.Category != "" {
+		it.Categories = append(it.Categories, category{Value: incident.Category})
+	}
+	if incident.Severity != "" {
+		it.Categories = append(it.Categories, category{Domain: "severity", Value: incident.Severity})
+	}
+
+	if incident.Polygon != nil {
+		points := make([]string, len(incident.Polygon))
+		for i, p := range incident.Polygon {
+			points[i] = formatFloat(p[1]) + " " + formatFloat(p[0])
+		}
+		it.Polygon = strings.Join(points, " ")
+	} else {
+		it.Point = formatFloat(incident.Latitude) + " " + formatFloat(incident.Longitude)
+		it.Radius = formatFloat(incident.Radius)
+	}
+
+	if err := xml.NewEncoder(w.w).Encode(it); err != nil {
+		return fmt.Errorf("failed to encode item: %w", err)
+	}
+	return nil
+}
+
+func (w *Writer) Close() error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w.w, `</channel></rss>`)
+	return err
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
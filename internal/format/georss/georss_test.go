@@ -0,0 +1,66 @@
+package georss
+
+import (
+	"bytes"
+	"encoding/xml"
+	"geo-alert-core/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter(t *testing.T) {
+	updated := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf, ChannelInfo{Title: "Zones", Link: "http://example.com", Updated: updated})
+	require.NoError(t, w.Write(&domain.Incident{
+		ID:        uuid.New(),
+		Title:     "Пожар",
+		Latitude:  55.75,
+		Longitude: 37.61,
+		Radius:    200,
+		Category:  "fire",
+		Severity:  domain.SeverityHigh,
+		UpdatedAt: updated,
+	}))
+	require.NoError(t, w.Write(&domain.Incident{
+		ID:        uuid.New(),
+		Title:     "Мост",
+		Polygon:   [][2]float64{{37.60, 55.74}, {37.62, 55.74}, {37.62, 55.76}, {37.60, 55.74}},
+		UpdatedAt: updated,
+	}))
+	require.NoError(t, w.Close())
+
+	var doc struct {
+		Channel struct {
+			LastBuildDate string `xml:"lastBuildDate"`
+			Items         []struct {
+				Title      string   `xml:"title"`
+				Categories []string `xml:"category"`
+				Point      string   `xml:"http://www.georss.org/georss point"`
+				Radius     string   `xml:"http://www.georss.org/georss radius"`
+				Polygon    string   `xml:"http://www.georss.org/georss polygon"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+
+	assert.Equal(t, "Wed, 01 May 2024 10:00:00 +0000", doc.Channel.LastBuildDate)
+	require.Len(t, doc.Channel.Items, 2)
+
+	circle := doc.Channel.Items[0]
+	assert.Equal(t, "Пожар", circle.Title)
+	assert.Equal(t, []string{"fire", "high"}, circle.Categories)
+	// GeoRSS пишет широту первой
+	assert.Equal(t, "55.75 37.61", circle.Point)
+	assert.Equal(t, "200", circle.Radius)
+	assert.Empty(t, circle.Polygon)
+
+	polygon := doc.Channel.Items[1]
+	assert.Equal(t, "55.74 37.6 55.74 37.62 55.76 37.62 55.74 37.6", polygon.Polygon)
+	assert.Empty(t, polygon.Point)
+}
@@ -0,0 +1,103 @@
+// Package gpx - выгрузка треков проверок координат в GPX 1.1
+package gpx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"geo-alert-core/internal/domain"
+	"io"
+	"strings"
+	"time"
+)
+
+type trackPoint struct {
+	XMLName   xml.Name `xml:"trkpt"`
+	Latitude  float64  `xml:"lat,attr"`
+	Longitude float64  `xml:"lon,attr"`
+	Time      string   `xml:"time"`
+}
+
+// Writer пишет GPX потоком. Проверки должны идти упорядоченными по пользователю и времени:
+// каждый пользователь получает отдельный трек.
+type Writer struct {
+	w           io.Writer
+	enc         *xml.Encoder
+	started     bool
+	currentUser string
+	inTrack     bool
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w, enc: xml.NewEncoder(w)}
+}
+
+func (w *Writer) start() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+
+	_, err := io.WriteString(w.w, xml.Header+
+		`<gpx version="1.1" creator="geo-alert-core" xmlns="http://www.topografix.com/GPX/1/1">`)
+	return err
+}
+
+func (w *Writer) Write(check *domain.LocationCheck) error {
+	if err := w.start(); err != nil {
+		return err
+	}
+
+	if !w.inTrack || check.UserID != w.currentUser {
+		if err := w.closeTrack(); err != nil {
+			return err
+		}
+
+		name, err := xmlText(check.UserID)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w.w, "<trk><name>"+name+"</name><trkseg>"); err != nil {
+			return err
+		}
+		w.currentUser = check.UserID
+		w.inTrack = true
+	}
+
+	point := trackPoint{
+		Latitude:  check.Latitude,
+		Longitude: check.Longitude,
+		Time:      check.CheckedAt.UTC().Format(time.RFC3339),
+	}
+	if err := w.enc.Encode(point); err != nil {
+		return fmt.Errorf("failed to encode track point: %w", err)
+	}
+	return w.enc.Flush()
+}
+
+func (w *Writer) closeTrack() error {
+	if !w.inTrack {
+		return nil
+	}
+	w.inTrack = false
+	_, err := io.WriteString(w.w, "</trkseg></trk>")
+	return err
+}
+
+func (w *Writer) Close() error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	if err := w.closeTrack(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w.w, "</gpx>")
+	return err
+}
+
+func xmlText(s string) (string, error) {
+	var buf strings.Builder
+	if err := xml.EscapeText(&buf, []byte(s)); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
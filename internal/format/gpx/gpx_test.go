@@ -0,0 +1,46 @@
+package gpx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"geo-alert-core/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter_TrackPerUser(t *testing.T) {
+	base := time.Date(2024, 5, 1, 14, 0, 0, 0, time.UTC)
+	checks := []*domain.LocationCheck{
+		{UserID: "alice", Latitude: 55.70, Longitude: 37.60, CheckedAt: base},
+		{UserID: "alice", Latitude: 55.71, Longitude: 37.61, CheckedAt: base.Add(time.Minute)},
+		{UserID: "bob", Latitude: 59.93, Longitude: 30.33, CheckedAt: base},
+	}
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for _, check := range checks {
+		require.NoError(t, w.Write(check))
+	}
+	require.NoError(t, w.Close())
+
+	var doc struct {
+		Tracks []struct {
+			Name   string `xml:"name"`
+			Points []struct {
+				Lat  float64 `xml:"lat,attr"`
+				Time string  `xml:"time"`
+			} `xml:"trkseg>trkpt"`
+		} `xml:"trk"`
+	}
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+
+	require.Len(t, doc.Tracks, 2)
+	assert.Equal(t, "alice", doc.Tracks[0].Name)
+	assert.Len(t, doc.Tracks[0].Points, 2)
+	assert.Equal(t, "2024-05-01T14:01:00Z", doc.Tracks[0].Points[1].Time)
+	assert.Equal(t, "bob", doc.Tracks[1].Name)
+	assert.Equal(t, 59.93, doc.Tracks[1].Points[0].Lat)
+}
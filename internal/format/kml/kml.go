@@ -0,0 +1,140 @@
+// Package kml - выгрузка инцидентов в KML для Google Earth и навигаторов
+package kml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"geo-alert-core/internal/domain"
+	"geo-alert-core/internal/geo"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Цвета в KML задаются как aabbggrr
+var severityColors = map[string]string{
+	domain.SeverityLow:      "ff00c000", // зеленый
+	domain.SeverityMedium:   "ff00d7ff", // желтый
+	domain.SeverityHigh:     "ff008cff", // оранжевый
+	domain.SeverityCritical: "ff0000e6", // красный
+}
+
+type style struct {
+	ID        string    `xml:"id,attr"`
+	LineStyle lineStyle `xml:"LineStyle"`
+	PolyStyle polyStyle `xml:"PolyStyle"`
+}
+
+type lineStyle struct {
+	Color string  `xml:"color"`
+	Width float64 `xml:"width"`
+}
+
+type polyStyle struct {
+	Color string `xml:"color"`
+}
+
+type placemark struct {
+	XMLName     xml.Name     `xml:"Placemark"`
+	ID          string       `xml:"id,attr"`
+	Name        string       `xml:"name"`
+	Description string       `xml:"description,omitempty"`
+	StyleURL    string       `xml:"styleUrl"`
+	Data        []dataField  `xml:"ExtendedData>Data"`
+	Polygon     polygonShape `xml:"Polygon"`
+}
+
+type dataField struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+type polygonShape struct {
+	Coordinates string `xml:"outerBoundaryIs>LinearRing>coordinates"`
+}
+
+// Writer пишет KML-документ потоком
+type Writer struct {
+	w        io.Writer
+	vertices int
+	started  bool
+}
+
+// NewWriter создает writer; круги аппроксимируются многоугольником из vertices вершин
+func NewWriter(w io.Writer, vertices int) *Writer {
+	return &Writer{w: w, vertices: vertices}
+}
+
+func (w *Writer) start() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+
+	if _, err := io.WriteString(w.w, xml.Header+`<kml xmlns="http://www.opengis.net/kml/2.2"><Document><name>Geo Alert incidents</name>`); err != nil {
+		return err
+	}
+
+	// по стилю на каждый уровень опасности, полупрозрачная заливка
+	enc := xml.NewEncoder(w.w)
+	for _, severity := range []string{domain.SeverityLow, domain.SeverityMedium, domain.SeverityHigh, domain.SeverityCritical} {
+		color := severityColors[severity]
+		s := style{
+			ID:        "severity-" + severity,
+			LineStyle: lineStyle{Color: color, Width: 2},
+			PolyStyle: polyStyle{Color: "66" + color[2:]},
+		}
+		if err := enc.EncodeElement(s, xml.StartElement{Name: xml.Name{Local: "Style"}}); err != nil {
+			return err
+		}
+	}
+	return enc.Flush()
+}
+
+func (w *Writer) Write(incident *domain.Incident) error {
+	if err := w.start(); err != nil {
+		return err
+	}
+
+	ring := incident.Polygon
+	if ring == nil {
+		ring = geo.CirclePolygon(incident.Latitude, incident.Longitude, incident.Radius, w.vertices)
+	}
+
+	coordinates := make([]string, len(ring))
+	for i, p := range ring {
+		coordinates[i] = strconv.FormatFloat(p[0], 'f', -1, 64) + "," + strconv.FormatFloat(p[1], 'f', -1, 64) + ",0"
+	}
+
+	severity := incident.Severity
+	if _, ok := severityColors[severity]; !ok {
+		severity = domain.SeverityMedium
+	}
+
+	pm := placemark{
+		ID:          incident.ID.String(),
+		Name:        incident.Title,
+		Description: incident.Description,
+		StyleURL:    "#severity-" + severity,
+		Data: []dataField{
+			{Name: "severity", Value: incident.Severity},
+			{Name: "category", Value: incident.Category},
+			{Name: "radius", Value: strconv.FormatFloat(incident.Radius, 'f', -1, 64)},
+			{Name: "is_active", Value: strconv.FormatBool(incident.IsActive)},
+		},
+		Polygon: polygonShape{Coordinates: strings.Join(coordinates, " ")},
+	}
+
+	if err := xml.NewEncoder(w.w).Encode(pm); err != nil {
+		return fmt.Errorf("failed to encode placemark: %w", err)
+	}
+	return nil
+}
+
+func (w *Writer) Close() error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w.w, `</Document></kml>`)
+	return err
+}
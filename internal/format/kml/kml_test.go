@@ -0,0 +1,57 @@
+package kml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"geo-alert-core/internal/domain"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, 16)
+
+	require.NoError(t, w.Write(&domain.Incident{
+		ID:        uuid.New(),
+		Title:     "Пожар <склад>",
+		Latitude:  55.75,
+		Longitude: 37.61,
+		Radius:    200,
+		Severity:  domain.SeverityCritical,
+	}))
+	require.NoError(t, w.Close())
+
+	var doc struct {
+		Document struct {
+			Styles []struct {
+				ID string `xml:"id,attr"`
+			} `xml:"Style"`
+			Placemarks []struct {
+				Name        string `xml:"name"`
+				StyleURL    string `xml:"styleUrl"`
+				Coordinates string `xml:"Polygon>outerBoundaryIs>LinearRing>coordinates"`
+			} `xml:"Placemark"`
+		} `xml:"Document"`
+	}
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+
+	assert.Len(t, doc.Document.Styles, 4)
+	require.Len(t, doc.Document.Placemarks, 1)
+	pm := doc.Document.Placemarks[0]
+	assert.Equal(t, "Пожар <склад>", pm.Name)
+	assert.Equal(t, "#severity-critical", pm.StyleURL)
+	assert.Len(t, strings.Fields(pm.Coordinates), 17) // 16 вершин + замыкающая
+}
+
+func TestWriter_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, NewWriter(&buf, 16).Close())
+
+	var doc struct{}
+	assert.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+}
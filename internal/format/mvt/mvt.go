@@ -0,0 +1,273 @@
+// Package mvt - сборка векторных тайлов Mapbox Vector Tile 2.1 без PostGIS.
+// Поддерживаются только полигоны, этого хватает для слоя зон.
+package mvt
+
+import (
+	"encoding/binary"
+	"geo-alert-core/internal/geo"
+	"math"
+)
+
+const (
+	// Extent - размер тайла в собственных координатах, как у ST_AsMVT по умолчанию
+	Extent = 4096
+	// Buffer - запас за краем тайла, как у ST_AsMVTGeom по умолчанию
+	Buffer = 256
+)
+
+// Property - атрибут объекта. Value - string, float64 или int.
+type Property struct {
+	Key   string
+	Value any
+}
+
+// Layer - слой одного тайла z/x/y. Объекты добавляются в координатах WGS 84.
+type Layer struct {
+	name    string
+	z, x, y int
+
+	features [][]byte
+	keys     []string
+	keyIndex map[string]int
+	values   [][]byte
+	valIndex map[string]int
+}
+
+func NewLayer(name string, z, x, y int) *Layer {
+	return &Layer{
+		name:     name,
+		z:        z,
+		x:        x,
+		y:        y,
+		keyIndex: map[string]int{},
+		valIndex: map[string]int{},
+	}
+}
+
+// Len - число объектов в слое
+func (l *Layer) Len() int {
+	return len(l.features)
+}
+
+// AddPolygon добавляет полигон по внешнему кольцу [долгота, широта].
+// Кольцо обрезается по тайлу с буфером; если от него ничего не осталось, объект пропускается.
+func (l *Layer) AddPolygon(ring []geo.Position, properties []Property) bool {
+	points := clip(l.project(ring))
+	if len(points) < 3 {
+		return false
+	}
+
+	// внешнее кольцо в MVT идет по часовой стрелке в координатах тайла (ось y вниз)
+	if signedArea(points) < 0 {
+		for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
+			points[i], points[j] = points[j], points[i]
+		}
+	}
+
+	var tags []uint64
+	for _, p := range properties {
+		value, ok := encodeValue(p.Value)
+		if !ok {
+			continue
+		}
+		tags = append(tags, uint64(l.key(p.Key)), uint64(l.value(value)))
+	}
+
+	var feature []byte
+	feature = appendPacked(feature, 2, tags)
+	feature = appendVarintField(feature, 3, 3) // POLYGON
+	feature = appendPacked(feature, 4, polygonCommands(points))
+
+	l.features = append(l.features, feature)
+	return true
+}
+
+// Encode собирает тайл из слоев. Пустые слои пропускаются, тайл без объектов - пустой срез.
+func Encode(layers ...*Layer) []byte {
+	var tile []byte
+	for _, l := range layers {
+		if l.Len() == 0 {
+			continue
+		}
+
+		var layer []byte
+		layer = appendVarintField(layer, 15, 2) // version
+		layer = appendBytesField(layer, 1, []byte(l.name))
+		for _, feature := range l.features {
+			layer = appendBytesField(layer, 2, feature)
+		}
+		for _, key := range l.keys {
+			layer = appendBytesField(layer, 3, []byte(key))
+		}
+		for _, value := range l.values {
+			layer = appendBytesField(layer, 4, value)
+		}
+		layer = appendVarintField(layer, 5, Extent)
+
+		tile = appendBytesField(tile, 3, layer)
+	}
+	return tile
+}
+
+func (l *Layer) key(key string) int {
+	if i, ok := l.keyIndex[key]; ok {
+		return i
+	}
+	l.keys = append(l.keys, key)
+	l.keyIndex[key] = len(l.keys) - 1
+	return len(l.keys) - 1
+}
+
+func (l *Layer) value(value []byte) int {
+	if i, ok := l.valIndex[string(value)]; ok {
+		return i
+	}
+	l.values = append(l.values, value)
+	l.valIndex[string(value)] = len(l.values) - 1
+	return len(l.values) - 1
+}
+
+// project переводит кольцо в целые координаты тайла без замыкающей точки
+func (l *Layer) project(ring []geo.Position) [][2]float64 {
+	size := 2 * geo.MercatorExtent / math.Exp2(float64(l.z))
+	minX := -geo.MercatorExtent + float64(l.x)*size
+	maxY := geo.MercatorExtent - float64(l.y)*size
+
+	if len(ring) > 1 && ring[0] == ring[len(ring)-1] {
+		ring = ring[:len(ring)-1]
+	}
+
+	points := make([][2]float64, 0, len(ring))
+	for _, p := range ring {
+		x, y := geo.ToMercator(p[1], p[0])
+		points = append(points, [2]float64{
+			math.Round((x - minX) / size * Extent),
+			math.Round((maxY - y) / size * Extent),
+		})
+	}
+	return points
+}
+
+// clip обрезает кольцо по квадрату тайла с буфером (Sutherland-Hodgman) и убирает повторы
+func clip(points [][2]float64) [][2]float64 {
+	const lo, hi = -Buffer, Extent + Buffer
+
+	edges := []func(p [2]float64) bool{
+		func(p [2]float64) bool { return p[0] >= lo },
+		func(p [2]float64) bool { return p[0] <= hi },
+		func(p [2]float64) bool { return p[1] >= lo },
+		func(p [2]float64) bool { return p[1] <= hi },
+	}
+	bounds := []float64{lo, hi, lo, hi}
+
+	for e, inside := range edges {
+		if len(points) == 0 {
+			break
+		}
+		axis := e / 2
+		input := points
+		points = nil
+		prev := input[len(input)-1]
+		for _, p := range input {
+			if inside(p) != inside(prev) {
+				t := (bounds[e] - prev[axis]) / (p[axis] - prev[axis])
+				var cross [2]float64
+				cross[axis] = bounds[e]
+				cross[1-axis] = math.Round(prev[1-axis] + t*(p[1-axis]-prev[1-axis]))
+				points = append(points, cross)
+			}
+			if inside(p) {
+				points = append(points, p)
+			}
+			prev = p
+		}
+	}
+
+	result := points[:0]
+	for _, p := range points {
+		if len(result) == 0 || result[len(result)-1] != p {
+			result = append(result, p)
+		}
+	}
+	for len(result) > 1 && result[0] == result[len(result)-1] {
+		result = result[:len(result)-1]
+	}
+	if math.Abs(signedArea(result)) == 0 {
+		return nil
+	}
+	return result
+}
+
+// signedArea - удвоенная площадь кольца, положительная для обхода по часовой стрелке при оси y вниз
+func signedArea(points [][2]float64) float64 {
+	var area float64
+	for i := range points {
+		p, q := points[i], points[(i+1)%len(points)]
+		area += p[0]*q[1] - q[0]*p[1]
+	}
+	return area
+}
+
+// polygonCommands кодирует кольцо командами MoveTo, LineTo и ClosePath
+func polygonCommands(points [][2]float64) []uint64 {
+	commands := make([]uint64, 0, len(points)*2+3)
+	var cx, cy int64
+	for i, p := range points {
+		switch i {
+		case 0:
+			commands = append(commands, command(1, 1))
+		case 1:
+			commands = append(commands, command(2, len(points)-1))
+		}
+		x, y := int64(p[0]), int64(p[1])
+		commands = append(commands, zigzag(x-cx), zigzag(y-cy))
+		cx, cy = x, y
+	}
+	return append(commands, command(7, 1))
+}
+
+func command(id, count int) uint64 {
+	return uint64(id&0x7 | count<<3)
+}
+
+func zigzag(n int64) uint64 {
+	return uint64((n << 1) ^ (n >> 63))
+}
+
+// encodeValue кодирует значение атрибута сообщением Value
+func encodeValue(value any) ([]byte, bool) {
+	switch v := value.(type) {
+	case string:
+		return appendBytesField(nil, 1, []byte(v)), true
+	case float64:
+		return binary.LittleEndian.AppendUint64(appendTag(nil, 3, 1), math.Float64bits(v)), true
+	case int:
+		// как ST_AsMVT: неотрицательные целые - uint_value, отрицательные - sint_value
+		if v >= 0 {
+			return appendVarintField(nil, 5, uint64(v)), true
+		}
+		return appendVarintField(nil, 6, zigzag(int64(v))), true
+	}
+	return nil, false
+}
+
+func appendTag(b []byte, field, wireType int) []byte {
+	return binary.AppendUvarint(b, uint64(field<<3|wireType))
+}
+
+func appendVarintField(b []byte, field int, value uint64) []byte {
+	return binary.AppendUvarint(appendTag(b, field, 0), value)
+}
+
+func appendBytesField(b []byte, field int, data []byte) []byte {
+	b = binary.AppendUvarint(appendTag(b, field, 2), uint64(len(data)))
+	return append(b, data...)
+}
+
+func appendPacked(b []byte, field int, values []uint64) []byte {
+	var data []byte
+	for _, v := range values {
+		data = binary.AppendUvarint(data, v)
+	}
+	return appendBytesField(b, field, data)
+}
@@ -0,0 +1,121 @@
+package mvt
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"geo-alert-core/internal/geo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fields разбирает сообщение protobuf: номер поля -> значения (varint или байты)
+func fields(t *testing.T, data []byte) map[int][]any {
+	t.Helper()
+	result := map[int][]any{}
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		require.Positive(t, n)
+		data = data[n:]
+		field, wireType := int(tag>>3), tag&7
+		switch wireType {
+		case 0:
+			v, n := binary.Uvarint(data)
+			require.Positive(t, n)
+			result[field] = append(result[field], v)
+			data = data[n:]
+		case 1:
+			result[field] = append(result[field], data[:8])
+			data = data[8:]
+		case 2:
+			size, n := binary.Uvarint(data)
+			require.Positive(t, n)
+			result[field] = append(result[field], data[n:n+int(size)])
+			data = data[n+int(size):]
+		default:
+			t.Fatalf("unexpected wire type %d", wireType)
+		}
+	}
+	return result
+}
+
+func packed(t *testing.T, data []byte) []uint64 {
+	var values []uint64
+	for len(data) > 0 {
+		v, n := binary.Uvarint(data)
+		require.Positive(t, n)
+		values = append(values, v)
+		data = data[n:]
+	}
+	return values
+}
+
+func TestEncodePolygon(t *testing.T) {
+	// тайл 0/0/0 покрывает весь мир, квадрат против часовой стрелки на карте
+	layer := NewLayer("incidents", 0, 0, 0)
+	square := []geo.Position{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}
+	ok := layer.AddPolygon(square, []Property{
+		{Key: "title", Value: "Пожар"},
+		{Key: "radius", Value: 500.0},
+		{Key: "version", Value: 3},
+	})
+	require.True(t, ok)
+
+	tile := fields(t, Encode(layer))
+	require.Len(t, tile[3], 1)
+
+	l := fields(t, tile[3][0].([]byte))
+	assert.Equal(t, []any{uint64(2)}, l[15])
+	assert.Equal(t, "incidents", string(l[1][0].([]byte)))
+	assert.Equal(t, []any{uint64(Extent)}, l[5])
+	require.Len(t, l[3], 3)
+	assert.Equal(t, "title", string(l[3][0].([]byte)))
+	require.Len(t, l[2], 1)
+
+	feature := fields(t, l[2][0].([]byte))
+	assert.Equal(t, []any{uint64(3)}, feature[3])
+	assert.Equal(t, []uint64{0, 0, 1, 1, 2, 2}, packed(t, feature[2][0].([]byte)))
+
+	geometry := packed(t, feature[4][0].([]byte))
+	assert.Equal(t, command(1, 1), geometry[0])
+	assert.Equal(t, command(2, 3), geometry[3])
+	assert.Equal(t, command(7, 1), geometry[len(geometry)-1])
+
+	// восстанавливаем кольцо и проверяем обход по часовой стрелке
+	params := append(geometry[1:3:3], geometry[4:len(geometry)-1]...)
+	var points [][2]float64
+	var x, y int64
+	for i := 0; i < len(params); i += 2 {
+		x += unzigzag(params[i])
+		y += unzigzag(params[i+1])
+		points = append(points, [2]float64{float64(x), float64(y)})
+	}
+	require.Len(t, points, 4)
+	assert.Positive(t, signedArea(points))
+	for _, p := range points {
+		assert.True(t, p[0] >= Extent/2 && p[1] <= Extent/2, "point %v", p)
+	}
+}
+
+func TestClipOutsideTile(t *testing.T) {
+	// тайл 2/0/0 - северо-запад, квадрат у экватора на востоке в него не попадает
+	layer := NewLayer("incidents", 2, 0, 0)
+	assert.False(t, layer.AddPolygon([]geo.Position{{100, 0}, {110, 0}, {110, 10}, {100, 0}}, nil))
+	assert.Empty(t, Encode(layer))
+}
+
+func TestClipLargePolygon(t *testing.T) {
+	layer := NewLayer("incidents", 3, 4, 2)
+	world := []geo.Position{{-179, -80}, {179, -80}, {179, 80}, {-179, 80}}
+	require.True(t, layer.AddPolygon(world, nil))
+
+	for _, p := range clip([][2]float64{{-10000, -10000}, {20000, -10000}, {20000, 20000}, {-10000, 20000}}) {
+		assert.True(t, p[0] >= -Buffer && p[0] <= Extent+Buffer)
+		assert.True(t, p[1] >= -Buffer && p[1] <= Extent+Buffer)
+	}
+}
+
+func unzigzag(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
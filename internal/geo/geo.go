@@ -0,0 +1,137 @@
+// Package geo - геометрия на сфере для зон инцидентов (без PostGIS)
+package geo
+
+import (
+	"errors"
+	"math"
+)
+
+// EarthRadius - средний радиус Земли в метрах
+const EarthRadius = 6371008.8
+
+var ErrInvalidPolygon = errors.New("polygon must have at least 3 distinct vertices and non-zero area")
+
+// minRingArea - площадь кольца в квадратных градусах, ниже которой полигон считается вырожденным
+const minRingArea = 1e-12
+
+// Position - точка в порядке GeoJSON: [долгота, широта]
+type Position = [2]float64
+
+// Distance возвращает расстояние между точками по формуле гаверсинусов, в метрах
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1 := lat1 * math.Pi / 180
+	phi2 := lat2 * math.Pi / 180
+	dPhi := (lat2 - lat1) * math.Pi / 180
+	dLambda := (lon2 - lon1) * math.Pi / 180
+
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// Destination возвращает точку на расстоянии distance метров от исходной по азимуту bearing (градусы)
+func Destination(lat, lon, distance, bearing float64) (float64, float64) {
+	phi1 := lat * math.Pi / 180
+	lambda1 := lon * math.Pi / 180
+	theta := bearing * math.Pi / 180
+	delta := distance / EarthRadius
+
+	phi2 := math.Asin(math.Sin(phi1)*math.Cos(delta) + math.Cos(phi1)*math.Sin(delta)*math.Cos(theta))
+	lambda2 := lambda1 + math.Atan2(
+		math.Sin(theta)*math.Sin(delta)*math.Cos(phi1),
+		math.Cos(delta)-math.Sin(phi1)*math.Sin(phi2),
+	)
+
+	lon2 := math.Mod(lambda2*180/math.Pi+540, 360) - 180
+	return phi2 * 180 / math.Pi, lon2
+}
+
+// CirclePolygon аппроксимирует круг замкнутым кольцом из vertices вершин
+func CirclePolygon(lat, lon, radius float64, vertices int) []Position {
+	if vertices < 3 {
+		vertices = 3
+	}
+
+	ring := make([]Position, 0, vertices+1)
+	for i := 0; i < vertices; i++ {
+		pLat, pLon := Destination(lat, lon, radius, float64(i)*360/float64(vertices))
+		ring = append(ring, Position{pLon, pLat})
+	}
+
+	return append(ring, ring[0])
+}
+
+// CloseRing проверяет кольцо полигона и замыкает его, если первая и последняя точки различаются
+func CloseRing(ring []Position) ([]Position, error) {
+	if len(ring) > 0 && ring[0] != ring[len(ring)-1] {
+		ring = append(append([]Position{}, ring...), ring[0])
+	}
+
+	if len(ring) < 4 {
+		return nil, ErrInvalidPolygon
+	}
+
+	for _, p := range ring {
+		if p[0] < -180 || p[0] > 180 || p[1] < -90 || p[1] > 90 {
+			return nil, errors.New("polygon vertex is out of range")
+		}
+	}
+
+	// повторяющиеся и лежащие на одной прямой вершины не образуют зону
+	distinct := make(map[Position]struct{}, len(ring))
+	for _, p := range ring {
+		distinct[p] = struct{}{}
+	}
+	if len(distinct) < 3 || math.Abs(ringArea(ring)) < minRingArea {
+		return nil, ErrInvalidPolygon
+	}
+
+	return ring, nil
+}
+
+// ringArea возвращает ориентированную площадь замкнутого кольца на плоскости (формула шнурков)
+func ringArea(ring []Position) float64 {
+	var area float64
+	for i := 0; i < len(ring)-1; i++ {
+		area += ring[i][0]*ring[i+1][1] - ring[i+1][0]*ring[i][1]
+	}
+	return area / 2
+}
+
+// BoundingCircle возвращает центр кольца и радиус, покрывающий все вершины.
+// Используется для быстрого отбора полигональных зон по расстоянию.
+func BoundingCircle(ring []Position) (lat, lon, radius float64) {
+	// последняя точка замкнутого кольца совпадает с первой
+	vertices := ring
+	if len(ring) > 1 && ring[0] == ring[len(ring)-1] {
+		vertices = ring[:len(ring)-1]
+	}
+
+	for _, p := range vertices {
+		lon += p[0]
+		lat += p[1]
+	}
+	lat /= float64(len(vertices))
+	lon /= float64(len(vertices))
+
+	for _, p := range vertices {
+		radius = math.Max(radius, Distance(lat, lon, p[1], p[0]))
+	}
+
+	// небольшой запас на погрешность округления
+	return lat, lon, math.Ceil(radius) + 1
+}
+
+// PointInPolygon проверяет попадание точки в кольцо (ray casting, плоская аппроксимация)
+func PointInPolygon(lat, lon float64, ring []Position) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+		if (yi > lat) != (yj > lat) && lon < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
@@ -0,0 +1,100 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistance(t *testing.T) {
+	// Красная площадь - Большой театр, около 700 м
+	d := Distance(55.7539, 37.6208, 55.7601, 37.6186)
+	assert.InDelta(t, 700, d, 50)
+
+	assert.Equal(t, 0.0, Distance(10, 20, 10, 20))
+}
+
+func TestCirclePolygon(t *testing.T) {
+	ring := CirclePolygon(55.75, 37.61, 500, 32)
+
+	assert.Len(t, ring, 33)
+	assert.Equal(t, ring[0], ring[len(ring)-1])
+	for _, p := range ring {
+		assert.InDelta(t, 500, Distance(55.75, 37.61, p[1], p[0]), 0.5)
+	}
+}
+
+func TestCloseRing(t *testing.T) {
+	ring, err := CloseRing([]Position{{0, 0}, {1, 0}, {1, 1}})
+	assert.NoError(t, err)
+	assert.Len(t, ring, 4)
+	assert.Equal(t, ring[0], ring[3])
+
+	_, err = CloseRing([]Position{{0, 0}, {1, 0}})
+	assert.ErrorIs(t, err, ErrInvalidPolygon)
+
+	_, err = CloseRing([]Position{{0, 0}, {200, 0}, {1, 1}})
+	assert.Error(t, err)
+
+	// меньше трех различных вершин
+	_, err = CloseRing([]Position{{0, 0}, {1, 0}, {0, 0}, {1, 0}})
+	assert.ErrorIs(t, err, ErrInvalidPolygon)
+
+	// вершины на одной прямой - нулевая площадь
+	_, err = CloseRing([]Position{{0, 0}, {1, 1}, {2, 2}})
+	assert.ErrorIs(t, err, ErrInvalidPolygon)
+}
+
+func TestBoundingCircleAndPointInPolygon(t *testing.T) {
+	square := []Position{{37.60, 55.74}, {37.62, 55.74}, {37.62, 55.76}, {37.60, 55.76}, {37.60, 55.74}}
+
+	lat, lon, radius := BoundingCircle(square)
+	assert.InDelta(t, 55.75, lat, 1e-9)
+	assert.InDelta(t, 37.61, lon, 1e-9)
+	for _, p := range square {
+		assert.LessOrEqual(t, Distance(lat, lon, p[1], p[0]), radius)
+	}
+
+	assert.True(t, PointInPolygon(55.75, 37.61, square))
+	assert.False(t, PointInPolygon(55.77, 37.61, square))
+}
+
+func TestMercator(t *testing.T) {
+	x, y := ToMercator(0, 180)
+	assert.InDelta(t, 20037508.34, x, 0.01)
+	assert.InDelta(t, 0, y, 1e-6)
+
+	// край карты Web Mercator - квадрат
+	_, y = ToMercator(85.0511287798, 0)
+	assert.InDelta(t, 20037508.34, y, 0.01)
+
+	lat, lon := FromMercator(ToMercator(55.75, 37.61))
+	assert.InDelta(t, 55.75, lat, 1e-9)
+	assert.InDelta(t, 37.61, lon, 1e-9)
+}
+
+func TestGeohash(t *testing.T) {
+	// пример из описания geohash
+	assert.Equal(t, "u4pruydqqvj", Geohash(57.64911, 10.40744, 11))
+
+	minLat, minLon, maxLat, maxLon := GeohashBounds("u4pruy")
+	assert.True(t, minLat <= 57.64911 && 57.64911 < maxLat)
+	assert.True(t, minLon <= 10.40744 && 10.40744 < maxLon)
+	assert.Equal(t, "u4pruy", Geohash((minLat+maxLat)/2, (minLon+maxLon)/2, 6))
+}
+
+func TestHexCell(t *testing.T) {
+	const size = 500.0
+	for _, cell := range [][2]int{{0, 0}, {1, 0}, {-1, 2}, {7, -3}} {
+		x, y := HexCenter(cell[0], cell[1], size)
+		ring := HexRing(cell[0], cell[1], size)
+		assert.Len(t, ring, 7)
+		assert.Equal(t, ring[0], ring[6])
+
+		// точки у вершин, чуть ближе к центру, остаются в своей ячейке
+		for _, p := range ring[:6] {
+			i, j := HexCell(x+(p[0]-x)*0.95, y+(p[1]-y)*0.95, size)
+			assert.Equal(t, cell, [2]int{i, j})
+		}
+	}
+}
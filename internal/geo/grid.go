@@ -0,0 +1,125 @@
+package geo
+
+import (
+	"math"
+	"strings"
+)
+
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// Geohash кодирует точку строкой длины precision, как ST_GeoHash
+func Geohash(lat, lon float64, precision int) string {
+	minLat, maxLat := -90.0, 90.0
+	minLon, maxLon := -180.0, 180.0
+
+	hash := make([]byte, 0, precision)
+	var bits, value int
+	even := true
+	for len(hash) < precision {
+		// четные биты кодируют долготу, нечетные - широту
+		if even {
+			mid := (minLon + maxLon) / 2
+			value <<= 1
+			if lon >= mid {
+				value |= 1
+				minLon = mid
+			} else {
+				maxLon = mid
+			}
+		} else {
+			mid := (minLat + maxLat) / 2
+			value <<= 1
+			if lat >= mid {
+				value |= 1
+				minLat = mid
+			} else {
+				maxLat = mid
+			}
+		}
+		even = !even
+
+		if bits++; bits == 5 {
+			hash = append(hash, geohashAlphabet[value])
+			bits, value = 0, 0
+		}
+	}
+
+	return string(hash)
+}
+
+// GeohashBounds возвращает прямоугольник ячейки geohash в градусах
+func GeohashBounds(hash string) (minLat, minLon, maxLat, maxLon float64) {
+	minLat, maxLat = -90.0, 90.0
+	minLon, maxLon = -180.0, 180.0
+
+	even := true
+	for i := 0; i < len(hash); i++ {
+		value := strings.IndexByte(geohashAlphabet, hash[i])
+		for bit := 4; bit >= 0; bit-- {
+			set := value>>bit&1 == 1
+			if even {
+				mid := (minLon + maxLon) / 2
+				if set {
+					minLon = mid
+				} else {
+					maxLon = mid
+				}
+			} else {
+				mid := (minLat + maxLat) / 2
+				if set {
+					minLat = mid
+				} else {
+					maxLat = mid
+				}
+			}
+			even = !even
+		}
+	}
+
+	return minLat, minLon, maxLat, maxLon
+}
+
+// HexCell возвращает индексы шестиугольника со стороной size, содержащего точку x, y
+// в метрах Web Mercator. Сетка совпадает с ST_HexagonGrid: плоские верх и низ,
+// нечетные столбцы сдвинуты вверх на половину высоты.
+func HexCell(x, y, size float64) (i, j int) {
+	column := int(math.Round(x / (1.5 * size)))
+
+	// шестиугольник точки - тот, чей центр ближе всего
+	best := math.Inf(1)
+	for c := column - 1; c <= column+1; c++ {
+		row := int(math.Round((y - hexRowOffset(c, size)) / (math.Sqrt(3) * size)))
+		cx, cy := HexCenter(c, row, size)
+		if d := math.Hypot(x-cx, y-cy); d < best {
+			best, i, j = d, c, row
+		}
+	}
+
+	return i, j
+}
+
+// HexCenter - центр шестиугольника i, j в метрах Web Mercator
+func HexCenter(i, j int, size float64) (x, y float64) {
+	return 1.5 * size * float64(i), math.Sqrt(3)*size*float64(j) + hexRowOffset(i, size)
+}
+
+// HexRing - замкнутое кольцо шестиугольника i, j в метрах Web Mercator, в порядке вершин ST_HexagonGrid
+func HexRing(i, j int, size float64) [][2]float64 {
+	dx := []float64{-1, -0.5, 0.5, 1, 0.5, -0.5, -1}
+	dy := []float64{0, -0.5, -0.5, 0, 0.5, 0.5, 0}
+
+	height := math.Sqrt(3) * size
+	cx, cy := HexCenter(i, j, size)
+	ring := make([][2]float64, len(dx))
+	for k := range dx {
+		ring[k] = [2]float64{cx + size*dx[k], cy + height*dy[k]}
+	}
+	return ring
+}
+
+func hexRowOffset(i int, size float64) float64 {
+	if i%2 != 0 {
+		return math.Sqrt(3) * size / 2
+	}
+	return 0
+}
@@ -0,0 +1,24 @@
+package geo
+
+import "math"
+
+// Web Mercator (EPSG:3857)
+const (
+	MercatorRadius = 6378137.0
+	// MercatorExtent - половина ширины мира в метрах проекции
+	MercatorExtent = math.Pi * MercatorRadius
+)
+
+// ToMercator переводит координаты в метры Web Mercator
+func ToMercator(lat, lon float64) (x, y float64) {
+	x = MercatorRadius * lon * math.Pi / 180
+	y = MercatorRadius * math.Log(math.Tan(math.Pi/4+lat*math.Pi/360))
+	return x, y
+}
+
+// FromMercator - обратное к ToMercator преобразование
+func FromMercator(x, y float64) (lat, lon float64) {
+	lon = x / MercatorRadius * 180 / math.Pi
+	lat = (2*math.Atan(math.Exp(y/MercatorRadius)) - math.Pi/2) * 180 / math.Pi
+	return lat, lon
+}
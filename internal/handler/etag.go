@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+)
+
+// etag for incident is its version in quotes, e.g. "3"
+func versionETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// parse If-Match value into expected version
+// "*" is returned as 0 which means any version
+// the tag must be a single quoted version, e.g. "3"; 3, ""3"" or "3 are rejected
+func parseIfMatch(header string) (int, bool) {
+	header = strings.TrimSpace(header)
+	if header == "*" {
+		return 0, true
+	}
+
+	// If-Match uses strong comparison, so weak tags are not accepted
+	if strings.HasPrefix(header, "W/") || strings.Contains(header, ",") {
+		return 0, false
+	}
+
+	if len(header) < 2 || header[0] != '"' || header[len(header)-1] != '"' {
+		return 0, false
+	}
+
+	value := header[1 : len(header)-1]
+	// Atoi accepts a sign, a version is digits only
+	if value == "" || strings.Trim(value, "0123456789") != "" {
+		return 0, false
+	}
+
+	version, err := strconv.Atoi(value)
+	if err != nil || version < 1 {
+		return 0, false
+	}
+
+	return version, true
+}
+
+// check If-None-Match header against current etag (weak comparison)
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
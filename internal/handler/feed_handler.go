@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"geo-alert-core/internal/domain"
+	"geo-alert-core/internal/format/cap"
+	"geo-alert-core/internal/format/georss"
+	"geo-alert-core/internal/logging"
+	"geo-alert-core/internal/service"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const feedTitle = "Geo Alert active incidents"
+
+// handler for public feeds of active incidents
+type FeedHandler struct {
+	service *service.IncidentService
+	sender  string // CAP sender of our alerts
+}
+
+func NewFeedHandler(incidentService *service.IncidentService, sender string) *FeedHandler {
+	return &FeedHandler{
+		service: incidentService,
+		sender:  sender,
+	}
+}
+
+// zone in a feed and CAP message about its current state
+type feedItem struct {
+	incident *domain.Incident
+	alert    *cap.Alert
+}
+
+// active incidents as Atom feed with CAP 1.2 alerts;
+// recently removed zones stay in the feed as Cancel messages
+// GET /api/v1/feeds/cap.atom
+func (h *FeedHandler) CAPFeed(c *gin.Context) {
+	items, updated, ok := h.loadFeed(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "application/atom+xml; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	writer := cap.NewFeedWriter(c.Writer, cap.FeedInfo{
+		ID:      "urn:geo-alert-core:feeds:cap",
+		Title:   feedTitle,
+		Link:    requestURL(c),
+		Updated: updated,
+	})
+	for _, item := range items {
+		if err := writer.Write(item.incident, item.alert); err != nil {
+			logging.FromContext(c.Request.Context()).Error("cap feed failed", "error", err)
+			return
+		}
+	}
+	if err := writer.Close(); err != nil {
+		logging.FromContext(c.Request.Context()).Error("cap feed failed", "error", err)
+	}
+}
+
+// active incidents as RSS 2.0 feed with GeoRSS geometry
+// GET /api/v1/feeds/georss.xml
+func (h *FeedHandler) GeoRSSFeed(c *gin.Context) {
+	items, updated, ok := h.loadFeed(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "application/rss+xml; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	writer := georss.NewWriter(c.Writer, georss.ChannelInfo{
+		Title:       feedTitle,
+		Link:        requestURL(c),
+		Description: "Danger zones currently in effect",
+		Updated:     updated,
+	})
+	for _, item := range items {
+		// rss has no cancellation, removed zones just leave the channel
+		if item.alert.MsgType == cap.MsgTypeCancel {
+			continue
+		}
+		if err := writer.Write(item.incident); err != nil {
+			logging.FromContext(c.Request.Context()).Error("georss feed failed", "error", err)
+			return
+		}
+	}
+	if err := writer.Close(); err != nil {
+		logging.FromContext(c.Request.Context()).Error("georss feed failed", "error", err)
+	}
+}
+
+// load feed zones and answer conditional request
+// returns ok=false when response is already written (error or 304)
+func (h *FeedHandler) loadFeed(c *gin.Context) ([]feedItem, time.Time, bool) {
+	entries, err := h.service.GetFeedEntries(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get active incidents",
+			"details": err.Error(),
+		})
+		return nil, time.Time{}, false
+	}
+
+	now := time.Now()
+	items := make([]feedItem, 0, len(entries))
+	for _, entry := range entries {
+		if alert := cap.FromFeedEntry(entry, h.sender, now); alert != nil {
+			items = append(items, feedItem{incident: entry.Incident, alert: alert})
+		}
+	}
+
+	etag, updated := feedValidators(items, now)
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "public, max-age=60")
+	if !updated.IsZero() {
+		c.Header("Last-Modified", updated.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(c, etag, updated) {
+		c.Status(http.StatusNotModified)
+		return nil, time.Time{}, false
+	}
+
+	return items, updated, true
+}
+
+// etag covers messages in the feed, so any change, removal or expiry of a zone changes it.
+// Last-Modified is the latest message: removed and expired zones are in the feed as Cancel
+// with the time of removal, so taking a zone down moves it forward too.
+// A Cancel leaving the feed after service.FeedCancelWindow changes only the etag.
+func feedValidators(items []feedItem, now time.Time) (string, time.Time) {
+	hash := sha256.New()
+	var updated time.Time
+	for _, item := range items {
+		hash.Write([]byte(item.alert.Identifier))
+		hash.Write([]byte(item.alert.MsgType))
+
+		if sent := item.alert.SentTime(); sent.After(updated) {
+			updated = sent
+		}
+		// zone scheduled in advance enters the feed at the start of its effective period
+		effective := item.incident.EffectiveAt
+		if item.alert.MsgType != cap.MsgTypeCancel && effective != nil && effective.After(updated) && !effective.After(now) {
+			updated = *effective
+		}
+	}
+	return `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`, updated
+}
+
+// If-None-Match has priority, If-Modified-Since is used only without it (RFC 9110)
+func notModified(c *gin.Context, etag string, updated time.Time) bool {
+	if header := c.GetHeader("If-None-Match"); header != "" {
+		return etagMatches(header, etag)
+	}
+
+	since, err := http.ParseTime(c.GetHeader("If-Modified-Since"))
+	if err != nil || updated.IsZero() {
+		return false
+	}
+	return !updated.Truncate(time.Second).After(since)
+}
+
+func requestURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + c.Request.Host + c.Request.URL.RequestURI()
+}
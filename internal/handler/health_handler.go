@@ -1,15 +1,19 @@
 package handler
 
 import (
+	"geo-alert-core/internal/domain"
+	"geo-alert-core/internal/service"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
-type HealthHandler struct{}
+type HealthHandler struct {
+	service *service.HealthService
+}
 
-func NewHealthHandler() *HealthHandler {
-	return &HealthHandler{}
+func NewHealthHandler(healthService *service.HealthService) *HealthHandler {
+	return &HealthHandler{service: healthService}
 }
 
 func (h *HealthHandler) Health(c *gin.Context) {
@@ -18,3 +22,26 @@ func (h *HealthHandler) Health(c *gin.Context) {
 		"service": "geo-alert-core",
 	})
 }
+
+// liveness probe: the process is running and serves http, dependencies are not checked
+// GET /api/v1/system/live
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":  domain.HealthOK,
+		"service": "geo-alert-core",
+	})
+}
+
+// readiness probe: status and latency of every dependency,
+// 503 when a required one (postgres, postgis) is down;
+// optional ones (redis, migrations, webhooks) only make the status degraded with 200
+// GET /api/v1/system/ready
+func (h *HealthHandler) Ready(c *gin.Context) {
+	report := h.service.Ready(c.Request.Context())
+
+	status := http.StatusOK
+	if report.Status == domain.HealthUnavailable {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"errors"
+	"geo-alert-core/internal/domain"
+	"geo-alert-core/internal/format/cap"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// max size of pushed CAP document
+const maxCAPBodyBytes = 10 << 20
+
+// accept pushed CAP 1.2 alert or Atom feed with inline alerts
+// POST /api/v1/cap/alerts
+func (h *IncidentHandler) IngestCAP(c *gin.Context) {
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxCAPBodyBytes))
+	if err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error":   "Request body is too large",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	feed, err := cap.ParseDocument(data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid CAP document",
+			"details": err.Error(),
+		})
+		return
+	}
+	if len(feed.Alerts) == 0 {
+		// links are only followed by the poller, pushed feeds must carry alerts inline
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Document contains no inline CAP alerts",
+		})
+		return
+	}
+
+	results := make([]*domain.CAPIngestResult, 0, len(feed.Alerts))
+	rejected := 0
+	for _, alert := range feed.Alerts {
+		result, err := h.service.IngestCAPAlert(c.Request.Context(), alert)
+		if err != nil {
+			if !isCAPValidationError(err) {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error":   "Failed to ingest CAP alert",
+					"details": err.Error(),
+					"results": results,
+				})
+				return
+			}
+			rejected++
+			results = append(results, &domain.CAPIngestResult{
+				Identifier: alert.Identifier,
+				MsgType:    alert.MsgType,
+				Error:      err.Error(),
+			})
+			continue
+		}
+		results = append(results, result)
+	}
+
+	status := http.StatusOK
+	if rejected == len(feed.Alerts) {
+		status = http.StatusUnprocessableEntity
+	}
+	c.JSON(status, gin.H{
+		"results":  results,
+		"rejected": rejected,
+	})
+}
+
+func isCAPValidationError(err error) bool {
+	return errors.Is(err, domain.ErrInvalidIncident) ||
+		errors.Is(err, domain.ErrInvalidCoordinates) ||
+		errors.Is(err, domain.ErrInvalidRadius) ||
+		errors.Is(err, domain.ErrInvalidSeverity)
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"geo-alert-core/internal/domain"
+	"geo-alert-core/internal/format/csv"
+	"geo-alert-core/internal/logging"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// max size of uploaded CSV, the file is streamed so it can be much larger than GeoJSON
+const maxCSVImportBodyBytes = 512 << 20
+
+// export incidents as CSV, filters are the same as for the list
+// GET /api/v1/incidents.csv
+func (h *IncidentHandler) ExportCSV(c *gin.Context) {
+	filter, err := parseExportFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid filter",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	started := false
+	start := func() {
+		if !started {
+			c.Header("Content-Type", "text/csv; charset=utf-8")
+			c.Header("Content-Disposition", `attachment; filename="incidents.csv"`)
+			c.Status(http.StatusOK)
+			started = true
+		}
+	}
+
+	writer := csv.NewIncidentWriter(c.Writer)
+	err = h.service.ExportIncidents(c.Request.Context(), filter, func(incident *domain.Incident) error {
+		start()
+		return writer.Write(incident)
+	})
+	if err != nil {
+		respondExportError(c, started, err)
+		return
+	}
+
+	start()
+	if err := writer.Close(); err != nil {
+		logging.FromContext(c.Request.Context()).Error("csv export failed", "error", err)
+	}
+}
+
+// import incidents from CSV, rows are read one by one
+// report contains only rejected rows unless full_report=true
+// POST /api/v1/incidents/import?dry_run=true (Content-Type: text/csv)
+func (h *IncidentHandler) ImportCSV(c *gin.Context) {
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxCSVImportBodyBytes)
+
+	opts := domain.ImportOptions{
+		DryRun:       c.Query("dry_run") == "true",
+		RejectedOnly: c.Query("full_report") != "true",
+	}
+	report, err := h.service.ImportIncidents(c.Request.Context(), csv.ReadIncidents(body), opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Import failed",
+			"details": err.Error(),
+			"report":  report,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
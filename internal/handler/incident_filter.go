@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"fmt"
+	"geo-alert-core/internal/domain"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parse filters, sorting and pagination of incident list from query string
+func parseIncidentFilter(c *gin.Context) (*domain.IncidentFilter, error) {
+	filter := &domain.IncidentFilter{
+		Query:        c.Query("q"),
+		Category:     c.Query("category"),
+		IncludeTotal: c.Query("include_total") == "true",
+	}
+
+	if v := c.Query("is_active"); v != "" {
+		isActive, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("is_active must be true or false")
+		}
+		filter.IsActive = &isActive
+	}
+
+	// deleted=true lists deleted zones that are kept until purge
+	if v := c.Query("deleted"); v != "" {
+		deleted, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("deleted must be true or false")
+		}
+		filter.Deleted = deleted
+	}
+
+	if v := c.Query("severity"); v != "" {
+		filter.Severities = strings.Split(v, ",")
+	}
+
+	timeParams := []struct {
+		name string
+		dest **time.Time
+	}{
+		{"created_from", &filter.CreatedFrom},
+		{"created_to", &filter.CreatedTo},
+		{"updated_from", &filter.UpdatedFrom},
+		{"updated_to", &filter.UpdatedTo},
+	}
+	for _, p := range timeParams {
+		v := c.Query(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be in RFC3339 format", p.name)
+		}
+		*p.dest = &t
+	}
+
+	// bbox=minLon,minLat,maxLon,maxLat
+	if v := c.Query("bbox"); v != "" {
+		values, err := parseFloats(v, 4)
+		if err != nil {
+			return nil, fmt.Errorf("bbox must be minLon,minLat,maxLon,maxLat")
+		}
+		filter.BBox = &domain.BoundingBox{
+			MinLongitude: values[0],
+			MinLatitude:  values[1],
+			MaxLongitude: values[2],
+			MaxLatitude:  values[3],
+		}
+	}
+
+	// near_lat, near_lon, near_meters - zones within N metres of the point
+	nearLat, nearLon := c.Query("near_lat"), c.Query("near_lon")
+	if nearLat != "" || nearLon != "" {
+		lat, errLat := strconv.ParseFloat(nearLat, 64)
+		lon, errLon := strconv.ParseFloat(nearLon, 64)
+		if errLat != nil || errLon != nil {
+			return nil, fmt.Errorf("near_lat and near_lon must both be numbers")
+		}
+		meters, err := strconv.ParseFloat(c.DefaultQuery("near_meters", "0"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("near_meters must be a number")
+		}
+		filter.Near = &domain.NearPoint{Latitude: lat, Longitude: lon, Meters: meters}
+	}
+
+	// sort=created_at (ascending) or sort=-created_at (descending)
+	if v := c.Query("sort"); v != "" {
+		filter.SortBy = strings.TrimPrefix(v, "-")
+		filter.SortDesc = strings.HasPrefix(v, "-")
+	}
+
+	page, limit, err := parsePagination(c)
+	if err != nil {
+		return nil, err
+	}
+	filter.Limit = limit
+	filter.Offset = (page - 1) * limit
+
+	return filter, nil
+}
+
+// parse page and page size, an invalid value is an error rather than a silent default
+// so that the client does not get another page than it asked for
+func parsePagination(c *gin.Context) (page, limit int, err error) {
+	// limit is an alias of page_size for cursor pagination
+	limit, err = strconv.Atoi(c.DefaultQuery("limit", c.DefaultQuery("page_size", "20")))
+	if err != nil || limit < 1 || limit > 100 {
+		return 0, 0, fmt.Errorf("limit must be an integer from 1 to 100")
+	}
+
+	page, err = strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		return 0, 0, fmt.Errorf("page must be a positive integer")
+	}
+
+	return page, limit, nil
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"geo-alert-core/internal/domain"
+	"geo-alert-core/internal/format/geojson"
+	"geo-alert-core/internal/logging"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// max size of uploaded GeoJSON document
+const maxImportBodyBytes = 20 << 20
+
+// export incidents as GeoJSON FeatureCollection
+// GET /api/v1/incidents.geojson
+func (h *IncidentHandler) ExportGeoJSON(c *gin.Context) {
+	filter, err := parseExportFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid filter",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	opts := geojson.Options{
+		CirclesAsPolygons: c.Query("circles") == "polygon",
+	}
+	opts.Vertices, _ = strconv.Atoi(c.DefaultQuery("vertices", "64"))
+	if opts.Vertices < 8 || opts.Vertices > 360 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "vertices must be between 8 and 360",
+		})
+		return
+	}
+
+	// headers are sent with the first feature, so filter errors can still get a proper status
+	started := false
+	start := func() {
+		if !started {
+			c.Header("Content-Type", "application/geo+json")
+			c.Status(http.StatusOK)
+			started = true
+		}
+	}
+
+	writer := geojson.NewWriter(c.Writer)
+	err = h.service.ExportIncidents(c.Request.Context(), filter, func(incident *domain.Incident) error {
+		start()
+		return writer.Write(geojson.FromIncident(incident, opts))
+	})
+	if err != nil {
+		respondExportError(c, started, err)
+		return
+	}
+
+	start()
+	if err := writer.Close(); err != nil {
+		logging.FromContext(c.Request.Context()).Error("geojson export failed", "error", err)
+	}
+}
+
+// import incidents, format is chosen by Content-Type (text/csv or GeoJSON by default)
+// POST /api/v1/incidents/import
+func (h *IncidentHandler) Import(c *gin.Context) {
+	if c.ContentType() == "text/csv" {
+		h.ImportCSV(c)
+		return
+	}
+	h.ImportGeoJSON(c)
+}
+
+// import incidents from GeoJSON FeatureCollection
+// POST /api/v1/incidents/import?dry_run=true
+func (h *IncidentHandler) ImportGeoJSON(c *gin.Context) {
+	var collection geojson.FeatureCollection
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBodyBytes)
+	if err := json.NewDecoder(body).Decode(&collection); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid GeoJSON",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if collection.Type != "FeatureCollection" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Expected a FeatureCollection",
+		})
+		return
+	}
+
+	items := make([]domain.ImportItem, len(collection.Features))
+	for i, feature := range collection.Features {
+		incident, err := geojson.ToIncident(feature)
+		items[i] = domain.ImportItem{Index: i, Incident: incident, Err: err}
+	}
+
+	opts := domain.ImportOptions{DryRun: c.Query("dry_run") == "true"}
+	report, err := h.service.ImportIncidents(c.Request.Context(), slices.Values(items), opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Import failed",
+			"details": err.Error(),
+			"report":  report,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// filters for exports: same as list, but only active incidents by default
+func parseExportFilter(c *gin.Context) (*domain.IncidentFilter, error) {
+	filter, err := parseIncidentFilter(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Query("is_active") == "" {
+		isActive := true
+		filter.IsActive = &isActive
+	}
+
+	return filter, nil
+}
+
+// report error of streaming export
+// if response is already started, client just gets truncated document
+func respondExportError(c *gin.Context, started bool, err error) {
+	if started {
+		logging.FromContext(c.Request.Context()).Error("export failed", "error", err)
+		return
+	}
+
+	if errors.Is(err, domain.ErrInvalidFilter) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid filter",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "Export failed",
+		"details": err.Error(),
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"geo-alert-core/internal/service"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -38,7 +39,7 @@ func (h *IncidentHandler) Create(c *gin.Context) {
 
 	incident, err := h.service.CreateIncident(c.Request.Context(), &req)
 	if err != nil {
-		if errors.Is(err, domain.ErrInvalidCoordinates) || errors.Is(err, domain.ErrInvalidRadius) {
+		if errors.Is(err, domain.ErrInvalidCoordinates) || errors.Is(err, domain.ErrInvalidRadius) || errors.Is(err, domain.ErrInvalidSeverity) {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error":   "Validation failed",
 				"details": err.Error(),
@@ -53,6 +54,7 @@ func (h *IncidentHandler) Create(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", versionETag(incident.Version))
 	c.JSON(http.StatusCreated, incident)
 }
 
@@ -84,16 +86,77 @@ func (h *IncidentHandler) GetByID(c *gin.Context) {
 		return
 	}
 
+	// cheap polling: client sends last seen etag and gets 304 if nothing changed
+	etag := versionETag(incident.Version)
+	c.Header("ETag", etag)
+	if ifNoneMatch := c.GetHeader("If-None-Match"); ifNoneMatch != "" && etagMatches(ifNoneMatch, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
 	c.JSON(http.StatusOK, incident)
 }
 
+// list incidents with filters, sorting and pagination
+// GET /api/v1/incidents
 func (h *IncidentHandler) GetAll(c *gin.Context) {
-	// parse pagination parameters
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	// point-in-time query: state of incidents at given moment
+	if asOfStr := c.Query("as_of"); asOfStr != "" {
+		page, pageSize, err := parsePagination(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid filter",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		asOf, err := time.Parse(time.RFC3339, asOfStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid as_of parameter",
+				"details": "as_of must be in RFC3339 format",
+			})
+			return
+		}
+
+		incidents, err := h.service.GetAllIncidentsAsOf(c.Request.Context(), asOf, page, pageSize)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to get incidents",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"data":      incidents,
+			"page":      page,
+			"page_size": pageSize,
+			"as_of":     asOf,
+		})
+		return
+	}
 
-	incidents, err := h.service.GetAllIncidents(c.Request.Context(), page, pageSize)
+	filter, err := parseIncidentFilter(c)
 	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid filter",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	result, nextCursor, err := h.service.ListIncidents(c.Request.Context(), filter, c.Query("cursor"))
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidFilter) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid filter",
+				"details": err.Error(),
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to get incidents",
 			"details": err.Error(),
@@ -101,13 +164,59 @@ func (h *IncidentHandler) GetAll(c *gin.Context) {
 		return
 	}
 
+	items := result.Items
+	if items == nil {
+		items = []*domain.Incident{}
+	}
+
+	response := gin.H{
+		"data":        items,
+		"page":        filter.Offset/filter.Limit + 1,
+		"page_size":   filter.Limit,
+		"next_cursor": nextCursor,
+	}
+	if result.Total != nil {
+		response["total"] = *result.Total
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// get all versions of incident with validity ranges
+// GET /api/v1/incidents/:id/versions
+func (h *IncidentHandler) GetVersions(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid incident ID",
+		})
+		return
+	}
+
+	versions, err := h.service.GetIncidentVersions(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrIncidentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Incident not found",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get incident versions",
+			"details": err.Error(),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"data":      incidents,
-		"page":      page,
-		"page_size": pageSize,
+		"data": versions,
 	})
 }
 
+// update incident, requires If-Match with etag from GetByID
+// PUT /api/v1/incidents/:id
 func (h *IncidentHandler) Update(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
@@ -118,6 +227,22 @@ func (h *IncidentHandler) Update(c *gin.Context) {
 		return
 	}
 
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" {
+		c.JSON(http.StatusPreconditionRequired, gin.H{
+			"error": "If-Match header is required",
+		})
+		return
+	}
+
+	expectedVersion, ok := parseIfMatch(ifMatch)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid If-Match header",
+		})
+		return
+	}
+
 	var req domain.UpdateIncidentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -127,7 +252,7 @@ func (h *IncidentHandler) Update(c *gin.Context) {
 		return
 	}
 
-	incident, err := h.service.UpdateIncident(c.Request.Context(), id, &req)
+	incident, err := h.service.UpdateIncident(c.Request.Context(), id, &req, expectedVersion)
 	if err != nil {
 		if errors.Is(err, domain.ErrIncidentNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{
@@ -136,7 +261,14 @@ func (h *IncidentHandler) Update(c *gin.Context) {
 			return
 		}
 
-		if errors.Is(err, domain.ErrInvalidCoordinates) || errors.Is(err, domain.ErrInvalidRadius) {
+		if errors.Is(err, domain.ErrVersionConflict) {
+			c.JSON(http.StatusPreconditionFailed, gin.H{
+				"error": "Incident was modified by someone else, reload it and retry",
+			})
+			return
+		}
+
+		if errors.Is(err, domain.ErrInvalidCoordinates) || errors.Is(err, domain.ErrInvalidRadius) || errors.Is(err, domain.ErrInvalidSeverity) {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error":   "Validation failed",
 				"details": err.Error(),
@@ -151,6 +283,7 @@ func (h *IncidentHandler) Update(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", versionETag(incident.Version))
 	c.JSON(http.StatusOK, incident)
 }
 
@@ -183,3 +316,65 @@ func (h *IncidentHandler) Delete(c *gin.Context) {
 		"message": "Incident deleted successfully",
 	})
 }
+
+// undo delete, restoring an incident that is not deleted is a no-op
+// POST /api/v1/incidents/:id/restore
+func (h *IncidentHandler) Restore(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid incident ID",
+		})
+		return
+	}
+
+	incident, err := h.service.RestoreIncident(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrIncidentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Incident not found",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to restore incident",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.Header("ETag", versionETag(incident.Version))
+	c.JSON(http.StatusOK, incident)
+}
+
+// physically remove incidents deleted earlier than retention_days ago (default from config),
+// their versions and links to location checks are removed too
+// POST /api/v1/incidents/purge
+func (h *IncidentHandler) Purge(c *gin.Context) {
+	var retention *time.Duration
+	if v := c.Query("retention_days"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid retention_days",
+				"details": "retention_days must be a non-negative integer",
+			})
+			return
+		}
+		d := time.Duration(days) * 24 * time.Hour
+		retention = &d
+	}
+
+	result, err := h.service.PurgeDeletedIncidents(c.Request.Context(), retention)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to purge incidents",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"geo-alert-core/internal/domain"
+	"geo-alert-core/internal/format/kml"
+	"geo-alert-core/internal/logging"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// export incidents as KML, circles are approximated by polygons
+// GET /api/v1/incidents.kml?vertices=64
+func (h *IncidentHandler) ExportKML(c *gin.Context) {
+	filter, err := parseExportFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid filter",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	vertices, _ := strconv.Atoi(c.DefaultQuery("vertices", "64"))
+	if vertices < 8 || vertices > 360 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "vertices must be between 8 and 360",
+		})
+		return
+	}
+
+	started := false
+	start := func() {
+		if !started {
+			c.Header("Content-Type", "application/vnd.google-earth.kml+xml")
+			c.Header("Content-Disposition", `attachment; filename="incidents.kml"`)
+			c.Status(http.StatusOK)
+			started = true
+		}
+	}
+
+	writer := kml.NewWriter(c.Writer, vertices)
+	err = h.service.ExportIncidents(c.Request.Context(), filter, func(incident *domain.Incident) error {
+		start()
+		return writer.Write(incident)
+	})
+	if err != nil {
+		respondExportError(c, started, err)
+		return
+	}
+
+	start()
+	if err := writer.Close(); err != nil {
+		logging.FromContext(c.Request.Context()).Error("kml export failed", "error", err)
+	}
+}
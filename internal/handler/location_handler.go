@@ -1,11 +1,18 @@
 package handler
 
 import (
+	"errors"
 	"geo-alert-core/internal/domain"
+	"geo-alert-core/internal/format/csv"
+	"geo-alert-core/internal/format/gpx"
+	"geo-alert-core/internal/logging"
 	"geo-alert-core/internal/service"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 // handler for checking coordinates
@@ -41,3 +48,212 @@ func (h *LocationHandler) CheckLocation(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+// replay location check against historical geometry
+// POST /api/v1/location/replay
+func (h *LocationHandler) ReplayLocation(c *gin.Context) {
+	var req domain.LocationReplayRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	response, err := h.service.ReplayLocation(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidCoordinates) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Validation failed",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, domain.ErrLocationCheckNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "No location check found for user before as_of",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to replay location check",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// export location checks as GPX tracks, one track per user
+// GET /api/v1/location/checks.gpx?user_id=&from=&to=
+func (h *LocationHandler) ExportGPX(c *gin.Context) {
+	from, to, err := parseTimeRange(c, 24*time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid time range",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	filter := &domain.LocationCheckFilter{
+		UserID: c.Query("user_id"),
+		From:   from,
+		To:     to,
+	}
+
+	started := false
+	start := func() {
+		if !started {
+			c.Header("Content-Type", "application/gpx+xml")
+			c.Header("Content-Disposition", `attachment; filename="tracks.gpx"`)
+			c.Status(http.StatusOK)
+			started = true
+		}
+	}
+
+	writer := gpx.NewWriter(c.Writer)
+	err = h.service.ExportChecks(c.Request.Context(), filter, func(check *domain.LocationCheck) error {
+		start()
+		return writer.Write(check)
+	})
+	if err != nil {
+		respondExportError(c, started, err)
+		return
+	}
+
+	start()
+	if err := writer.Close(); err != nil {
+		logging.FromContext(c.Request.Context()).Error("gpx export failed", "error", err)
+	}
+}
+
+// export location checks as CSV with ids of incidents they hit
+// GET /api/v1/location/checks.csv?user_id=&from=&to=
+func (h *LocationHandler) ExportChecksCSV(c *gin.Context) {
+	from, to, err := parseTimeRange(c, 24*time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid time range",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	filter := &domain.LocationCheckFilter{
+		UserID: c.Query("user_id"),
+		From:   from,
+		To:     to,
+	}
+
+	started := false
+	start := func() {
+		if !started {
+			c.Header("Content-Type", "text/csv; charset=utf-8")
+			c.Header("Content-Disposition", `attachment; filename="location_checks.csv"`)
+			c.Status(http.StatusOK)
+			started = true
+		}
+	}
+
+	writer := csv.NewCheckWriter(c.Writer)
+	err = h.service.ExportChecks(c.Request.Context(), filter, func(check *domain.LocationCheck) error {
+		start()
+		return writer.Write(check)
+	})
+	if err != nil {
+		respondExportError(c, started, err)
+		return
+	}
+
+	start()
+	if err := writer.Close(); err != nil {
+		logging.FromContext(c.Request.Context()).Error("csv export failed", "error", err)
+	}
+}
+
+// density of location checks aggregated into geohash or hexagon cells
+// GET /api/v1/location/heatmap?bbox=&from=&to=&grid=&precision=&cell_size=&only_hits=&incident_id=
+func (h *LocationHandler) Heatmap(c *gin.Context) {
+	filter, err := parseHeatmapFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid heatmap parameters",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	heatmap, err := h.service.Heatmap(c.Request.Context(), filter)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidFilter) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid heatmap parameters",
+				"details": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to build heatmap",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, heatmap)
+}
+
+func parseHeatmapFilter(c *gin.Context) (*domain.HeatmapFilter, error) {
+	from, to, err := parseTimeRange(c, 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	// bbox=minLon,minLat,maxLon,maxLat
+	values, err := parseFloats(c.Query("bbox"), 4)
+	if err != nil {
+		return nil, errors.New("bbox=minLon,minLat,maxLon,maxLat is required")
+	}
+
+	filter := &domain.HeatmapFilter{
+		Grid: c.Query("grid"),
+		BBox: domain.BoundingBox{
+			MinLongitude: values[0],
+			MinLatitude:  values[1],
+			MaxLongitude: values[2],
+			MaxLatitude:  values[3],
+		},
+		From: from,
+		To:   to,
+	}
+
+	if v := c.Query("precision"); v != "" {
+		if filter.Precision, err = strconv.Atoi(v); err != nil {
+			return nil, errors.New("precision must be an integer")
+		}
+	}
+	if v := c.Query("cell_size"); v != "" {
+		if filter.CellSize, err = strconv.ParseFloat(v, 64); err != nil {
+			return nil, errors.New("cell_size must be a number of meters")
+		}
+	}
+	if v := c.Query("only_hits"); v != "" {
+		if filter.OnlyHits, err = strconv.ParseBool(v); err != nil {
+			return nil, errors.New("only_hits must be true or false")
+		}
+	}
+	if v := c.Query("incident_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return nil, errors.New("incident_id must be a UUID")
+		}
+		filter.IncidentID = &id
+	}
+
+	return filter, nil
+}
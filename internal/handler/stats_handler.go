@@ -1,11 +1,15 @@
 package handler
 
 import (
+	"errors"
+	"geo-alert-core/internal/domain"
 	"geo-alert-core/internal/service"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 // handler for stats
@@ -19,22 +23,47 @@ func NewStatsHandler(statsService *service.StatsService) *StatsHandler {
 	}
 }
 
+// stats of active zones for a period
+// GET /api/v1/incidents/stats?minutes=60 | ?window=today | ?from=&to=
 func (h *StatsHandler) GetStats(c *gin.Context) {
-	minutesStr := c.Query("minutes")
-	minutes := 0
-	if minutesStr != "" {
-		var err error
-		minutes, err = strconv.Atoi(minutesStr)
+	query := &domain.StatsQuery{
+		Window: c.Query("window"),
+	}
+
+	if minutesStr := c.Query("minutes"); minutesStr != "" {
+		minutes, err := strconv.Atoi(minutesStr)
 		if err != nil || minutes <= 0 {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error": "Invalid minutes parameter",
 			})
 			return
 		}
+		query.Minutes = minutes
+	}
+
+	var err error
+	if query.From, err = parseOptionalTime(c, "from"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if query.To, err = parseOptionalTime(c, "to"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
 	}
 
-	stats, err := h.service.GetStats(c.Request.Context(), minutes)
+	report, err := h.service.GetStats(c.Request.Context(), query)
 	if err != nil {
+		if errors.Is(err, domain.ErrInvalidFilter) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid stats period",
+				"details": err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to get stats",
 			"details": err.Error(),
@@ -42,7 +71,55 @@ func (h *StatsHandler) GetStats(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": stats,
+	c.JSON(http.StatusOK, report)
+}
+
+// bucketed series of users, checks and entries for one zone
+// GET /api/v1/incidents/:id/stats/timeseries?from=&to=&bucket=minute|hour|day
+func (h *StatsHandler) GetTimeSeries(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid incident ID",
+		})
+		return
+	}
+
+	from, to, err := parseTimeRange(c, 24*time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid time range",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	series, err := h.service.GetTimeSeries(c.Request.Context(), &domain.TimeSeriesFilter{
+		IncidentID: id,
+		From:       from,
+		To:         to,
+		Bucket:     c.Query("bucket"),
 	})
+	if err != nil {
+		if errors.Is(err, domain.ErrIncidentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Incident not found",
+			})
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidFilter) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid time series parameters",
+				"details": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get time series",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, series)
 }
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"geo-alert-core/internal/domain"
+	"geo-alert-core/internal/service"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handler for vector tiles
+type TileHandler struct {
+	service *service.TileService
+}
+
+func NewTileHandler(tileService *service.TileService) *TileHandler {
+	return &TileHandler{
+		service: tileService,
+	}
+}
+
+// incidents as Mapbox Vector Tile, layer "incidents"
+// GET /api/v1/tiles/:z/:x/:y.mvt
+func (h *TileHandler) GetTile(c *gin.Context) {
+	// gin can't match ":y.mvt", so the extension is part of the last param
+	yParam, ok := strings.CutSuffix(c.Param("y"), ".mvt")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Tile must be requested with .mvt extension",
+		})
+		return
+	}
+
+	z, errZ := strconv.Atoi(c.Param("z"))
+	x, errX := strconv.Atoi(c.Param("x"))
+	y, errY := strconv.Atoi(yParam)
+	if errZ != nil || errX != nil || errY != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Tile coordinates must be integers",
+		})
+		return
+	}
+
+	tile, err := h.service.GetTile(c.Request.Context(), z, x, y)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidTile) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid tile",
+				"details": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to build tile",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	sum := sha256.Sum256(tile)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, max-age=60")
+	if header := c.GetHeader("If-None-Match"); header != "" && etagMatches(header, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/vnd.mapbox-vector-tile", tile)
+}
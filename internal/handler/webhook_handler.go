@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"errors"
+	"geo-alert-core/internal/domain"
+	"geo-alert-core/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handler for the webhook delivery log
+type WebhookHandler struct {
+	service *service.WebhookDeliveryService
+}
+
+func NewWebhookHandler(deliveryService *service.WebhookDeliveryService) *WebhookHandler {
+	return &WebhookHandler{
+		service: deliveryService,
+	}
+}
+
+// list recent webhook deliveries, newest first
+// GET /api/v1/webhooks/deliveries?status=failed&limit=50
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	filter := &domain.WebhookDeliveryFilter{Status: c.Query("status")}
+	if limit := c.Query("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid filter",
+				"details": "limit must be an integer",
+			})
+			return
+		}
+		filter.Limit = value
+	}
+
+	deliveries, err := h.service.ListDeliveries(c.Request.Context(), filter)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidFilter) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid filter",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list webhook deliveries",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": deliveries,
+	})
+}
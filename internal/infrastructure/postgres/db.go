@@ -3,6 +3,9 @@ package postgres
 import (
 	"database/sql"
 	"fmt"
+
+	// registers the "postgres" driver for database/sql
+	_ "github.com/lib/pq"
 )
 
 func NewDB(dsn string) (*sql.DB, error) {
@@ -0,0 +1,32 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// PingCheck checks that the database accepts connections
+func PingCheck(db *sql.DB) func(ctx context.Context) (map[string]any, error) {
+	return func(ctx context.Context) (map[string]any, error) {
+		if err := db.PingContext(ctx); err != nil {
+			return nil, fmt.Errorf("failed to ping database: %w", err)
+		}
+		stats := db.Stats()
+		return map[string]any{
+			"open_connections": stats.OpenConnections,
+			"in_use":           stats.InUse,
+		}, nil
+	}
+}
+
+// PostGISCheck checks that the postgis extension is installed and callable
+func PostGISCheck(db *sql.DB) func(ctx context.Context) (map[string]any, error) {
+	return func(ctx context.Context) (map[string]any, error) {
+		var version string
+		if err := db.QueryRowContext(ctx, "SELECT postgis_lib_version()").Scan(&version); err != nil {
+			return nil, fmt.Errorf("postgis is not available: %w", err)
+		}
+		return map[string]any{"version": version}, nil
+	}
+}
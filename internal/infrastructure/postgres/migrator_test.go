@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"geo-alert-core/migrations"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"002_zones.up.sql":     {Data: []byte("CREATE TABLE zones (id INT);")},
+		"002_zones.down.sql":   {Data: []byte("DROP TABLE zones;")},
+		"001_initial.up.sql":   {Data: []byte("CREATE TABLE a (id INT);")},
+		"001_initial.down.sql": {Data: []byte("DROP TABLE a;")},
+		"003_no_down.up.sql":   {Data: []byte("SELECT 1;")},
+		"README.md":            {Data: []byte("not a migration")},
+	}
+
+	loaded, err := LoadMigrations(fsys)
+	require.NoError(t, err)
+	require.Len(t, loaded, 3)
+
+	assert.Equal(t, 1, loaded[0].Version)
+	assert.Equal(t, "initial", loaded[0].Name)
+	assert.Equal(t, "DROP TABLE a;", loaded[0].Down)
+	assert.Len(t, loaded[0].Checksum, 64)
+	assert.Equal(t, "zones", loaded[1].Name)
+	assert.Empty(t, loaded[2].Down)
+}
+
+func TestLoadMigrations_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{"down without up", fstest.MapFS{
+			"001_initial.down.sql": {Data: []byte("DROP TABLE a;")},
+		}},
+		{"different names for one version", fstest.MapFS{
+			"001_initial.up.sql": {Data: []byte("SELECT 1;")},
+			"001_other.down.sql": {Data: []byte("SELECT 1;")},
+		}},
+		{"zero version", fstest.MapFS{
+			"000_initial.up.sql": {Data: []byte("SELECT 1;")},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadMigrations(tt.fsys)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	loaded, err := LoadMigrations(migrations.FS)
+	require.NoError(t, err)
+	require.NotEmpty(t, loaded)
+
+	// версии идут подряд и у каждой есть откат
+	for i, migration := range loaded {
+		assert.Equal(t, i+1, migration.Version)
+		assert.NotEmpty(t, migration.Down, "migration %d has no down script", migration.Version)
+	}
+}
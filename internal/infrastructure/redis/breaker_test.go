@@ -0,0 +1,69 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	breaker := newCircuitBreaker(3, 10*time.Second)
+	breaker.now = func() time.Time { return now }
+	timeout := errors.New("i/o timeout")
+
+	// промахи кэша и ответы с ошибкой не считаются отказом
+	breaker.record(redis.Nil, false)
+	breaker.record(redis.TxFailedErr, false)
+	assert.Equal(t, CircuitClosed, breaker.state())
+
+	for i := 0; i < 3; i++ {
+		_, err := breaker.allow()
+		require.NoError(t, err)
+		breaker.record(timeout, false)
+	}
+	assert.Equal(t, CircuitOpen, breaker.state())
+	_, err := breaker.allow()
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+
+	// после паузы пропускаем ровно одну пробную команду
+	now = now.Add(10 * time.Second)
+	probe, err := breaker.allow()
+	require.NoError(t, err)
+	assert.True(t, probe)
+	_, err = breaker.allow()
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+
+	// неудачная проба - еще одна пауза
+	breaker.record(timeout, probe)
+	assert.Equal(t, CircuitOpen, breaker.state())
+
+	now = now.Add(10 * time.Second)
+	probe, err = breaker.allow()
+	require.NoError(t, err)
+	breaker.record(nil, probe)
+	assert.Equal(t, CircuitClosed, breaker.state())
+}
+
+func TestClient_FailsFastWhenUnavailable(t *testing.T) {
+	// на этом порту никто не слушает
+	client := NewClient("127.0.0.1:1", "", 0, 100*time.Millisecond, time.Minute)
+	defer client.Close()
+	ctx := context.Background()
+
+	for i := 0; i < breakerFailureThreshold; i++ {
+		assert.Error(t, client.Ping(ctx))
+	}
+
+	err := client.GetClient().Get(ctx, "key").Err()
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+
+	details, err := client.HealthCheck(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, CircuitOpen, details["circuit"])
+}
@@ -3,33 +3,85 @@ package redis
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
 type Client struct {
-	client *redis.Client
+	client  *redis.Client
+	breaker *circuitBreaker
 }
 
-func NewClient(addr, password string, db int) (*Client, error) {
+// NewClient does not require redis to be up: commands fail fast while the circuit is open
+// and go-redis reconnects by itself once redis is back, see Run
+func NewClient(addr, password string, db int, timeout, cooldown time.Duration) *Client {
 	rdb := redis.NewClient(&redis.Options{
-		Addr:     addr,
-		Password: password,
-		DB:       db,
+		Addr:         addr,
+		Password:     password,
+		DB:           db,
+		DialTimeout:  timeout,
+		ReadTimeout:  timeout,
+		WriteTimeout: timeout,
+		// failures are handled by the breaker, retries would only multiply the timeout
+		MaxRetries: -1,
 	})
 
-	ctx := context.Background()
-	if err := rdb.Ping(ctx).Err(); err != nil {
-		return nil, fmt.Errorf("failed to ping redis: %w", err)
-	}
+	breaker := newCircuitBreaker(breakerFailureThreshold, cooldown)
+	rdb.AddHook(breakerHook{breaker: breaker})
+	rdb.AddHook(tracingHook{})
 
-	return &Client{client: rdb}, nil
+	return &Client{client: rdb, breaker: breaker}
 }
 
 func (c *Client) GetClient() *redis.Client {
 	return c.client
 }
 
+// Ping checks the connection, used at startup to warn that the service runs without redis
+func (c *Client) Ping(ctx context.Context) error {
+	if err := c.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to ping redis: %w", err)
+	}
+	return nil
+}
+
+// Run probes redis while the circuit is not closed, so the cache comes back
+// even when no requests touch redis. Stops when ctx is cancelled.
+func (c *Client) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.breaker.cooldown)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if c.breaker.state() == CircuitHalfOpen {
+			if err := c.Ping(ctx); err != nil {
+				slog.Debug("redis is still unavailable", "error", err)
+			}
+		}
+	}
+}
+
+// HealthCheck pings redis for the readiness endpoint
+func (c *Client) HealthCheck(ctx context.Context) (map[string]any, error) {
+	state := c.breaker.state()
+	if err := c.client.Ping(ctx).Err(); err != nil {
+		return map[string]any{"circuit": state}, fmt.Errorf("failed to ping redis: %w", err)
+	}
+	stats := c.client.PoolStats()
+	return map[string]any{
+		"circuit":           c.breaker.state(),
+		"total_connections": stats.TotalConns,
+		"idle_connections":  stats.IdleConns,
+	}, nil
+}
+
 func (c *Client) Close() error {
 	return c.client.Close()
 }
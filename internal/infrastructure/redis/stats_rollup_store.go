@@ -0,0 +1,176 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"geo-alert-core/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	rollupSinceKey     = "stats:rollup:since"
+	rollupWatermarkKey = "stats:rollup:watermark"
+)
+
+// StatsRollupStore keeps per-zone aggregates in redis:
+// unique users in HyperLogLog (PFCOUNT over several keys gives the union), checks in counters
+type StatsRollupStore struct {
+	client *redis.Client
+}
+
+func NewStatsRollupStore(client *redis.Client) *StatsRollupStore {
+	return &StatsRollupStore{client: client}
+}
+
+func rollupUsersKey(granularity string, incidentID uuid.UUID, start time.Time) string {
+	return fmt.Sprintf("stats:users:%s:%s:%d", granularity, incidentID, start.Unix())
+}
+
+func rollupChecksKey(granularity string, incidentID uuid.UUID, start time.Time) string {
+	return fmt.Sprintf("stats:checks:%s:%s:%d", granularity, incidentID, start.Unix())
+}
+
+func (s *StatsRollupStore) State(ctx context.Context) (time.Time, time.Time, error) {
+	values, err := s.client.MGet(ctx, rollupSinceKey, rollupWatermarkKey).Result()
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("failed to get rollup state: %w", err)
+	}
+
+	var times [2]time.Time
+	for i, value := range values {
+		str, ok := value.(string)
+		if !ok {
+			continue // ключа нет
+		}
+		ms, err := strconv.ParseInt(str, 10, 64)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid rollup state: %w", err)
+		}
+		times[i] = time.UnixMilli(ms).UTC()
+	}
+
+	return times[0], times[1], nil
+}
+
+func (s *StatsRollupStore) Init(ctx context.Context, start time.Time) error {
+	value := start.UnixMilli()
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SetNX(ctx, rollupSinceKey, value, 0)
+		pipe.SetNX(ctx, rollupWatermarkKey, value, 0)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to init rollup: %w", err)
+	}
+	return nil
+}
+
+// Add writes buckets and moves the watermark in one MULTI, so a failed run is retried without double counting.
+// WATCH on the watermark makes a concurrent instance that processed the same range lose with ErrRollupConflict.
+func (s *StatsRollupStore) Add(ctx context.Context, buckets []*domain.StatsBucket, after, until time.Time) error {
+	err := s.client.Watch(ctx, func(tx *redis.Tx) error {
+		current, err := tx.Get(ctx, rollupWatermarkKey).Int64()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return err
+		}
+		if current != after.UnixMilli() {
+			return domain.ErrRollupConflict
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, bucket := range buckets {
+				addRollupBucket(ctx, pipe, bucket)
+			}
+			pipe.Set(ctx, rollupWatermarkKey, until.UnixMilli(), 0)
+			return nil
+		})
+		return err
+	}, rollupWatermarkKey)
+
+	if errors.Is(err, redis.TxFailedErr) {
+		return domain.ErrRollupConflict
+	}
+	if err != nil {
+		return fmt.Errorf("failed to add rollup buckets: %w", err)
+	}
+	return nil
+}
+
+// addRollupBucket adds a minute bucket to its minute and hour aggregates
+func addRollupBucket(ctx context.Context, pipe redis.Pipeliner, bucket *domain.StatsBucket) {
+	users := make([]interface{}, len(bucket.Users))
+	for i, user := range bucket.Users {
+		users[i] = user
+	}
+
+	for _, level := range []struct {
+		granularity string
+		start       time.Time
+		ttl         time.Duration
+	}{
+		{"m", bucket.Start, domain.RollupMinuteRetention},
+		{"h", bucket.Start.Truncate(time.Hour), domain.RollupHourRetention},
+	} {
+		usersKey := rollupUsersKey(level.granularity, bucket.IncidentID, level.start)
+		checksKey := rollupChecksKey(level.granularity, bucket.IncidentID, level.start)
+		pipe.PFAdd(ctx, usersKey, users...)
+		pipe.IncrBy(ctx, checksKey, int64(bucket.Checks))
+		// срок считается от начала агрегата, а не от последней записи
+		pipe.ExpireAt(ctx, usersKey, level.start.Add(level.ttl))
+		pipe.ExpireAt(ctx, checksKey, level.start.Add(level.ttl))
+	}
+}
+
+func (s *StatsRollupStore) Count(ctx context.Context, incidentIDs []uuid.UUID, minutes, hours []time.Time) (map[uuid.UUID]*domain.StatsCount, error) {
+	users := make(map[uuid.UUID]*redis.IntCmd, len(incidentIDs))
+	checks := make(map[uuid.UUID]*redis.SliceCmd, len(incidentIDs))
+
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, id := range incidentIDs {
+			usersKeys := make([]string, 0, len(minutes)+len(hours))
+			checksKeys := make([]string, 0, len(minutes)+len(hours))
+			for _, m := range minutes {
+				usersKeys = append(usersKeys, rollupUsersKey("m", id, m))
+				checksKeys = append(checksKeys, rollupChecksKey("m", id, m))
+			}
+			for _, h := range hours {
+				usersKeys = append(usersKeys, rollupUsersKey("h", id, h))
+				checksKeys = append(checksKeys, rollupChecksKey("h", id, h))
+			}
+			if len(usersKeys) == 0 {
+				continue
+			}
+			users[id] = pipe.PFCount(ctx, usersKeys...)
+			checks[id] = pipe.MGet(ctx, checksKeys...)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("failed to count rollup: %w", err)
+	}
+
+	counts := make(map[uuid.UUID]*domain.StatsCount, len(incidentIDs))
+	for _, id := range incidentIDs {
+		count := &domain.StatsCount{}
+		counts[id] = count
+		if cmd, ok := users[id]; ok {
+			count.Users = int(cmd.Val())
+		}
+		if cmd, ok := checks[id]; ok {
+			for _, value := range cmd.Val() {
+				if str, ok := value.(string); ok {
+					n, _ := strconv.Atoi(str)
+					count.Checks += n
+				}
+			}
+		}
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,52 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"geo-alert-core/internal/tracing"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// tracingHook opens a span per redis command or pipeline
+type tracingHook struct{}
+
+func (tracingHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (tracingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ctx, span := tracing.Start(ctx, "redis."+cmd.Name(), semconv.DBSystemRedis)
+		defer span.End()
+
+		err := next(ctx, cmd)
+		// промах кэша - не ошибка
+		if !errors.Is(err, redis.Nil) {
+			tracing.RecordError(span, err)
+		}
+		return err
+	}
+}
+
+func (tracingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		ctx, span := tracing.Start(ctx, "redis.pipeline",
+			semconv.DBSystemRedis,
+			attribute.Int("db.redis.commands", len(cmds)),
+		)
+		defer span.End()
+
+		err := next(ctx, cmds)
+		if !errors.Is(err, redis.Nil) {
+			tracing.RecordError(span, err)
+		}
+		return err
+	}
+}
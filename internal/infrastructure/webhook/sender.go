@@ -5,9 +5,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"geo-alert-core/internal/domain"
+	"geo-alert-core/internal/logging"
+	"geo-alert-core/internal/metrics"
+	"geo-alert-core/internal/tracing"
 	"io"
 	"net/http"
+	"sync/atomic"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // payload for webhook
@@ -28,12 +35,23 @@ type IncidentInfo struct {
 	Radius      float64 `json:"radius"`
 }
 
+// delivery log keeps every send with its attempts, see GET /api/v1/webhooks/deliveries
+type DeliveryLog interface {
+	Create(ctx context.Context, delivery *domain.WebhookDelivery) error
+	Update(ctx context.Context, delivery *domain.WebhookDelivery) error
+}
+
+// how long a write to the delivery log may take, it must not hold up retries
+const deliveryLogTimeout = 5 * time.Second
+
 // sender sends webhooks with retry mechanism
 type Sender struct {
 	client        *http.Client
 	webhookURL    string
 	retryAttempts int
 	retryDelay    time.Duration
+	pending       atomic.Int64 // webhooks being sent or waiting for a retry
+	deliveryLog   DeliveryLog  // nil - deliveries are not recorded
 }
 
 // new sender for webhooks
@@ -48,8 +66,17 @@ func NewSender(webhookURL string, retryAttempts int, retryDelay time.Duration) *
 	}
 }
 
+// SetDeliveryLog records deliveries in log; a failed write is logged and does not stop the send
+func (s *Sender) SetDeliveryLog(log DeliveryLog) {
+	s.deliveryLog = log
+}
+
 // send webhook with exponential backoff
 func (s *Sender) Send(ctx context.Context, payload *WebhookPayload) error {
+	s.pending.Add(1)
+	defer s.pending.Add(-1)
+
+	delivery := s.startDelivery(ctx, payload)
 	var lastErr error
 
 	for attempt := 0; attempt < s.retryAttempts; attempt++ {
@@ -57,23 +84,116 @@ func (s *Sender) Send(ctx context.Context, payload *WebhookPayload) error {
 			delay := s.retryDelay * time.Duration(1<<uint(attempt-1))
 			select {
 			case <-ctx.Done():
+				s.finishDelivery(ctx, delivery, domain.DeliveryFailed, ctx.Err())
 				return ctx.Err()
 			case <-time.After(delay):
 			}
 		}
 
-		err := s.sendRequest(ctx, payload)
+		start := time.Now()
+		attemptCtx, span := tracing.Start(ctx, "webhook.send", attribute.Int("webhook.attempt", attempt+1))
+		err := s.sendRequest(attemptCtx, payload)
+		tracing.RecordError(span, err)
+		span.End()
+		metrics.WebhookAttempts.Inc()
+		metrics.WebhookAttemptDuration.Observe(time.Since(start).Seconds())
+		if delivery != nil {
+			delivery.Attempts = attempt + 1
+		}
 		if err == nil {
+			metrics.WebhookDeliveries.WithLabelValues("success").Inc()
+			s.finishDelivery(ctx, delivery, domain.DeliveryDelivered, nil)
 			return nil // successfully sent
 		}
 
 		lastErr = err
-		fmt.Printf("Webhook send attempt %d failed: %v\n", attempt+1, err)
+		logging.FromContext(ctx).Warn("webhook send attempt failed", "attempt", attempt+1, "error", err)
+		if attempt+1 < s.retryAttempts {
+			s.recordAttempt(ctx, delivery, err)
+		}
 	}
 
+	metrics.WebhookDeliveries.WithLabelValues("failure").Inc()
+	s.finishDelivery(ctx, delivery, domain.DeliveryFailed, lastErr)
 	return fmt.Errorf("webhook send failed after %d attempts: %w", s.retryAttempts, lastErr)
 }
 
+// startDelivery records a pending delivery, nil when there is no log or the write failed
+func (s *Sender) startDelivery(ctx context.Context, payload *WebhookPayload) *domain.WebhookDelivery {
+	if s.deliveryLog == nil {
+		return nil
+	}
+
+	incidentIDs := make([]string, len(payload.Incidents))
+	for i, incident := range payload.Incidents {
+		incidentIDs[i] = incident.ID
+	}
+	delivery := &domain.WebhookDelivery{
+		UserID:      payload.UserID,
+		IncidentIDs: incidentIDs,
+		URL:         s.webhookURL,
+		Status:      domain.DeliveryPending,
+	}
+
+	logCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), deliveryLogTimeout)
+	defer cancel()
+	if err := s.deliveryLog.Create(logCtx, delivery); err != nil {
+		logging.FromContext(ctx).Error("failed to record webhook delivery", "error", err)
+		return nil
+	}
+	return delivery
+}
+
+// recordAttempt saves a failed attempt of a delivery that will be retried
+func (s *Sender) recordAttempt(ctx context.Context, delivery *domain.WebhookDelivery, err error) {
+	if delivery == nil {
+		return
+	}
+	delivery.LastError = err.Error()
+	s.updateDelivery(ctx, delivery)
+}
+
+// finishDelivery saves the final status, err is the error of the last attempt
+func (s *Sender) finishDelivery(ctx context.Context, delivery *domain.WebhookDelivery, status string, err error) {
+	if delivery == nil {
+		return
+	}
+	now := time.Now()
+	delivery.Status = status
+	delivery.FinishedAt = &now
+	if err != nil {
+		delivery.LastError = err.Error()
+	}
+	s.updateDelivery(ctx, delivery)
+}
+
+// updateDelivery writes even when ctx is cancelled, otherwise an interrupted send stays pending
+func (s *Sender) updateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) {
+	logCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), deliveryLogTimeout)
+	defer cancel()
+	if err := s.deliveryLog.Update(logCtx, delivery); err != nil {
+		logging.FromContext(ctx).Error("failed to update webhook delivery", "delivery_id", delivery.ID, "error", err)
+	}
+}
+
+// Pending returns the number of webhooks that are not delivered or given up yet
+func (s *Sender) Pending() int64 {
+	return s.pending.Load()
+}
+
+// BacklogCheck fails when more than limit webhooks are pending,
+// which usually means the receiver is slow or down and retries pile up
+func (s *Sender) BacklogCheck(limit int64) func(ctx context.Context) (map[string]any, error) {
+	return func(ctx context.Context) (map[string]any, error) {
+		pending := s.Pending()
+		details := map[string]any{"pending": pending, "limit": limit}
+		if pending > limit {
+			return details, fmt.Errorf("webhook backlog %d exceeds limit %d", pending, limit)
+		}
+		return details, nil
+	}
+}
+
 // otpravlyaem zayavku http zapros
 func (s *Sender) sendRequest(ctx context.Context, payload *WebhookPayload) error {
 	jsonData, err := json.Marshal(payload)
@@ -87,6 +207,11 @@ func (s *Sender) sendRequest(ctx context.Context, payload *WebhookPayload) error
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	// получатель может продолжить трассировку проверки координат и найти ее в наших логах
+	tracing.Inject(ctx, req.Header)
+	if requestID := logging.RequestID(ctx); requestID != "" {
+		req.Header.Set("X-Request-ID", requestID)
+	}
 
 	resp, err := s.client.Do(req)
 	if err != nil {
@@ -0,0 +1,53 @@
+// Package logging - структурированные логи в JSON (log/slog).
+// Логгер с полями запроса (request_id, user_id, ...) передается через context,
+// поэтому сервисы и инфраструктура пишут строки с теми же полями, что и handler.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+type loggerKey struct{}
+
+type requestIDKey struct{}
+
+// Setup делает JSON-логгер с заданным уровнем логгером по умолчанию
+func Setup(w io.Writer, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(strings.ToUpper(level))); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+
+	logger := slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: lvl}))
+	slog.SetDefault(logger)
+	return logger, nil
+}
+
+// FromContext возвращает логгер запроса или логгер по умолчанию
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// With добавляет поля к логгеру в контексте: logging.With(ctx, "user_id", id)
+func With(ctx context.Context, args ...any) context.Context {
+	return context.WithValue(ctx, loggerKey{}, FromContext(ctx).With(args...))
+}
+
+// WithRequestID сохраняет id запроса и добавляет его в логгер
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	ctx = context.WithValue(ctx, requestIDKey{}, requestID)
+	return With(ctx, "request_id", requestID)
+}
+
+// RequestID возвращает id запроса или пустую строку
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
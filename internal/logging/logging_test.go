@@ -0,0 +1,45 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetup_InvalidLevel(t *testing.T) {
+	_, err := Setup(&bytes.Buffer{}, "loud")
+	assert.Error(t, err)
+}
+
+func TestContextLogger(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	_, err := Setup(&buf, "debug")
+	require.NoError(t, err)
+	defer slog.SetDefault(previous)
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	ctx = With(ctx, "user_id", "user-1")
+	// поля сохраняются, даже если запрос уже завершен
+	ctx = context.WithoutCancel(ctx)
+	FromContext(ctx).Debug("checked", "has_danger", true)
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "DEBUG", line["level"])
+	assert.Equal(t, "checked", line["msg"])
+	assert.Equal(t, "req-1", line["request_id"])
+	assert.Equal(t, "user-1", line["user_id"])
+	assert.Equal(t, true, line["has_danger"])
+	assert.Equal(t, "req-1", RequestID(ctx))
+}
+
+func TestFromContext_Default(t *testing.T) {
+	assert.Same(t, slog.Default(), FromContext(context.Background()))
+	assert.Empty(t, RequestID(context.Background()))
+}
@@ -0,0 +1,110 @@
+// Package metrics - метрики Prometheus для HTTP, PostGIS, кэша и вебхуков.
+// Все метрики регистрируются в собственном реестре и отдаются через Handler.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "geoalert"
+
+// Registry - реестр метрик сервиса (плюс стандартные метрики Go и процесса)
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+var (
+	HTTPRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route template.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	LocationChecks = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "location_checks_total",
+		Help:      "Location checks by result: hit (inside at least one zone) or miss.",
+	}, []string{"result"})
+
+	DBQueryDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Duration of repository queries.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"repository", "operation"})
+
+	CacheRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Tile cache lookups by cache (tiles in Redis, tiles_local in process) and result: hit, miss or error.",
+	}, []string{"cache", "result"})
+
+	WebhookAttempts = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_attempts_total",
+		Help:      "Webhook HTTP attempts including retries.",
+	})
+
+	WebhookDeliveries = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_deliveries_total",
+		Help:      "Webhooks by final result after retries: success or failure.",
+	}, []string{"result"})
+
+	WebhookAttemptDuration = factory.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "webhook_attempt_duration_seconds",
+		Help:      "Latency of a single webhook HTTP attempt.",
+		Buckets:   prometheus.DefBuckets,
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler отдает метрики в текстовом формате Prometheus
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// Middleware измеряет время ответа. Маршрут берется шаблоном (/incidents/:id),
+// чтобы id не раздували число временных рядов.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		HTTPRequestDuration.
+			WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+// ObserveQuery записывает длительность запроса к БД, удобно вызывать через defer:
+//
+//	defer metrics.ObserveQuery("incidents", "find_nearby", time.Now())
+func ObserveQuery(repository, operation string, start time.Time) {
+	DBQueryDuration.WithLabelValues(repository, operation).Observe(time.Since(start).Seconds())
+}
+
+// CacheResult считает обращение к кэшу: hit, miss или error
+func CacheResult(cache, result string) {
+	CacheRequests.WithLabelValues(cache, result).Inc()
+}
@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scrape(t *testing.T) string {
+	t.Helper()
+	server := httptest.NewServer(Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestMiddleware_UsesRouteTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+	router.GET("/incidents/:id", func(c *gin.Context) {
+		c.Status(http.StatusNotFound)
+	})
+
+	for _, path := range []string{"/incidents/a", "/incidents/b", "/nowhere"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	body := scrape(t)
+	// id не попадает в метки: оба запроса в одном ряду
+	assert.Contains(t, body, `geoalert_http_request_duration_seconds_count{method="GET",route="/incidents/:id",status="404"} 2`)
+	assert.Contains(t, body, `route="unmatched"`)
+	assert.Contains(t, body, "go_goroutines")
+}
+
+func TestObserveQueryAndCache(t *testing.T) {
+	ObserveQuery("incidents", "find_nearby_incidents", time.Now().Add(-20*time.Millisecond))
+	CacheResult("tiles", "hit")
+	CacheResult("tiles", "hit")
+	CacheResult("tiles", "miss")
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(CacheRequests.WithLabelValues("tiles", "hit")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(CacheRequests.WithLabelValues("tiles", "miss")))
+	assert.Contains(t, scrape(t), `geoalert_db_query_duration_seconds_count{operation="find_nearby_incidents",repository="incidents"} 1`)
+}
@@ -1,14 +1,33 @@
 package middleware
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"net/http"
 	"strings"
 
+	"geo-alert-core/internal/logging"
+
 	"github.com/gin-gonic/gin"
 )
 
-// middleware for checking API key
-func APIKeyAuth(validAPIKey string) gin.HandlerFunc {
+// APIKeyValidator checks keys issued through the API (service.APIKeyService)
+type APIKeyValidator interface {
+	ValidateAPIKey(ctx context.Context, key string) (bool, error)
+}
+
+// principalContextKey - gin context key with the authenticated client
+const principalContextKey = "principal"
+
+// Principal returns the client authenticated by APIKeyAuth or "" for public endpoints.
+// The key itself is not exposed: the principal is its sha256 hash.
+func Principal(c *gin.Context) string {
+	return c.GetString(principalContextKey)
+}
+
+// middleware for checking API key: the key from config or one of the issued keys (issued may be nil)
+func APIKeyAuth(validAPIKey string, issued APIKeyValidator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var apiKey string
 
@@ -26,8 +45,22 @@ func APIKeyAuth(validAPIKey string) gin.HandlerFunc {
 			apiKey = c.GetHeader("X-API-Key")
 		}
 
-		// Проверяем ключ
-		if apiKey == "" || apiKey != validAPIKey {
+		// Проверяем ключ: ключ из конфигурации работает и без хранилища
+		valid := apiKey != "" && apiKey == validAPIKey
+		if !valid && apiKey != "" && issued != nil {
+			ok, err := issued.ValidateAPIKey(c.Request.Context(), apiKey)
+			if err != nil {
+				logging.FromContext(c.Request.Context()).Error("api key check failed", "error", err)
+				c.JSON(http.StatusServiceUnavailable, gin.H{
+					"error": "Failed to check API key",
+				})
+				c.Abort()
+				return
+			}
+			valid = ok
+		}
+
+		if !valid {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error": "Invalid or missing API key",
 			})
@@ -35,7 +68,9 @@ func APIKeyAuth(validAPIKey string) gin.HandlerFunc {
 			return
 		}
 
-		// Ключ валиден, продолжаем
+		// Ключ валиден, запоминаем клиента и продолжаем
+		hash := sha256.Sum256([]byte(apiKey))
+		c.Set(principalContextKey, "key:"+hex.EncodeToString(hash[:]))
 		c.Next()
 	}
 }
@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -52,7 +54,7 @@ func TestAPIKeyAuth(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			router := gin.New()
-			router.Use(APIKeyAuth(tt.apiKey))
+			router.Use(APIKeyAuth(tt.apiKey, nil))
 			router.GET("/test", func(c *gin.Context) {
 				c.JSON(http.StatusOK, gin.H{"status": "ok"})
 			})
@@ -69,3 +71,47 @@ func TestAPIKeyAuth(t *testing.T) {
 		})
 	}
 }
+
+// issuedKeys - выданные ключи; err имитирует недоступное хранилище
+type issuedKeys struct {
+	keys map[string]bool
+	err  error
+}
+
+func (v *issuedKeys) ValidateAPIKey(ctx context.Context, key string) (bool, error) {
+	return v.keys[key], v.err
+}
+
+func TestAPIKeyAuth_IssuedKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		validator      *issuedKeys
+		key            string
+		expectedStatus int
+	}{
+		{"issued key", &issuedKeys{keys: map[string]bool{"issued": true}}, "issued", http.StatusOK},
+		{"unknown key", &issuedKeys{keys: map[string]bool{"issued": true}}, "other", http.StatusUnauthorized},
+		// ключ из конфигурации не зависит от хранилища
+		{"static key with storage down", &issuedKeys{err: errors.New("connection refused")}, "secret-key", http.StatusOK},
+		{"issued key with storage down", &issuedKeys{err: errors.New("connection refused")}, "issued", http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(APIKeyAuth("secret-key", tt.validator))
+			router.GET("/test", func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"status": "ok"})
+			})
+
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.Header.Set("X-API-Key", tt.key)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
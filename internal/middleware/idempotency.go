@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"geo-alert-core/internal/domain"
+	"geo-alert-core/internal/logging"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotencyLeaseTTL - сколько живет отметка "запрос выполняется". Если процесс упал,
+// не успев сохранить ответ, повтор с тем же ключом снова выполнится через это время, а не через весь TTL.
+const IdempotencyLeaseTTL = time.Minute
+
+// idempotencyStoreTimeout - сохранение ответа и освобождение ключа после того, как клиент отключился
+const idempotencyStoreTimeout = 5 * time.Second
+
+// хранилище ключей идемпотентности (реализация на Redis в infrastructure/redis)
+type IdempotencyStore interface {
+	// Reserve атомарно занимает ключ записью rec.
+	// Если ключ уже занят, возвращает существующую запись и ничего не меняет.
+	Reserve(ctx context.Context, key string, rec *domain.IdempotencyRecord, ttl time.Duration) (*domain.IdempotencyRecord, error)
+	Save(ctx context.Context, key string, rec *domain.IdempotencyRecord, ttl time.Duration) error
+	Release(ctx context.Context, key string) error
+}
+
+// middleware for Idempotency-Key header
+// povtornyy zapros s tem zhe klyuchom i telom poluchaet sohranenny otvet,
+// s drugim telom - 422
+// klyuchi razdeleny po klientam (Principal), chuzhoy otvet po sovpavshemu klyuchu ne vydaetsya
+func Idempotency(store IdempotencyStore, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || store == nil {
+			c.Next()
+			return
+		}
+
+		if len(key) > 255 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Idempotency-Key must be at most 255 characters",
+			})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Failed to read request body",
+			})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.Sum256(body)
+		bodyHash := hex.EncodeToString(hash[:])
+		// публичные запросы без ключа API делят одну область, для них ответ
+		// все равно выдается только на то же тело запроса
+		principal := Principal(c)
+		if principal == "" {
+			principal = "anonymous"
+		}
+		storeKey := "idempotency:" + principal + ":" + c.Request.Method + ":" + c.FullPath() + ":" + key
+		ctx := c.Request.Context()
+
+		existing, err := store.Reserve(ctx, storeKey, &domain.IdempotencyRecord{BodyHash: bodyHash}, IdempotencyLeaseTTL)
+		if err != nil {
+			// хранилище недоступно - обрабатываем запрос как обычно
+			logging.FromContext(ctx).Warn("idempotency store unavailable", "error", err)
+			c.Next()
+			return
+		}
+
+		if existing != nil {
+			switch {
+			case existing.BodyHash != bodyHash:
+				c.JSON(http.StatusUnprocessableEntity, gin.H{
+					"error": "Idempotency-Key was already used with a different request body",
+				})
+			case !existing.Completed:
+				c.JSON(http.StatusConflict, gin.H{
+					"error": "Request with this Idempotency-Key is still in progress",
+				})
+			default:
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(existing.StatusCode, existing.ContentType, existing.Body)
+			}
+			c.Abort()
+			return
+		}
+
+		// клиент мог уже отключиться, а запись все равно нужно сохранить или снять
+		storeCtx := func() (context.Context, context.CancelFunc) {
+			return context.WithTimeout(context.WithoutCancel(ctx), idempotencyStoreTimeout)
+		}
+		release := func() {
+			releaseCtx, cancel := storeCtx()
+			defer cancel()
+			if err := store.Release(releaseCtx, storeKey); err != nil {
+				logging.FromContext(ctx).Error("failed to release idempotency key", "error", err)
+			}
+		}
+
+		// при панике обработчика ключ освобождается, паника идет дальше в gin.Recovery
+		completed := false
+		defer func() {
+			if !completed {
+				release()
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		c.Next()
+
+		// ошибки сервера не запоминаем, чтобы клиент мог повторить запрос
+		if recorder.Status() >= http.StatusInternalServerError {
+			return
+		}
+
+		rec := &domain.IdempotencyRecord{
+			BodyHash:    bodyHash,
+			Completed:   true,
+			StatusCode:  recorder.Status(),
+			ContentType: recorder.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+		}
+		saveCtx, cancel := storeCtx()
+		defer cancel()
+		if err := store.Save(saveCtx, storeKey, rec, ttl); err != nil {
+			logging.FromContext(ctx).Error("failed to save idempotency record", "error", err)
+			return
+		}
+		completed = true
+	}
+}
+
+// копирует тело ответа, чтобы сохранить его для повторов
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,248 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"geo-alert-core/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryIdempotencyStore - хранилище в памяти для тестов
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]*domain.IdempotencyRecord
+	ttls    map[string]time.Duration
+	ctxErrs []error // ошибки контекста при Save/Release
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{
+		records: make(map[string]*domain.IdempotencyRecord),
+		ttls:    make(map[string]time.Duration),
+	}
+}
+
+func (s *memoryIdempotencyStore) Reserve(ctx context.Context, key string, rec *domain.IdempotencyRecord, ttl time.Duration) (*domain.IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.records[key]; ok {
+		return existing, nil
+	}
+	s.records[key] = rec
+	s.ttls[key] = ttl
+	return nil, nil
+}
+
+func (s *memoryIdempotencyStore) Save(ctx context.Context, key string, rec *domain.IdempotencyRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ctxErrs = append(s.ctxErrs, ctx.Err())
+	s.records[key] = rec
+	s.ttls[key] = ttl
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ctxErrs = append(s.ctxErrs, ctx.Err())
+	delete(s.records, key)
+	return nil
+}
+
+func TestIdempotency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := newMemoryIdempotencyStore()
+	calls := 0
+	status := http.StatusCreated
+
+	router := gin.New()
+	router.POST("/test", Idempotency(store, time.Hour), func(c *gin.Context) {
+		calls++
+		c.JSON(status, gin.H{"call": calls})
+	})
+
+	send := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/test", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	first := send("key-1", `{"a":1}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, 1, calls)
+
+	// повтор с тем же телом - сохраненный ответ, обработчик не вызывается
+	replay := send("key-1", `{"a":1}`)
+	assert.Equal(t, http.StatusCreated, replay.Code)
+	assert.Equal(t, first.Body.String(), replay.Body.String())
+	assert.Equal(t, "true", replay.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, 1, calls)
+
+	// тот же ключ с другим телом
+	conflict := send("key-1", `{"a":2}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, conflict.Code)
+	assert.Equal(t, 1, calls)
+
+	// без ключа middleware ничего не делает
+	send("", `{"a":1}`)
+	send("", `{"a":1}`)
+	assert.Equal(t, 3, calls)
+
+	// ответ 5xx не сохраняется, повтор доходит до обработчика
+	status = http.StatusInternalServerError
+	send("key-2", `{}`)
+	status = http.StatusCreated
+	retry := send("key-2", `{}`)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, 5, calls)
+}
+
+func TestIdempotency_InProgress(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := newMemoryIdempotencyStore()
+	router := gin.New()
+	router.POST("/test", Idempotency(store, time.Hour), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	// первый запрос еще выполняется: ключ занят незавершенной записью
+	req := httptest.NewRequest("POST", "/test", strings.NewReader(`{}`))
+	req.Header.Set(IdempotencyKeyHeader, "busy")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	for k, rec := range store.records {
+		store.records[k] = &domain.IdempotencyRecord{BodyHash: rec.BodyHash}
+	}
+
+	req = httptest.NewRequest("POST", "/test", strings.NewReader(`{}`))
+	req.Header.Set(IdempotencyKeyHeader, "busy")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestIdempotency_LeaseAndResponseTTL(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := newMemoryIdempotencyStore()
+	var leaseTTL time.Duration
+	router := gin.New()
+	router.POST("/test", Idempotency(store, time.Hour), func(c *gin.Context) {
+		// пока обработчик выполняется, ключ занят на короткую аренду
+		for key := range store.records {
+			leaseTTL = store.ttls[key]
+		}
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest("POST", "/test", strings.NewReader(`{}`))
+	req.Header.Set(IdempotencyKeyHeader, "lease")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, IdempotencyLeaseTTL, leaseTTL)
+	for _, ttl := range store.ttls {
+		assert.Equal(t, time.Hour, ttl)
+	}
+}
+
+func TestIdempotency_ReleaseOnPanic(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := newMemoryIdempotencyStore()
+	router := gin.New()
+	router.Use(gin.Recovery())
+	router.POST("/test", Idempotency(store, time.Hour), func(c *gin.Context) {
+		panic("boom")
+	})
+
+	req := httptest.NewRequest("POST", "/test", strings.NewReader(`{}`))
+	req.Header.Set(IdempotencyKeyHeader, "panic")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Empty(t, store.records)
+}
+
+func TestIdempotency_SaveAfterClientGone(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := newMemoryIdempotencyStore()
+	ctx, cancel := context.WithCancel(context.Background())
+	router := gin.New()
+	router.POST("/test", Idempotency(store, time.Hour), func(c *gin.Context) {
+		cancel() // клиент отключился, пока обработчик работал
+		c.Status(http.StatusCreated)
+	})
+
+	req := httptest.NewRequest("POST", "/test", strings.NewReader(`{}`)).WithContext(ctx)
+	req.Header.Set(IdempotencyKeyHeader, "gone")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, []error{nil}, store.ctxErrs)
+	for _, rec := range store.records {
+		assert.True(t, rec.Completed)
+	}
+}
+
+func TestIdempotency_ScopedByPrincipal(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := newMemoryIdempotencyStore()
+	calls := 0
+
+	router := gin.New()
+	router.Use(APIKeyAuth("client-a", &issuedKeys{keys: map[string]bool{"client-b": true}}))
+	router.POST("/test", Idempotency(store, time.Hour), func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusCreated, gin.H{"call": calls})
+	})
+
+	send := func(apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/test", strings.NewReader(`{"a":1}`))
+		req.Header.Set("X-API-Key", apiKey)
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	first := send("client-a")
+	assert.Equal(t, http.StatusCreated, first.Code)
+
+	// другой клиент с тем же ключом и телом не получает чужой ответ
+	other := send("client-b")
+	assert.Equal(t, http.StatusCreated, other.Code)
+	assert.Empty(t, other.Header().Get("Idempotent-Replayed"))
+	assert.NotEqual(t, first.Body.String(), other.Body.String())
+	assert.Equal(t, 2, calls)
+
+	// повтор того же клиента - сохраненный ответ
+	replay := send("client-a")
+	assert.Equal(t, "true", replay.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, first.Body.String(), replay.Body.String())
+	assert.Equal(t, 2, calls)
+
+	// в хранилище не попадает сам ключ API
+	for key := range store.records {
+		assert.NotContains(t, key, "client-a")
+		assert.NotContains(t, key, "client-b")
+	}
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"geo-alert-core/internal/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		header   string
+		expected string
+	}{
+		{name: "propagates client id", header: "req-123", expected: "req-123"},
+		{name: "generates when missing", header: ""},
+		{name: "replaces id with control characters", header: "abc\nlevel=ERROR"},
+		{name: "replaces too long id", header: strings.Repeat("a", maxRequestIDLength+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fromContext string
+			router := gin.New()
+			router.Use(RequestID())
+			router.GET("/test", func(c *gin.Context) {
+				fromContext = logging.RequestID(c.Request.Context())
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if tt.header != "" {
+				req.Header.Set(RequestIDHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			got := w.Header().Get(RequestIDHeader)
+			assert.Equal(t, got, fromContext)
+			if tt.expected != "" {
+				assert.Equal(t, tt.expected, got)
+			} else {
+				_, err := uuid.Parse(got)
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRequestLogger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var buf bytes.Buffer
+	previous := slog.Default()
+	_, err := logging.Setup(&buf, "info")
+	require.NoError(t, err)
+	defer slog.SetDefault(previous)
+
+	router := gin.New()
+	router.Use(RequestID(), RequestLogger())
+	router.GET("/incidents/:id", func(c *gin.Context) {
+		logging.FromContext(c.Request.Context()).Info("handler")
+		c.Status(http.StatusNotFound)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/incidents/42", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	// строка из handler несет тот же request_id
+	var handlerLine map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &handlerLine))
+	assert.Equal(t, "req-1", handlerLine["request_id"])
+
+	var requestLine map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &requestLine))
+	assert.Equal(t, "WARN", requestLine["level"])
+	assert.Equal(t, "req-1", requestLine["request_id"])
+	assert.Equal(t, "/incidents/:id", requestLine["route"])
+	assert.Equal(t, float64(http.StatusNotFound), requestLine["status"])
+}
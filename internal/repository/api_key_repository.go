@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"geo-alert-core/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// APIKeyRepository хранит выданные ключи API: вместо ключа - его хэш
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *domain.APIKey, hash string) error
+	// FindByHash возвращает действующий (не отозванный) ключ, иначе ErrAPIKeyNotFound
+	FindByHash(ctx context.Context, hash string) (*domain.APIKey, error)
+	// List возвращает все ключи, в том числе отозванные, новые первыми
+	List(ctx context.Context) ([]*domain.APIKey, error)
+	// Revoke отзывает ключ. Повторный отзыв - не ошибка, неизвестный id - ErrAPIKeyNotFound
+	Revoke(ctx context.Context, id uuid.UUID) error
+}
+
+type postgresAPIKeyRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresAPIKeyRepository(db *sql.DB) APIKeyRepository {
+	return &postgresAPIKeyRepository{db: db}
+}
+
+func (r *postgresAPIKeyRepository) Create(ctx context.Context, key *domain.APIKey, hash string) error {
+	ctx, end := observeQuery(ctx, "api_keys", "create")
+	defer end()
+
+	key.ID = uuid.New()
+	key.CreatedAt = utcNow()
+	key.RevokedAt = nil
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO api_keys (id, name, prefix, key_hash, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, key.ID, key.Name, key.Prefix, hash, key.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	return nil
+}
+
+func (r *postgresAPIKeyRepository) FindByHash(ctx context.Context, hash string) (*domain.APIKey, error) {
+	ctx, end := observeQuery(ctx, "api_keys", "find_by_hash")
+	defer end()
+
+	row := r.db.QueryRowContext(ctx, `
+		SELECT id, name, prefix, created_at, revoked_at
+		FROM api_keys
+		WHERE key_hash = $1 AND revoked_at IS NULL
+	`, hash)
+
+	key, err := scanAPIKey(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w", domain.ErrAPIKeyNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	return key, nil
+}
+
+func (r *postgresAPIKeyRepository) List(ctx context.Context) ([]*domain.APIKey, error) {
+	ctx, end := observeQuery(ctx, "api_keys", "list")
+	defer end()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, name, prefix, created_at, revoked_at
+		FROM api_keys
+		ORDER BY created_at DESC, id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []*domain.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (r *postgresAPIKeyRepository) Revoke(ctx context.Context, id uuid.UUID) error {
+	ctx, end := observeQuery(ctx, "api_keys", "revoke")
+	defer end()
+
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE api_keys SET revoked_at = $1
+		WHERE id = $2 AND revoked_at IS NULL
+	`, utcNow(), id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected > 0 {
+		return nil
+	}
+
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM api_keys WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check api key existence: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("%w", domain.ErrAPIKeyNotFound)
+	}
+
+	return nil
+}
+
+func scanAPIKey(row rowScanner) (*domain.APIKey, error) {
+	var key domain.APIKey
+	var revokedAt sql.NullTime
+	if err := row.Scan(&key.ID, &key.Name, &key.Prefix, &key.CreatedAt, &revokedAt); err != nil {
+		return nil, err
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return &key, nil
+}
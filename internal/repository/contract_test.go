@@ -0,0 +1,59 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"geo-alert-core/internal/infrastructure/postgres"
+	"geo-alert-core/internal/repository"
+	"geo-alert-core/internal/repository/repotest"
+	"geo-alert-core/migrations"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryRepositoryContract(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Repositories {
+		store := repository.NewMemoryStore()
+		return repotest.Repositories{
+			Incidents:         repository.NewMemoryIncidentRepository(store),
+			LocationChecks:    repository.NewMemoryLocationCheckRepository(store),
+			APIKeys:           repository.NewMemoryAPIKeyRepository(store),
+			WebhookDeliveries: repository.NewMemoryWebhookDeliveryRepository(store),
+		}
+	})
+}
+
+// TEST_POSTGRES_DSN - отдельная база с PostGIS; тест накатывает миграции и очищает таблицы
+func TestPostgresRepositoryContract(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+
+	db, err := postgres.NewDB(dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := postgres.NewMigrator(db, migrations.FS)
+	require.NoError(t, err)
+	require.NoError(t, migrator.Up(context.Background()))
+
+	repotest.Run(t, func(t *testing.T) repotest.Repositories {
+		truncate(t, db)
+		return repotest.Repositories{
+			Incidents:         repository.NewPostgresIncidentRepository(db),
+			LocationChecks:    repository.NewPostgresLocationCheckRepository(db),
+			APIKeys:           repository.NewPostgresAPIKeyRepository(db),
+			WebhookDeliveries: repository.NewPostgresWebhookDeliveryRepository(db),
+		}
+	})
+}
+
+func truncate(t *testing.T, db *sql.DB) {
+	t.Helper()
+	_, err := db.Exec(`TRUNCATE incidents, incident_versions, location_checks, location_check_incidents, external_superseded, api_keys, webhook_deliveries CASCADE`)
+	require.NoError(t, err)
+}
@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"geo-alert-core/internal/domain"
+	"strings"
+	"time"
+)
+
+// sortColumn - SQL-выражение для сортировки и тип, к которому приводится значение курсора
+type sortColumn struct {
+	expr    string
+	sqlType string
+}
+
+// severityRank упорядочивает уровни опасности по возрастанию, а не по алфавиту
+const severityRank = `CASE i.severity WHEN 'low' THEN 1 WHEN 'medium' THEN 2 WHEN 'high' THEN 3 WHEN 'critical' THEN 4 ELSE 0 END`
+
+// searchQuery объединяет разбор запроса по русской и английской конфигурациям
+func searchQuery(placeholder string) string {
+	return fmt.Sprintf("(websearch_to_tsquery('russian', %s) || websearch_to_tsquery('english', %s))", placeholder, placeholder)
+}
+
+// queryArgs накапливает аргументы и выдает для них плейсхолдеры $1, $2, ...
+type queryArgs struct {
+	args []any
+}
+
+func (q *queryArgs) add(value any) string {
+	// время сравнивается с колонками TIMESTAMP в UTC, см. timestamps.go
+	if t, ok := value.(time.Time); ok {
+		value = t.UTC()
+	}
+	q.args = append(q.args, value)
+	return fmt.Sprintf("$%d", len(q.args))
+}
+
+// incidentSortColumn возвращает выражение сортировки для поля фильтра
+func incidentSortColumn(filter *domain.IncidentFilter, args *queryArgs) (sortColumn, error) {
+	switch filter.SortBy {
+	case "", domain.SortByCreatedAt:
+		return sortColumn{expr: "i.created_at", sqlType: "timestamp"}, nil
+	case domain.SortByUpdatedAt:
+		return sortColumn{expr: "i.updated_at", sqlType: "timestamp"}, nil
+	case domain.SortByTitle:
+		return sortColumn{expr: "i.title", sqlType: "text"}, nil
+	case domain.SortByRadius:
+		return sortColumn{expr: "i.radius", sqlType: "numeric"}, nil
+	case domain.SortBySeverity:
+		return sortColumn{expr: severityRank, sqlType: "int"}, nil
+	case domain.SortByDistance:
+		if filter.Near == nil {
+			return sortColumn{}, fmt.Errorf("%w: sort by distance requires a point", domain.ErrInvalidFilter)
+		}
+		expr := fmt.Sprintf(
+			"ST_Distance(ST_MakePoint(i.longitude, i.latitude)::geography, ST_MakePoint(%s, %s)::geography)",
+			args.add(filter.Near.Longitude), args.add(filter.Near.Latitude),
+		)
+		return sortColumn{expr: expr, sqlType: "float8"}, nil
+	case domain.SortByRelevance:
+		if filter.Query == "" {
+			return sortColumn{}, fmt.Errorf("%w: sort by relevance requires a search query", domain.ErrInvalidFilter)
+		}
+		expr := fmt.Sprintf("ts_rank(i.search_vector, %s)", searchQuery(args.add(filter.Query)))
+		return sortColumn{expr: expr, sqlType: "real"}, nil
+	}
+
+	return sortColumn{}, fmt.Errorf("%w: unknown sort field %q", domain.ErrInvalidFilter, filter.SortBy)
+}
+
+// incidentConditions строит условия WHERE по фильтру (без курсора).
+// Удаленные и неудаленные зоны в одну выборку не попадают никогда.
+func incidentConditions(filter *domain.IncidentFilter, args *queryArgs) []string {
+	conditions := []string{"i.deleted_at IS NULL"}
+	if filter.Deleted {
+		conditions[0] = "i.deleted_at IS NOT NULL"
+	}
+
+	if filter.Query != "" {
+		conditions = append(conditions, "i.search_vector @@ "+searchQuery(args.add(filter.Query)))
+	}
+	if filter.IsActive != nil {
+		conditions = append(conditions, "i.is_active = "+args.add(*filter.IsActive))
+	}
+	if filter.Category != "" {
+		conditions = append(conditions, "i.category = "+args.add(filter.Category))
+	}
+	if len(filter.Severities) > 0 {
+		placeholders := make([]string, len(filter.Severities))
+		for i, severity := range filter.Severities {
+			placeholders[i] = args.add(severity)
+		}
+		conditions = append(conditions, "i.severity IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if filter.CreatedFrom != nil {
+		conditions = append(conditions, "i.created_at >= "+args.add(*filter.CreatedFrom))
+	}
+	if filter.CreatedTo != nil {
+		conditions = append(conditions, "i.created_at < "+args.add(*filter.CreatedTo))
+	}
+	if filter.UpdatedFrom != nil {
+		conditions = append(conditions, "i.updated_at >= "+args.add(*filter.UpdatedFrom))
+	}
+	if filter.UpdatedTo != nil {
+		conditions = append(conditions, "i.updated_at < "+args.add(*filter.UpdatedTo))
+	}
+	if box := filter.BBox; box != nil {
+		// без SRID, чтобы совпадало с выражением индекса idx_incidents_location
+		conditions = append(conditions, fmt.Sprintf(
+			"ST_MakePoint(i.longitude, i.latitude) && ST_MakeEnvelope(%s, %s, %s, %s)",
+			args.add(box.MinLongitude), args.add(box.MinLatitude), args.add(box.MaxLongitude), args.add(box.MaxLatitude),
+		))
+	}
+	if near := filter.Near; near != nil {
+		conditions = append(conditions, fmt.Sprintf(
+			"ST_DWithin(ST_MakePoint(i.longitude, i.latitude)::geography, ST_MakePoint(%s, %s)::geography, i.radius + %s)",
+			args.add(near.Longitude), args.add(near.Latitude), args.add(near.Meters),
+		))
+	}
+
+	return conditions
+}
+
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(conditions, " AND ")
+}
+
+// List возвращает страницу инцидентов по фильтру.
+// Сортировка всегда дополняется id, поэтому курсор стабилен при одинаковых значениях.
+func (r *postgresIncidentRepository) List(ctx context.Context, filter *domain.IncidentFilter) (*domain.IncidentPage, error) {
+	ctx, end := observeQuery(ctx, "incidents", "list")
+	defer end()
+
+	args := &queryArgs{}
+
+	sort, err := incidentSortColumn(filter, args)
+	if err != nil {
+		return nil, err
+	}
+
+	conditions := incidentConditions(filter, args)
+
+	page := &domain.IncidentPage{}
+
+	if filter.IncludeTotal {
+		// считаем по тем же условиям, но без курсора; аргументы сортировки в запросе не нужны
+		countArgs := &queryArgs{}
+		countQuery := `SELECT COUNT(*) FROM incidents i ` + whereClause(incidentConditions(filter, countArgs))
+
+		var total int
+		if err := r.db.QueryRowContext(ctx, countQuery, countArgs.args...).Scan(&total); err != nil {
+			return nil, fmt.Errorf("failed to count incidents: %w", err)
+		}
+		page.Total = &total
+	}
+
+	direction, comparison := "ASC", ">"
+	if filter.SortDesc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if cursor := filter.Cursor; cursor != nil {
+		conditions = append(conditions, fmt.Sprintf(
+			"(%s, i.id) %s (%s::%s, %s)",
+			sort.expr, comparison, args.add(cursor.Value), sort.sqlType, args.add(cursor.ID),
+		))
+	}
+
+	// берем на одну запись больше, чтобы понять, есть ли следующая страница
+	query := fmt.Sprintf(`
+		SELECT %s, (%s)::text AS sort_value
+		FROM incidents i
+		%s
+		ORDER BY %s %s, i.id %s
+		LIMIT %s
+	`, incidentColumns, sort.expr, whereClause(conditions), sort.expr, direction, direction, args.add(filter.Limit+1))
+
+	if filter.Cursor == nil && filter.Offset > 0 {
+		query += " OFFSET " + args.add(filter.Offset)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list incidents: %w", err)
+	}
+	defer rows.Close()
+
+	var lastValue string
+	for rows.Next() {
+		var row incidentRow
+		var sortValue string
+		if err := rows.Scan(append(row.dest(), &sortValue)...); err != nil {
+			return nil, fmt.Errorf("failed to scan incident: %w", err)
+		}
+		incident, err := row.result()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(page.Items) == filter.Limit {
+			page.NextCursor = &domain.IncidentCursor{
+				SortBy:   filter.SortBy,
+				SortDesc: filter.SortDesc,
+				Value:    lastValue,
+				ID:       page.Items[len(page.Items)-1].ID,
+			}
+			break
+		}
+
+		page.Items = append(page.Items, incident)
+		lastValue = sortValue
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate incidents: %w", err)
+	}
+
+	return page, nil
+}
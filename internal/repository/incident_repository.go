@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"geo-alert-core/internal/domain"
 	"time"
@@ -16,10 +17,25 @@ type IncidentRepository interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Incident, error)
 	GetAll(ctx context.Context, limit, offset int) ([]*domain.Incident, error)
 	GetActiveIncidents(ctx context.Context) ([]*domain.Incident, error)
+	GetFeedEntries(ctx context.Context, endedSince time.Time) ([]*domain.FeedEntry, error)
 	Update(ctx context.Context, id uuid.UUID, incident *domain.Incident) error
 	Delete(ctx context.Context, id uuid.UUID) error
+	Restore(ctx context.Context, id uuid.UUID) error
+	Purge(ctx context.Context, deletedBefore time.Time) (int, error)
 	FindNearbyIncidents(ctx context.Context, latitude, longitude float64) ([]*domain.Incident, error)
-	GetStats(ctx context.Context, minutes int) ([]*domain.IncidentStats, error)
+	GetStats(ctx context.Context, from, to time.Time) ([]*domain.IncidentStats, error)
+	GetTimeSeries(ctx context.Context, filter *domain.TimeSeriesFilter) ([]*domain.TimeSeriesPoint, error)
+	List(ctx context.Context, filter *domain.IncidentFilter) (*domain.IncidentPage, error)
+	FindByExternalID(ctx context.Context, source, externalID string) ([]*domain.Incident, error)
+	MarkSuperseded(ctx context.Context, source, externalID, supersededBy string) error
+	SupersededBy(ctx context.Context, source, externalID string) (string, error)
+	GetTile(ctx context.Context, z, x, y int) ([]byte, error)
+
+	// История версий (point-in-time запросы). asOf может быть с любым смещением:
+	// границы версий хранятся в UTC, и asOf сравнивается с ними в UTC
+	GetAllAsOf(ctx context.Context, asOf time.Time, limit, offset int) ([]*domain.Incident, error)
+	FindNearbyIncidentsAsOf(ctx context.Context, latitude, longitude float64, asOf time.Time) ([]*domain.Incident, error)
+	GetVersions(ctx context.Context, id uuid.UUID) ([]*domain.IncidentVersion, error)
 }
 
 type postgresIncidentRepository struct {
@@ -31,24 +47,52 @@ func NewPostgresIncidentRepository(db *sql.DB) IncidentRepository {
 }
 
 func (r *postgresIncidentRepository) Create(ctx context.Context, incident *domain.Incident) error {
+	ctx, end := observeQuery(ctx, "incidents", "create")
+	defer end()
+
 	query := `
-		INSERT INTO incidents (id, title, description, latitude, longitude, radius, is_active, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO incidents (
+			id, title, description, latitude, longitude, radius, area, category, severity, is_active,
+			source, external_id, effective_at, expires_at, urgency, certainty, version, created_at, updated_at
+		)
+		VALUES (
+			$1, $2, $3, $4, $5, $6, ST_GeomFromGeoJSON($7::text)::geography, $8, $9, $10,
+			$11, $12, $13, $14, $15, $16, $17, $18, $19
+		)
 	`
 
-	now := time.Now()
-	incident.ID = uuid.New()
+	area, err := encodePolygon(incident.Polygon)
+	if err != nil {
+		return err
+	}
+
+	// id можно задать заранее (импорт), иначе генерируем
+	now := utcNow()
+	if incident.ID == uuid.Nil {
+		incident.ID = uuid.New()
+	}
+	incident.Version = 1
 	incident.CreatedAt = now
 	incident.UpdatedAt = now
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err = r.db.ExecContext(ctx, query,
 		incident.ID,
 		incident.Title,
 		incident.Description,
 		incident.Latitude,
 		incident.Longitude,
 		incident.Radius,
+		area,
+		incident.Category,
+		incident.Severity,
 		incident.IsActive,
+		incident.Source,
+		nullString(incident.ExternalID),
+		utcTime(incident.EffectiveAt),
+		utcTime(incident.ExpiresAt),
+		incident.Urgency,
+		incident.Certainty,
+		incident.Version,
 		incident.CreatedAt,
 		incident.UpdatedAt,
 	)
@@ -61,25 +105,16 @@ func (r *postgresIncidentRepository) Create(ctx context.Context, incident *domai
 }
 
 func (r *postgresIncidentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Incident, error) {
+	ctx, end := observeQuery(ctx, "incidents", "get_by_id")
+	defer end()
+
 	query := `
-		SELECT id, title, description, latitude, longitude, radius, is_active, created_at, updated_at
-		FROM incidents
-		WHERE id = $1
+		SELECT ` + incidentColumns + `
+		FROM incidents i
+		WHERE i.id = $1 AND i.deleted_at IS NULL
 	`
 
-	var incident domain.Incident
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&incident.ID,
-		&incident.Title,
-		&incident.Description,
-		&incident.Latitude,
-		&incident.Longitude,
-		&incident.Radius,
-		&incident.IsActive,
-		&incident.CreatedAt,
-		&incident.UpdatedAt,
-	)
-
+	incident, err := scanIncident(r.db.QueryRowContext(ctx, query, id))
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("%w", domain.ErrIncidentNotFound)
 	}
@@ -87,14 +122,18 @@ func (r *postgresIncidentRepository) GetByID(ctx context.Context, id uuid.UUID)
 		return nil, fmt.Errorf("failed to get incident: %w", err)
 	}
 
-	return &incident, nil
+	return incident, nil
 }
 
 func (r *postgresIncidentRepository) GetAll(ctx context.Context, limit, offset int) ([]*domain.Incident, error) {
+	ctx, end := observeQuery(ctx, "incidents", "get_all")
+	defer end()
+
 	query := `
-		SELECT id, title, description, latitude, longitude, radius, is_active, created_at, updated_at
-		FROM incidents
-		ORDER BY created_at DESC
+		SELECT ` + incidentColumns + `
+		FROM incidents i
+		WHERE i.deleted_at IS NULL
+		ORDER BY i.created_at DESC
 		LIMIT $1 OFFSET $2
 	`
 
@@ -104,35 +143,18 @@ func (r *postgresIncidentRepository) GetAll(ctx context.Context, limit, offset i
 	}
 	defer rows.Close()
 
-	var incidents []*domain.Incident
-	for rows.Next() {
-		var incident domain.Incident
-		err := rows.Scan(
-			&incident.ID,
-			&incident.Title,
-			&incident.Description,
-			&incident.Latitude,
-			&incident.Longitude,
-			&incident.Radius,
-			&incident.IsActive,
-			&incident.CreatedAt,
-			&incident.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan incident: %w", err)
-		}
-		incidents = append(incidents, &incident)
-	}
-
-	return incidents, nil
+	return scanIncidents(rows)
 }
 
 func (r *postgresIncidentRepository) GetActiveIncidents(ctx context.Context) ([]*domain.Incident, error) {
+	ctx, end := observeQuery(ctx, "incidents", "get_active_incidents")
+	defer end()
+
 	query := `
-		SELECT id, title, description, latitude, longitude, radius, is_active, created_at, updated_at
-		FROM incidents
-		WHERE is_active = true
-		ORDER BY created_at DESC
+		SELECT ` + incidentColumns + `
+		FROM incidents i
+		WHERE ` + activeNow + `
+		ORDER BY i.created_at DESC
 	`
 
 	rows, err := r.db.QueryContext(ctx, query)
@@ -141,53 +163,153 @@ func (r *postgresIncidentRepository) GetActiveIncidents(ctx context.Context) ([]
 	}
 	defer rows.Close()
 
-	var incidents []*domain.Incident
+	return scanIncidents(rows)
+}
+
+// GetFeedEntries возвращает действующие зоны и зоны, снятые (выключенные, удаленные или истекшие)
+// не раньше endedSince, вместе с их предыдущей версией
+func (r *postgresIncidentRepository) GetFeedEntries(ctx context.Context, endedSince time.Time) ([]*domain.FeedEntry, error) {
+	ctx, end := observeQuery(ctx, "incidents", "get_feed_entries")
+	defer end()
+
+	query := `
+		SELECT ` + incidentColumns + `,
+			prev.valid_from,
+			(prev.data->>'version')::int,
+			COALESCE((prev.data->>'is_active')::boolean, false) AND prev.data->>'deleted_at' IS NULL
+		FROM incidents i
+		LEFT JOIN LATERAL (
+			SELECT v.valid_from, v.data
+			FROM incident_versions v
+			WHERE v.incident_id = i.id
+			ORDER BY v.version DESC
+			OFFSET 1 LIMIT 1
+		) prev ON true
+		WHERE (` + activeNow + `)
+		OR ((i.is_active = false OR i.deleted_at IS NOT NULL) AND i.updated_at >= $1)
+		OR (i.is_active = true AND i.deleted_at IS NULL AND i.expires_at >= $2 AND i.expires_at <= NOW())
+		ORDER BY i.created_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, endedSince.UTC(), endedSince.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feed entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*domain.FeedEntry
 	for rows.Next() {
-		var incident domain.Incident
-		err := rows.Scan(
-			&incident.ID,
-			&incident.Title,
-			&incident.Description,
-			&incident.Latitude,
-			&incident.Longitude,
-			&incident.Radius,
-			&incident.IsActive,
-			&incident.CreatedAt,
-			&incident.UpdatedAt,
-		)
+		var row incidentRow
+		var prevUpdatedAt sql.NullTime
+		var prevVersion sql.NullInt64
+		var prevPublished sql.NullBool
+		dest := append(row.dest(), &prevUpdatedAt, &prevVersion, &prevPublished)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan feed entry: %w", err)
+		}
+		incident, err := row.result()
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan incident: %w", err)
+			return nil, err
+		}
+
+		entry := &domain.FeedEntry{Incident: incident}
+		if prevUpdatedAt.Valid {
+			entry.Previous = &domain.PreviousVersion{
+				Version:   int(prevVersion.Int64),
+				UpdatedAt: prevUpdatedAt.Time,
+				Published: prevPublished.Bool,
+			}
 		}
-		incidents = append(incidents, &incident)
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate feed entries: %w", err)
 	}
 
-	return incidents, nil
+	return entries, nil
 }
 
+// Update сохраняет инцидент, если его версия в БД совпадает с incident.Version.
+// При успехе incident.Version увеличивается, при расхождении возвращается ErrVersionConflict.
 func (r *postgresIncidentRepository) Update(ctx context.Context, id uuid.UUID, incident *domain.Incident) error {
+	ctx, end := observeQuery(ctx, "incidents", "update")
+	defer end()
+
 	query := `
 		UPDATE incidents
 		SET title = $1, description = $2, latitude = $3, longitude = $4, 
-		    radius = $5, is_active = $6, updated_at = $7
-		WHERE id = $8
+		    radius = $5, area = ST_GeomFromGeoJSON($6::text)::geography, category = $7, severity = $8,
+		    is_active = $9, source = $10, external_id = $11, effective_at = $12, expires_at = $13,
+		    urgency = $14, certainty = $15, updated_at = $16, version = version + 1
+		WHERE id = $17 AND version = $18 AND deleted_at IS NULL
+		RETURNING version
 	`
 
-	incident.UpdatedAt = time.Now()
-	result, err := r.db.ExecContext(ctx, query,
+	area, err := encodePolygon(incident.Polygon)
+	if err != nil {
+		return err
+	}
+
+	updatedAt := utcNow()
+	var newVersion int
+	err = r.db.QueryRowContext(ctx, query,
 		incident.Title,
 		incident.Description,
 		incident.Latitude,
 		incident.Longitude,
 		incident.Radius,
+		area,
+		incident.Category,
+		incident.Severity,
 		incident.IsActive,
-		incident.UpdatedAt,
+		incident.Source,
+		nullString(incident.ExternalID),
+		utcTime(incident.EffectiveAt),
+		utcTime(incident.ExpiresAt),
+		incident.Urgency,
+		incident.Certainty,
+		updatedAt,
 		id,
-	)
+		incident.Version,
+	).Scan(&newVersion)
 
+	if err == sql.ErrNoRows {
+		// Строки нет (или она удалена) или версия уже ушла вперед
+		var exists bool
+		if err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM incidents WHERE id = $1 AND deleted_at IS NULL)`, id).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to check incident existence: %w", err)
+		}
+		if !exists {
+			return fmt.Errorf("%w", domain.ErrIncidentNotFound)
+		}
+		return fmt.Errorf("%w", domain.ErrVersionConflict)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to update incident: %w", err)
 	}
 
+	incident.UpdatedAt = updatedAt
+	incident.Version = newVersion
+
+	return nil
+}
+
+// Delete помечает инцидент удаленным. Флаг is_active не меняется: после Restore
+// зона вернется в том же состоянии, в котором была удалена.
+func (r *postgresIncidentRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	ctx, end := observeQuery(ctx, "incidents", "delete")
+	defer end()
+
+	query := `
+		UPDATE incidents SET deleted_at = $1, updated_at = $1, version = version + 1
+		WHERE id = $2 AND deleted_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, utcNow(), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete incident: %w", err)
+	}
+
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return fmt.Errorf("failed to get rows affected: %w", err)
@@ -200,36 +322,73 @@ func (r *postgresIncidentRepository) Update(ctx context.Context, id uuid.UUID, i
 	return nil
 }
 
-func (r *postgresIncidentRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	query := `UPDATE incidents SET is_active = false, updated_at = $1 WHERE id = $2`
+// Restore снимает пометку об удалении. Для неудаленного инцидента ничего не делает.
+func (r *postgresIncidentRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	ctx, end := observeQuery(ctx, "incidents", "restore")
+	defer end()
 
-	result, err := r.db.ExecContext(ctx, query, time.Now(), id)
+	query := `
+		UPDATE incidents SET deleted_at = NULL, updated_at = $1, version = version + 1
+		WHERE id = $2 AND deleted_at IS NOT NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, utcNow(), id)
 	if err != nil {
-		return fmt.Errorf("failed to delete incident: %w", err)
+		return fmt.Errorf("failed to restore incident: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
+	if rowsAffected > 0 {
+		return nil
+	}
 
-	if rowsAffected == 0 {
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM incidents WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check incident existence: %w", err)
+	}
+	if !exists {
 		return fmt.Errorf("%w", domain.ErrIncidentNotFound)
 	}
 
 	return nil
 }
 
+// Purge физически удаляет инциденты, помеченные удаленными раньше deletedBefore.
+// Вместе с ними каскадно удаляются история версий и связи с проверками, сами проверки остаются.
+func (r *postgresIncidentRepository) Purge(ctx context.Context, deletedBefore time.Time) (int, error) {
+	ctx, end := observeQuery(ctx, "incidents", "purge")
+	defer end()
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM incidents WHERE deleted_at < $1`, deletedBefore.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge incidents: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return int(purged), nil
+}
+
 func (r *postgresIncidentRepository) FindNearbyIncidents(ctx context.Context, latitude, longitude float64) ([]*domain.Incident, error) {
+	ctx, end := observeQuery(ctx, "incidents", "find_nearby_incidents")
+	defer end()
+
 	query := `
-		SELECT id, title, description, latitude, longitude, radius, is_active, created_at, updated_at
-		FROM incidents
-		WHERE is_active = true
+		SELECT ` + incidentColumns + `
+		FROM incidents i
+		WHERE ` + activeNow + `
 		AND ST_DWithin(
-			ST_MakePoint(longitude, latitude)::geography,
+			ST_MakePoint(i.longitude, i.latitude)::geography,
 			ST_MakePoint($1, $2)::geography,
-			radius
+			i.radius
 		)
+		AND (i.area IS NULL OR ST_Covers(i.area, ST_MakePoint($1, $2)::geography))
 	`
 
 	rows, err := r.db.QueryContext(ctx, query, longitude, latitude)
@@ -238,45 +397,30 @@ func (r *postgresIncidentRepository) FindNearbyIncidents(ctx context.Context, la
 	}
 	defer rows.Close()
 
-	var incidents []*domain.Incident
-	for rows.Next() {
-		var incident domain.Incident
-		err := rows.Scan(
-			&incident.ID,
-			&incident.Title,
-			&incident.Description,
-			&incident.Latitude,
-			&incident.Longitude,
-			&incident.Radius,
-			&incident.IsActive,
-			&incident.CreatedAt,
-			&incident.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan incident: %w", err)
-		}
-		incidents = append(incidents, &incident)
-	}
-
-	return incidents, nil
+	return scanIncidents(rows)
 }
 
 // Используем параметризованный запрос вместо fmt.Sprintf (защита от SQL injection)
-func (r *postgresIncidentRepository) GetStats(ctx context.Context, minutes int) ([]*domain.IncidentStats, error) {
+func (r *postgresIncidentRepository) GetStats(ctx context.Context, from, to time.Time) ([]*domain.IncidentStats, error) {
+	ctx, end := observeQuery(ctx, "incidents", "get_stats")
+	defer end()
+
 	query := `
 		SELECT 
 			i.id as zone_id,
-			COUNT(DISTINCT lc.user_id) as user_count
+			i.title,
+			COUNT(DISTINCT lc.user_id) as user_count,
+			COUNT(lc.id) as check_count
 		FROM incidents i
 		LEFT JOIN location_check_incidents lci ON i.id = lci.incident_id
 		LEFT JOIN location_checks lc ON lci.location_check_id = lc.id
-			AND lc.checked_at >= NOW() - INTERVAL '1 minute' * $1
-		WHERE i.is_active = true
+			AND lc.checked_at >= $1 AND lc.checked_at < $2
+		WHERE ` + activeNow + `
 		GROUP BY i.id
 		ORDER BY user_count DESC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, minutes)
+	rows, err := r.db.QueryContext(ctx, query, from.UTC(), to.UTC())
 	if err != nil {
 		return nil, fmt.Errorf("failed to get stats: %w", err)
 	}
@@ -285,12 +429,430 @@ func (r *postgresIncidentRepository) GetStats(ctx context.Context, minutes int)
 	var stats []*domain.IncidentStats
 	for rows.Next() {
 		var stat domain.IncidentStats
-		err := rows.Scan(&stat.ZoneID, &stat.UserCount)
+		err := rows.Scan(&stat.ZoneID, &stat.Title, &stat.UserCount, &stat.CheckCount)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan stat: %w", err)
 		}
 		stats = append(stats, &stat)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get stats: %w", err)
+	}
 
 	return stats, nil
 }
+
+// GetTimeSeries считает статистику зоны по шагам, шаги без проверок не возвращаются.
+// Вход определяется по предыдущей проверке того же пользователя, в том числе сделанной до from.
+func (r *postgresIncidentRepository) GetTimeSeries(ctx context.Context, filter *domain.TimeSeriesFilter) ([]*domain.TimeSeriesPoint, error) {
+	ctx, end := observeQuery(ctx, "incidents", "get_time_series")
+	defer end()
+
+	query := `
+		WITH users AS (
+			SELECT DISTINCT lc.user_id
+			FROM location_checks lc
+			JOIN location_check_incidents lci ON lci.location_check_id = lc.id AND lci.incident_id = $1
+			WHERE lc.checked_at >= $2 AND lc.checked_at < $3
+		),
+		checks AS (
+			SELECT lc.user_id, lc.checked_at,
+				EXISTS (
+					SELECT 1 FROM location_check_incidents lci
+					WHERE lci.location_check_id = lc.id AND lci.incident_id = $1
+				) AS inside
+			FROM location_checks lc
+			JOIN users u ON u.user_id = lc.user_id
+			WHERE lc.checked_at >= $2 AND lc.checked_at < $3
+		),
+		previous AS (
+			SELECT u.user_id, p.inside
+			FROM users u
+			LEFT JOIN LATERAL (
+				SELECT EXISTS (
+					SELECT 1 FROM location_check_incidents lci
+					WHERE lci.location_check_id = lc.id AND lci.incident_id = $1
+				) AS inside
+				FROM location_checks lc
+				WHERE lc.user_id = u.user_id AND lc.checked_at < $2
+				ORDER BY lc.checked_at DESC
+				LIMIT 1
+			) p ON true
+		),
+		transitions AS (
+			SELECT c.user_id, c.checked_at, c.inside,
+				COALESCE(LAG(c.inside) OVER (PARTITION BY c.user_id ORDER BY c.checked_at), p.inside, false) AS was_inside
+			FROM checks c
+			JOIN previous p ON p.user_id = c.user_id
+		)
+		SELECT date_trunc($4, checked_at, 'UTC') AS bucket,
+			COUNT(DISTINCT user_id) FILTER (WHERE inside),
+			COUNT(*) FILTER (WHERE inside),
+			COUNT(*) FILTER (WHERE inside AND NOT was_inside)
+		FROM transitions
+		GROUP BY bucket
+		HAVING COUNT(*) FILTER (WHERE inside) > 0
+		ORDER BY bucket
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, filter.IncidentID, filter.From.UTC(), filter.To.UTC(), filter.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get time series: %w", err)
+	}
+	defer rows.Close()
+
+	var points []*domain.TimeSeriesPoint
+	for rows.Next() {
+		var point domain.TimeSeriesPoint
+		err := rows.Scan(&point.Time, &point.Users, &point.Checks, &point.Entries)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan time series point: %w", err)
+		}
+		point.Time = point.Time.UTC()
+		points = append(points, &point)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate time series: %w", err)
+	}
+
+	return points, nil
+}
+
+// FindByExternalID возвращает инциденты внешнего источника по его идентификатору.
+// Один внешний документ может породить несколько зон с ключами вида "id#2".
+// Удаленные зоны тоже возвращаются: ключ external_id за ними сохраняется до очистки.
+// Префикс сравнивается как строка, а не через LIKE: в идентификаторах бывают % и _.
+func (r *postgresIncidentRepository) FindByExternalID(ctx context.Context, source, externalID string) ([]*domain.Incident, error) {
+	ctx, end := observeQuery(ctx, "incidents", "find_by_external_id")
+	defer end()
+
+	query := `
+		SELECT ` + incidentColumns + `
+		FROM incidents i
+		WHERE i.source = $1
+		AND (i.external_id = $2 OR starts_with(i.external_id, $2 || '#'))
+		ORDER BY i.external_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, source, externalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find incidents by external id: %w", err)
+	}
+	defer rows.Close()
+
+	return scanIncidents(rows)
+}
+
+// MarkSuperseded запоминает, что документ источника заменен документом supersededBy.
+// Повторная пометка не меняет первую запись.
+func (r *postgresIncidentRepository) MarkSuperseded(ctx context.Context, source, externalID, supersededBy string) error {
+	ctx, end := observeQuery(ctx, "external_superseded", "mark")
+	defer end()
+
+	query := `
+		INSERT INTO external_superseded (source, external_id, superseded_by, superseded_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, source, externalID, supersededBy, utcNow()); err != nil {
+		return fmt.Errorf("failed to mark external document superseded: %w", err)
+	}
+	return nil
+}
+
+// SupersededBy возвращает идентификатор документа, заменившего externalID, или пустую строку
+func (r *postgresIncidentRepository) SupersededBy(ctx context.Context, source, externalID string) (string, error) {
+	ctx, end := observeQuery(ctx, "external_superseded", "get")
+	defer end()
+
+	query := `SELECT superseded_by FROM external_superseded WHERE source = $1 AND external_id = $2`
+
+	var supersededBy string
+	err := r.db.QueryRowContext(ctx, query, source, externalID).Scan(&supersededBy)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get superseding document: %w", err)
+	}
+	return supersededBy, nil
+}
+
+// GetTile собирает векторный тайл (Mapbox Vector Tile) с действующими зонами в слое "incidents".
+// Круги строятся буфером вокруг центра, полигоны берутся как есть.
+func (r *postgresIncidentRepository) GetTile(ctx context.Context, z, x, y int) ([]byte, error) {
+	ctx, end := observeQuery(ctx, "incidents", "get_tile")
+	defer end()
+
+	// Отбор идет в EPSG:3857 по рамке тайла с запасом на буфер ST_AsMVTGeom (256 из 4096).
+	// Рамка тайла как geography на малых зумах вырождается (на z=0 - весь мир), поэтому в 4326 не сравниваем.
+	// Зоны обрезаются по широтам, которые есть в проекции Меркатора.
+	query := `
+		WITH bounds AS (
+			SELECT ST_TileEnvelope($1, $2, $3) AS geom
+		),
+		zones AS (
+			SELECT
+				ST_Transform(
+					ST_ClipByBox2D(
+						COALESCE(i.area, ST_Buffer(ST_MakePoint(i.longitude, i.latitude)::geography, i.radius))::geometry,
+						ST_MakeEnvelope(-180, -85.0511, 180, 85.0511, 4326)
+					),
+					3857
+				) AS geom,
+				i.id, i.title, i.category, i.severity, i.radius, i.version
+			FROM incidents i
+			WHERE ` + activeNow + `
+		),
+		features AS (
+			SELECT
+				ST_AsMVTGeom(zones.geom, bounds.geom) AS geom,
+				zones.id::text AS id,
+				zones.title,
+				zones.category,
+				zones.severity,
+				zones.radius::float8 AS radius,
+				zones.version
+			FROM zones, bounds
+			WHERE zones.geom && ST_Expand(bounds.geom, (ST_XMax(bounds.geom) - ST_XMin(bounds.geom)) * 256 / 4096)
+		)
+		SELECT COALESCE(ST_AsMVT(features.*, 'incidents', 4096, 'geom'), ''::bytea)
+		FROM features
+		WHERE geom IS NOT NULL
+	`
+
+	var tile []byte
+	if err := r.db.QueryRowContext(ctx, query, z, x, y).Scan(&tile); err != nil {
+		return nil, fmt.Errorf("failed to build tile %d/%d/%d: %w", z, x, y, err)
+	}
+
+	return tile, nil
+}
+
+// GetAllAsOf возвращает инциденты в том виде, в котором они были на момент asOf
+func (r *postgresIncidentRepository) GetAllAsOf(ctx context.Context, asOf time.Time, limit, offset int) ([]*domain.Incident, error) {
+	ctx, end := observeQuery(ctx, "incidents", "get_all_as_of")
+	defer end()
+
+	query := `
+		SELECT ` + incidentColumns + `
+		FROM incident_versions v
+		CROSS JOIN LATERAL jsonb_populate_record(NULL::incidents, v.data) i
+		WHERE v.valid_from <= $1
+		AND (v.valid_to IS NULL OR v.valid_to > $1)
+		AND i.deleted_at IS NULL
+		ORDER BY i.created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, asOf.UTC(), limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get incidents as of %s: %w", asOf.Format(time.RFC3339), err)
+	}
+	defer rows.Close()
+
+	return scanIncidents(rows)
+}
+
+// FindNearbyIncidentsAsOf ищет зоны, которые были активны в точке на момент asOf
+func (r *postgresIncidentRepository) FindNearbyIncidentsAsOf(ctx context.Context, latitude, longitude float64, asOf time.Time) ([]*domain.Incident, error) {
+	ctx, end := observeQuery(ctx, "incidents", "find_nearby_incidents_as_of")
+	defer end()
+
+	query := `
+		SELECT ` + incidentColumns + `
+		FROM incident_versions v
+		CROSS JOIN LATERAL jsonb_populate_record(NULL::incidents, v.data) i
+		WHERE v.valid_from <= $3
+		AND (v.valid_to IS NULL OR v.valid_to > $3)
+		AND i.is_active = true
+		AND i.deleted_at IS NULL
+		AND (i.effective_at IS NULL OR i.effective_at <= $3)
+		AND (i.expires_at IS NULL OR i.expires_at > $3)
+		AND ST_DWithin(
+			ST_MakePoint(i.longitude, i.latitude)::geography,
+			ST_MakePoint($1, $2)::geography,
+			i.radius
+		)
+		AND (i.area IS NULL OR ST_Covers(i.area, ST_MakePoint($1, $2)::geography))
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, longitude, latitude, asOf.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to find nearby incidents as of %s: %w", asOf.Format(time.RFC3339), err)
+	}
+	defer rows.Close()
+
+	return scanIncidents(rows)
+}
+
+func (r *postgresIncidentRepository) GetVersions(ctx context.Context, id uuid.UUID) ([]*domain.IncidentVersion, error) {
+	ctx, end := observeQuery(ctx, "incidents", "get_versions")
+	defer end()
+
+	query := `
+		SELECT v.version, v.valid_from, v.valid_to, ` + incidentColumns + `
+		FROM incident_versions v
+		CROSS JOIN LATERAL jsonb_populate_record(NULL::incidents, v.data) i
+		WHERE v.incident_id = $1
+		ORDER BY v.version
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get incident versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []*domain.IncidentVersion
+	for rows.Next() {
+		var version domain.IncidentVersion
+		var validTo sql.NullTime
+		var row incidentRow
+		dest := append([]any{&version.Version, &version.ValidFrom, &validTo}, row.dest()...)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan incident version: %w", err)
+		}
+		incident, err := row.result()
+		if err != nil {
+			return nil, err
+		}
+		version.Incident = *incident
+		if validTo.Valid {
+			version.ValidTo = &validTo.Time
+		}
+		versions = append(versions, &version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate incident versions: %w", err)
+	}
+
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%w", domain.ErrIncidentNotFound)
+	}
+
+	return versions, nil
+}
+
+// incidentColumns - общий список колонок, таблица везде идет под алиасом i
+const incidentColumns = `i.id, i.title, i.description, i.latitude, i.longitude, i.radius, ST_AsGeoJSON(i.area),
+	i.category, i.severity, i.is_active, i.source, i.external_id, i.effective_at, i.expires_at, i.urgency, i.certainty,
+	i.version, i.created_at, i.updated_at, i.deleted_at`
+
+// activeNow - зона включена, не удалена и текущий момент попадает в период ее действия
+const activeNow = `i.is_active = true
+		AND i.deleted_at IS NULL
+		AND (i.effective_at IS NULL OR i.effective_at <= NOW())
+		AND (i.expires_at IS NULL OR i.expires_at > NOW())`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// incidentRow - буфер для сканирования строки incidentColumns
+type incidentRow struct {
+	incident   domain.Incident
+	area       sql.NullString
+	externalID sql.NullString
+}
+
+// dest возвращает указатели на поля в порядке incidentColumns
+func (r *incidentRow) dest() []any {
+	return []any{
+		&r.incident.ID,
+		&r.incident.Title,
+		&r.incident.Description,
+		&r.incident.Latitude,
+		&r.incident.Longitude,
+		&r.incident.Radius,
+		&r.area,
+		&r.incident.Category,
+		&r.incident.Severity,
+		&r.incident.IsActive,
+		&r.incident.Source,
+		&r.externalID,
+		&r.incident.EffectiveAt,
+		&r.incident.ExpiresAt,
+		&r.incident.Urgency,
+		&r.incident.Certainty,
+		&r.incident.Version,
+		&r.incident.CreatedAt,
+		&r.incident.UpdatedAt,
+		&r.incident.DeletedAt,
+	}
+}
+
+// result разбирает геометрию и возвращает готовый инцидент
+func (r *incidentRow) result() (*domain.Incident, error) {
+	incident := r.incident
+	incident.ExternalID = r.externalID.String
+	if r.area.Valid {
+		polygon, err := decodePolygon(r.area.String)
+		if err != nil {
+			return nil, err
+		}
+		incident.Polygon = polygon
+	}
+	return &incident, nil
+}
+
+func scanIncident(row rowScanner) (*domain.Incident, error) {
+	var r incidentRow
+	if err := row.Scan(r.dest()...); err != nil {
+		return nil, err
+	}
+	return r.result()
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// encodePolygon готовит кольцо для ST_GeomFromGeoJSON, nil - зона без полигона
+func encodePolygon(polygon [][2]float64) (any, error) {
+	if polygon == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(map[string]any{
+		"type":        "Polygon",
+		"coordinates": [][][2]float64{polygon},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode polygon: %w", err)
+	}
+
+	return string(data), nil
+}
+
+func decodePolygon(geoJSON string) ([][2]float64, error) {
+	var geometry struct {
+		Coordinates [][][2]float64 `json:"coordinates"`
+	}
+	if err := json.Unmarshal([]byte(geoJSON), &geometry); err != nil {
+		return nil, fmt.Errorf("failed to decode polygon: %w", err)
+	}
+	if len(geometry.Coordinates) == 0 {
+		return nil, nil
+	}
+
+	// дыры в полигонах не поддерживаются, берем только внешнее кольцо
+	return geometry.Coordinates[0], nil
+}
+
+func scanIncidents(rows *sql.Rows) ([]*domain.Incident, error) {
+	var incidents []*domain.Incident
+	for rows.Next() {
+		incident, err := scanIncident(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan incident: %w", err)
+		}
+		incidents = append(incidents, incident)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate incidents: %w", err)
+	}
+
+	return incidents, nil
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"geo-alert-core/internal/domain"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,6 +15,10 @@ import (
 type LocationCheckRepository interface {
 	Create(ctx context.Context, check *domain.LocationCheck) error
 	LinkToIncidents(ctx context.Context, checkID uuid.UUID, incidentIDs []uuid.UUID) error
+	GetLatestByUser(ctx context.Context, userID string, before time.Time) (*domain.LocationCheck, error)
+	ForEach(ctx context.Context, filter *domain.LocationCheckFilter, fn func(*domain.LocationCheck) error) error
+	Heatmap(ctx context.Context, filter *domain.HeatmapFilter) ([]*domain.HeatmapCell, error)
+	ForEachHit(ctx context.Context, after, until time.Time, fn func(*domain.IncidentHit) error) error
 }
 
 // realization for postgres
@@ -27,13 +32,16 @@ func NewPostgresLocationCheckRepository(db *sql.DB) LocationCheckRepository {
 }
 
 func (r *postgresLocationCheckRepository) Create(ctx context.Context, check *domain.LocationCheck) error {
+	ctx, end := observeQuery(ctx, "location_checks", "create")
+	defer end()
+
 	query := `
 		INSERT INTO location_checks (id, user_id, latitude, longitude, checked_at, webhook_sent)
 		VALUES ($1, $2, $3, $4, $5, $6)
 	`
 
 	check.ID = uuid.New()
-	check.CheckedAt = time.Now()
+	check.CheckedAt = utcNow()
 	check.WebhookSent = false
 
 	_, err := r.db.ExecContext(ctx, query,
@@ -53,6 +61,9 @@ func (r *postgresLocationCheckRepository) Create(ctx context.Context, check *dom
 }
 
 func (r *postgresLocationCheckRepository) LinkToIncidents(ctx context.Context, checkID uuid.UUID, incidentIDs []uuid.UUID) error {
+	ctx, end := observeQuery(ctx, "location_checks", "link_to_incidents")
+	defer end()
+
 	if len(incidentIDs) == 0 {
 		return nil // no incidents to link
 	}
@@ -89,3 +100,224 @@ func (r *postgresLocationCheckRepository) LinkToIncidents(ctx context.Context, c
 
 	return nil
 }
+
+// last check of user made not later than before
+func (r *postgresLocationCheckRepository) GetLatestByUser(ctx context.Context, userID string, before time.Time) (*domain.LocationCheck, error) {
+	ctx, end := observeQuery(ctx, "location_checks", "get_latest_by_user")
+	defer end()
+
+	query := `
+		SELECT id, user_id, latitude, longitude, checked_at, webhook_sent
+		FROM location_checks
+		WHERE user_id = $1 AND checked_at <= $2
+		ORDER BY checked_at DESC
+		LIMIT 1
+	`
+
+	var check domain.LocationCheck
+	err := r.db.QueryRowContext(ctx, query, userID, before.UTC()).Scan(
+		&check.ID,
+		&check.UserID,
+		&check.Latitude,
+		&check.Longitude,
+		&check.CheckedAt,
+		&check.WebhookSent,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w", domain.ErrLocationCheckNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest location check: %w", err)
+	}
+
+	return &check, nil
+}
+
+// stream checks for period ordered by user and time, without loading all of them into memory
+func (r *postgresLocationCheckRepository) ForEach(ctx context.Context, filter *domain.LocationCheckFilter, fn func(*domain.LocationCheck) error) error {
+	ctx, end := observeQuery(ctx, "location_checks", "for_each")
+	defer end()
+
+	query := `
+		SELECT lc.id, lc.user_id, lc.latitude, lc.longitude, lc.checked_at, lc.webhook_sent,
+			(
+				SELECT string_agg(lci.incident_id::text, ',' ORDER BY lci.incident_id)
+				FROM location_check_incidents lci
+				WHERE lci.location_check_id = lc.id
+			) AS incident_ids
+		FROM location_checks lc
+		WHERE lc.checked_at >= $1 AND lc.checked_at < $2
+		AND ($3::text = '' OR lc.user_id = $3)
+		ORDER BY lc.user_id, lc.checked_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, filter.From.UTC(), filter.To.UTC(), filter.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get location checks: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var check domain.LocationCheck
+		var incidentIDs sql.NullString
+		err := rows.Scan(
+			&check.ID,
+			&check.UserID,
+			&check.Latitude,
+			&check.Longitude,
+			&check.CheckedAt,
+			&check.WebhookSent,
+			&incidentIDs,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan location check: %w", err)
+		}
+
+		if incidentIDs.Valid {
+			for _, value := range strings.Split(incidentIDs.String, ",") {
+				id, err := uuid.Parse(value)
+				if err != nil {
+					return fmt.Errorf("failed to parse incident id of location check: %w", err)
+				}
+				check.IncidentIDs = append(check.IncidentIDs, id)
+			}
+		}
+
+		if err := fn(&check); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate location checks: %w", err)
+	}
+
+	return nil
+}
+
+// checks inside bbox and time range, $7/$8 restrict them to ones that hit a zone
+const heatmapChecks = `
+	WITH checks AS (
+		SELECT lc.user_id, lc.latitude, lc.longitude
+		FROM location_checks lc
+		WHERE lc.checked_at >= $1 AND lc.checked_at < $2
+		AND lc.longitude BETWEEN $3 AND $5
+		AND lc.latitude BETWEEN $4 AND $6
+		AND (NOT $7::boolean OR EXISTS (
+			SELECT 1 FROM location_check_incidents lci
+			WHERE lci.location_check_id = lc.id
+			AND ($8::uuid IS NULL OR lci.incident_id = $8)
+		))
+	)
+`
+
+// aggregate checks into geohash or hexagon cells, cells without checks are omitted
+func (r *postgresLocationCheckRepository) Heatmap(ctx context.Context, filter *domain.HeatmapFilter) ([]*domain.HeatmapCell, error) {
+	ctx, end := observeQuery(ctx, "location_checks", "heatmap")
+	defer end()
+
+	var query string
+	var size any
+	switch filter.Grid {
+	case domain.GridGeohash:
+		query = heatmapChecks + `
+			, cells AS (
+				SELECT ST_GeoHash(ST_SetSRID(ST_MakePoint(longitude, latitude), 4326), $9::int) AS cell, user_id
+				FROM checks
+			)
+			SELECT cell, COUNT(*), COUNT(DISTINCT user_id),
+				ST_Y(ST_PointFromGeoHash(cell)), ST_X(ST_PointFromGeoHash(cell)),
+				ST_AsGeoJSON(ST_GeomFromGeoHash(cell))
+			FROM cells
+			GROUP BY cell
+			ORDER BY cell
+		`
+		size = filter.Precision
+	case domain.GridHex:
+		// the grid is built in Web Mercator so cells are regular on the map
+		query = heatmapChecks + `
+			, points AS (
+				SELECT user_id, ST_Transform(ST_SetSRID(ST_MakePoint(longitude, latitude), 4326), 3857) AS geom
+				FROM checks
+			)
+			SELECT hex.i || ',' || hex.j, COUNT(*), COUNT(DISTINCT points.user_id),
+				ST_Y(ST_Transform(ST_Centroid(hex.geom), 4326)), ST_X(ST_Transform(ST_Centroid(hex.geom), 4326)),
+				ST_AsGeoJSON(ST_Transform(hex.geom, 4326))
+			FROM ST_HexagonGrid($9::float8, ST_Transform(ST_MakeEnvelope($3, $4, $5, $6, 4326), 3857)) AS hex
+			JOIN points ON ST_Intersects(hex.geom, points.geom)
+			GROUP BY hex.i, hex.j, hex.geom
+			ORDER BY hex.i, hex.j
+		`
+		size = filter.CellSize
+	default:
+		return nil, fmt.Errorf("%w: unknown grid %q", domain.ErrInvalidFilter, filter.Grid)
+	}
+
+	onlyHits := filter.OnlyHits || filter.IncidentID != nil
+	rows, err := r.db.QueryContext(ctx, query,
+		filter.From.UTC(), filter.To.UTC(),
+		filter.BBox.MinLongitude, filter.BBox.MinLatitude, filter.BBox.MaxLongitude, filter.BBox.MaxLatitude,
+		onlyHits, filter.IncidentID, size,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build heatmap: %w", err)
+	}
+	defer rows.Close()
+
+	cells := []*domain.HeatmapCell{}
+	for rows.Next() {
+		var cell domain.HeatmapCell
+		var polygon string
+		err := rows.Scan(&cell.Cell, &cell.Checks, &cell.Users, &cell.Latitude, &cell.Longitude, &polygon)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan heatmap cell: %w", err)
+		}
+		if cell.Polygon, err = decodePolygon(polygon); err != nil {
+			return nil, err
+		}
+		cells = append(cells, &cell)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate heatmap cells: %w", err)
+	}
+
+	return cells, nil
+}
+
+// stream hits of zones with after < checked_at <= until, used by the stats rollup
+func (r *postgresLocationCheckRepository) ForEachHit(ctx context.Context, after, until time.Time, fn func(*domain.IncidentHit) error) error {
+	ctx, end := observeQuery(ctx, "location_checks", "for_each_hit")
+	defer end()
+
+	query := `
+		SELECT lci.incident_id, lc.user_id, lc.checked_at
+		FROM location_checks lc
+		JOIN location_check_incidents lci ON lci.location_check_id = lc.id
+		WHERE lc.checked_at > $1 AND lc.checked_at <= $2
+		ORDER BY lc.checked_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, after.UTC(), until.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to get incident hits: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hit domain.IncidentHit
+		if err := rows.Scan(&hit.IncidentID, &hit.UserID, &hit.CheckedAt); err != nil {
+			return fmt.Errorf("failed to scan incident hit: %w", err)
+		}
+		if err := fn(&hit); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate incident hits: %w", err)
+	}
+
+	return nil
+}
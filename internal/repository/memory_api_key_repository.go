@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"geo-alert-core/internal/domain"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// realization in memory, keys live in the shared store
+type memoryAPIKeyRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryAPIKeyRepository(store *MemoryStore) APIKeyRepository {
+	return &memoryAPIKeyRepository{store: store}
+}
+
+func (r *memoryAPIKeyRepository) Create(ctx context.Context, key *domain.APIKey, hash string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	// same as UNIQUE (key_hash)
+	for _, stored := range r.store.apiKeys {
+		if stored.hash == hash {
+			return fmt.Errorf("failed to create api key: hash already exists")
+		}
+	}
+
+	key.ID = uuid.New()
+	key.CreatedAt = time.Now()
+	key.RevokedAt = nil
+
+	r.store.apiKeys = append(r.store.apiKeys, &storedAPIKey{key: *key, hash: hash})
+	return nil
+}
+
+func (r *memoryAPIKeyRepository) FindByHash(ctx context.Context, hash string) (*domain.APIKey, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, stored := range r.store.apiKeys {
+		if stored.hash == hash && stored.key.RevokedAt == nil {
+			return cloneAPIKey(&stored.key), nil
+		}
+	}
+	return nil, fmt.Errorf("%w", domain.ErrAPIKeyNotFound)
+}
+
+func (r *memoryAPIKeyRepository) List(ctx context.Context) ([]*domain.APIKey, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	keys := make([]*domain.APIKey, 0, len(r.store.apiKeys))
+	for i := len(r.store.apiKeys) - 1; i >= 0; i-- {
+		keys = append(keys, cloneAPIKey(&r.store.apiKeys[i].key))
+	}
+	return keys, nil
+}
+
+func (r *memoryAPIKeyRepository) Revoke(ctx context.Context, id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, stored := range r.store.apiKeys {
+		if stored.key.ID != id {
+			continue
+		}
+		if stored.key.RevokedAt == nil {
+			now := time.Now()
+			stored.key.RevokedAt = &now
+		}
+		return nil
+	}
+	return fmt.Errorf("%w", domain.ErrAPIKeyNotFound)
+}
+
+func cloneAPIKey(key *domain.APIKey) *domain.APIKey {
+	clone := *key
+	if key.RevokedAt != nil {
+		revokedAt := *key.RevokedAt
+		clone.RevokedAt = &revokedAt
+	}
+	return &clone
+}
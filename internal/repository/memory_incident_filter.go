@@ -0,0 +1,274 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"geo-alert-core/internal/domain"
+	"geo-alert-core/internal/geo"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// memorySortValue - значение сортировки; заполнено одно поле в зависимости от поля сортировки
+type memorySortValue struct {
+	time   time.Time
+	text   string
+	number float64
+}
+
+func (v memorySortValue) compare(other memorySortValue) int {
+	if c := v.time.Compare(other.time); c != 0 {
+		return c
+	}
+	if c := strings.Compare(v.text, other.text); c != 0 {
+		return c
+	}
+	return cmp.Compare(v.number, other.number)
+}
+
+// memorySortColumn - как получить значение сортировки из инцидента и как записать его в курсор
+type memorySortColumn struct {
+	value  func(*domain.Incident) memorySortValue
+	encode func(memorySortValue) string
+	decode func(string) (memorySortValue, error)
+}
+
+func timeSortColumn(field func(*domain.Incident) time.Time) memorySortColumn {
+	return memorySortColumn{
+		value: func(incident *domain.Incident) memorySortValue {
+			return memorySortValue{time: field(incident)}
+		},
+		encode: func(v memorySortValue) string {
+			return v.time.Format(time.RFC3339Nano)
+		},
+		decode: func(s string) (memorySortValue, error) {
+			t, err := time.Parse(time.RFC3339Nano, s)
+			return memorySortValue{time: t}, err
+		},
+	}
+}
+
+func numberSortColumn(field func(*domain.Incident) float64) memorySortColumn {
+	return memorySortColumn{
+		value: func(incident *domain.Incident) memorySortValue {
+			return memorySortValue{number: field(incident)}
+		},
+		encode: func(v memorySortValue) string {
+			return strconv.FormatFloat(v.number, 'g', -1, 64)
+		},
+		decode: func(s string) (memorySortValue, error) {
+			f, err := strconv.ParseFloat(s, 64)
+			return memorySortValue{number: f}, err
+		},
+	}
+}
+
+// memorySeverityRank упорядочивает уровни опасности по возрастанию, как severityRank
+func memorySeverityRank(severity string) float64 {
+	switch severity {
+	case domain.SeverityLow:
+		return 1
+	case domain.SeverityMedium:
+		return 2
+	case domain.SeverityHigh:
+		return 3
+	case domain.SeverityCritical:
+		return 4
+	}
+	return 0
+}
+
+// memoryIncidentSortColumn возвращает сортировку для поля фильтра
+func memoryIncidentSortColumn(filter *domain.IncidentFilter) (memorySortColumn, error) {
+	switch filter.SortBy {
+	case "", domain.SortByCreatedAt:
+		return timeSortColumn(func(i *domain.Incident) time.Time { return i.CreatedAt }), nil
+	case domain.SortByUpdatedAt:
+		return timeSortColumn(func(i *domain.Incident) time.Time { return i.UpdatedAt }), nil
+	case domain.SortByTitle:
+		return memorySortColumn{
+			value:  func(i *domain.Incident) memorySortValue { return memorySortValue{text: i.Title} },
+			encode: func(v memorySortValue) string { return v.text },
+			decode: func(s string) (memorySortValue, error) { return memorySortValue{text: s}, nil },
+		}, nil
+	case domain.SortByRadius:
+		return numberSortColumn(func(i *domain.Incident) float64 { return i.Radius }), nil
+	case domain.SortBySeverity:
+		return numberSortColumn(func(i *domain.Incident) float64 { return memorySeverityRank(i.Severity) }), nil
+	case domain.SortByDistance:
+		near := filter.Near
+		if near == nil {
+			return memorySortColumn{}, fmt.Errorf("%w: sort by distance requires a point", domain.ErrInvalidFilter)
+		}
+		return numberSortColumn(func(i *domain.Incident) float64 {
+			return geo.Distance(i.Latitude, i.Longitude, near.Latitude, near.Longitude)
+		}), nil
+	case domain.SortByRelevance:
+		if filter.Query == "" {
+			return memorySortColumn{}, fmt.Errorf("%w: sort by relevance requires a search query", domain.ErrInvalidFilter)
+		}
+		terms := searchTerms(filter.Query)
+		return numberSortColumn(func(i *domain.Incident) float64 { return searchRank(i, terms) }), nil
+	}
+
+	return memorySortColumn{}, fmt.Errorf("%w: unknown sort field %q", domain.ErrInvalidFilter, filter.SortBy)
+}
+
+// searchTerms разбивает запрос на слова в нижнем регистре
+func searchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// searchMatches - все слова запроса встречаются в названии или описании.
+// Морфологии нет, поэтому слово ищется как подстрока: "пожар" найдет и "пожары".
+func searchMatches(incident *domain.Incident, terms []string) bool {
+	text := strings.ToLower(incident.Title + " " + incident.Description)
+	for _, term := range terms {
+		if !strings.Contains(text, term) {
+			return false
+		}
+	}
+	return true
+}
+
+// searchRank - число вхождений слов, название весит больше описания (веса A и B у ts_rank)
+func searchRank(incident *domain.Incident, terms []string) float64 {
+	title := strings.ToLower(incident.Title)
+	description := strings.ToLower(incident.Description)
+
+	var rank float64
+	for _, term := range terms {
+		rank += float64(strings.Count(title, term)) + 0.4*float64(strings.Count(description, term))
+	}
+	return rank
+}
+
+// memoryIncidentMatches проверяет инцидент по условиям фильтра (без курсора)
+func memoryIncidentMatches(incident *domain.Incident, filter *domain.IncidentFilter, terms []string) bool {
+	if (incident.DeletedAt != nil) != filter.Deleted {
+		return false
+	}
+	if filter.Query != "" && !searchMatches(incident, terms) {
+		return false
+	}
+	if filter.IsActive != nil && incident.IsActive != *filter.IsActive {
+		return false
+	}
+	if filter.Category != "" && incident.Category != filter.Category {
+		return false
+	}
+	if len(filter.Severities) > 0 && !slices.Contains(filter.Severities, incident.Severity) {
+		return false
+	}
+	if filter.CreatedFrom != nil && incident.CreatedAt.Before(*filter.CreatedFrom) {
+		return false
+	}
+	if filter.CreatedTo != nil && !incident.CreatedAt.Before(*filter.CreatedTo) {
+		return false
+	}
+	if filter.UpdatedFrom != nil && incident.UpdatedAt.Before(*filter.UpdatedFrom) {
+		return false
+	}
+	if filter.UpdatedTo != nil && !incident.UpdatedAt.Before(*filter.UpdatedTo) {
+		return false
+	}
+	if box := filter.BBox; box != nil {
+		if incident.Longitude < box.MinLongitude || incident.Longitude > box.MaxLongitude ||
+			incident.Latitude < box.MinLatitude || incident.Latitude > box.MaxLatitude {
+			return false
+		}
+	}
+	if near := filter.Near; near != nil {
+		distance := geo.Distance(incident.Latitude, incident.Longitude, near.Latitude, near.Longitude)
+		if distance > incident.Radius+near.Meters {
+			return false
+		}
+	}
+	return true
+}
+
+// List возвращает страницу инцидентов по фильтру.
+// Сортировка всегда дополняется id, поэтому курсор стабилен при одинаковых значениях.
+func (r *memoryIncidentRepository) List(ctx context.Context, filter *domain.IncidentFilter) (*domain.IncidentPage, error) {
+	sort, err := memoryIncidentSortColumn(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	terms := searchTerms(filter.Query)
+	incidents := r.selectIncidents(func(incident *domain.Incident) bool {
+		return memoryIncidentMatches(incident, filter, terms)
+	})
+
+	result := &domain.IncidentPage{}
+	if filter.IncludeTotal {
+		total := len(incidents)
+		result.Total = &total
+	}
+
+	direction := 1
+	if filter.SortDesc {
+		direction = -1
+	}
+	compare := func(value memorySortValue, id uuid.UUID, otherValue memorySortValue, otherID uuid.UUID) int {
+		if c := value.compare(otherValue); c != 0 {
+			return c * direction
+		}
+		return compareIDs(id, otherID) * direction
+	}
+
+	type sortedIncident struct {
+		incident *domain.Incident
+		value    memorySortValue
+	}
+	sorted := make([]sortedIncident, len(incidents))
+	for i, incident := range incidents {
+		sorted[i] = sortedIncident{incident: incident, value: sort.value(incident)}
+	}
+	slices.SortFunc(sorted, func(a, b sortedIncident) int {
+		return compare(a.value, a.incident.ID, b.value, b.incident.ID)
+	})
+
+	if cursor := filter.Cursor; cursor != nil {
+		cursorValue, err := sort.decode(cursor.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed cursor", domain.ErrInvalidFilter)
+		}
+		// первая запись строго после курсора
+		start, _ := slices.BinarySearchFunc(sorted, cursorValue, func(item sortedIncident, target memorySortValue) int {
+			if c := compare(item.value, item.incident.ID, target, cursor.ID); c != 0 {
+				return c
+			}
+			return -1
+		})
+		sorted = sorted[start:]
+	} else {
+		sorted = page(sorted, -1, filter.Offset)
+	}
+
+	for i, item := range sorted {
+		if i == filter.Limit {
+			if i > 0 {
+				last := sorted[i-1]
+				result.NextCursor = &domain.IncidentCursor{
+					SortBy:   filter.SortBy,
+					SortDesc: filter.SortDesc,
+					Value:    sort.encode(last.value),
+					ID:       last.incident.ID,
+				}
+			}
+			break
+		}
+		result.Items = append(result.Items, item.incident)
+	}
+
+	return result, nil
+}
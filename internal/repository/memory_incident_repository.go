@@ -0,0 +1,503 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"geo-alert-core/internal/domain"
+	"geo-alert-core/internal/format/mvt"
+	"geo-alert-core/internal/geo"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// число вершин круга в тайле, как у ST_Buffer по умолчанию
+const memoryTileCircleVertices = 32
+
+// memoryIncidentRepository - IncidentRepository без БД: расстояния по формуле гаверсинусов,
+// полнотекстовый поиск - по вхождению всех слов запроса
+type memoryIncidentRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryIncidentRepository(store *MemoryStore) IncidentRepository {
+	return &memoryIncidentRepository{store: store}
+}
+
+func (r *memoryIncidentRepository) Create(ctx context.Context, incident *domain.Incident) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	// id можно задать заранее (импорт), иначе генерируем
+	if incident.ID == uuid.Nil {
+		incident.ID = uuid.New()
+	}
+	if _, exists := r.store.incidents[incident.ID]; exists {
+		return fmt.Errorf("failed to create incident: id %s already exists", incident.ID)
+	}
+
+	now := time.Now()
+	incident.Version = 1
+	incident.CreatedAt = now
+	incident.UpdatedAt = now
+
+	stored := cloneIncident(incident)
+	r.store.incidents[stored.ID] = stored
+	r.store.recordVersion(stored)
+
+	return nil
+}
+
+func (r *memoryIncidentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Incident, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	incident, ok := r.store.incidents[id]
+	if !ok || !notDeleted(incident) {
+		return nil, fmt.Errorf("%w", domain.ErrIncidentNotFound)
+	}
+
+	return cloneIncident(incident), nil
+}
+
+func (r *memoryIncidentRepository) GetAll(ctx context.Context, limit, offset int) ([]*domain.Incident, error) {
+	return page(r.selectIncidents(notDeleted), limit, offset), nil
+}
+
+func (r *memoryIncidentRepository) GetActiveIncidents(ctx context.Context) ([]*domain.Incident, error) {
+	now := time.Now()
+	return r.selectIncidents(func(incident *domain.Incident) bool {
+		return activeAt(incident, now)
+	}), nil
+}
+
+// GetFeedEntries возвращает действующие зоны и зоны, снятые (выключенные, удаленные или истекшие)
+// не раньше endedSince, вместе с их предыдущей версией
+func (r *memoryIncidentRepository) GetFeedEntries(ctx context.Context, endedSince time.Time) ([]*domain.FeedEntry, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	now := time.Now()
+	var entries []*domain.FeedEntry
+	for _, incident := range r.store.incidents {
+		published := incident.IsActive && notDeleted(incident)
+		ended := !published && !incident.UpdatedAt.Before(endedSince)
+		expired := published && incident.ExpiresAt != nil &&
+			!incident.ExpiresAt.Before(endedSince) && !incident.ExpiresAt.After(now)
+		if !activeAt(incident, now) && !ended && !expired {
+			continue
+		}
+
+		entry := &domain.FeedEntry{Incident: cloneIncident(incident)}
+		if versions := r.store.versions[incident.ID]; len(versions) > 1 {
+			prev := versions[len(versions)-2]
+			entry.Previous = &domain.PreviousVersion{
+				Version:   prev.Incident.Version,
+				UpdatedAt: prev.ValidFrom,
+				Published: prev.Incident.IsActive && notDeleted(&prev.Incident),
+			}
+		}
+		entries = append(entries, entry)
+	}
+	slices.SortFunc(entries, func(a, b *domain.FeedEntry) int {
+		return newestFirst(a.Incident, b.Incident)
+	})
+
+	return entries, nil
+}
+
+// Update сохраняет инцидент, если его текущая версия совпадает с incident.Version.
+// При успехе incident.Version увеличивается, при расхождении возвращается ErrVersionConflict.
+func (r *memoryIncidentRepository) Update(ctx context.Context, id uuid.UUID, incident *domain.Incident) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	current, ok := r.store.incidents[id]
+	if !ok || !notDeleted(current) {
+		return fmt.Errorf("%w", domain.ErrIncidentNotFound)
+	}
+	if current.Version != incident.Version {
+		return fmt.Errorf("%w", domain.ErrVersionConflict)
+	}
+
+	// id, created_at и deleted_at не меняются, как и в UPDATE
+	stored := cloneIncident(incident)
+	stored.ID = id
+	stored.CreatedAt = current.CreatedAt
+	stored.DeletedAt = nil
+	stored.UpdatedAt = time.Now()
+	stored.Version = current.Version + 1
+
+	r.store.incidents[id] = stored
+	r.store.recordVersion(stored)
+
+	incident.UpdatedAt = stored.UpdatedAt
+	incident.Version = stored.Version
+
+	return nil
+}
+
+// Delete помечает инцидент удаленным. Флаг IsActive не меняется: после Restore
+// зона вернется в том же состоянии, в котором была удалена.
+func (r *memoryIncidentRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	current, ok := r.store.incidents[id]
+	if !ok || !notDeleted(current) {
+		return fmt.Errorf("%w", domain.ErrIncidentNotFound)
+	}
+
+	now := time.Now()
+	stored := cloneIncident(current)
+	stored.DeletedAt = &now
+	stored.UpdatedAt = now
+	stored.Version++
+
+	r.store.incidents[id] = stored
+	r.store.recordVersion(stored)
+
+	return nil
+}
+
+// Restore снимает пометку об удалении. Для неудаленного инцидента ничего не делает.
+func (r *memoryIncidentRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	current, ok := r.store.incidents[id]
+	if !ok {
+		return fmt.Errorf("%w", domain.ErrIncidentNotFound)
+	}
+	if notDeleted(current) {
+		return nil
+	}
+
+	stored := cloneIncident(current)
+	stored.DeletedAt = nil
+	stored.UpdatedAt = time.Now()
+	stored.Version++
+
+	r.store.incidents[id] = stored
+	r.store.recordVersion(stored)
+
+	return nil
+}
+
+// Purge удаляет инциденты, помеченные удаленными раньше deletedBefore, вместе с историей
+// и связями с проверками, как ON DELETE CASCADE. Сами проверки остаются.
+func (r *memoryIncidentRepository) Purge(ctx context.Context, deletedBefore time.Time) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	purged := map[uuid.UUID]bool{}
+	for id, incident := range r.store.incidents {
+		if incident.DeletedAt != nil && incident.DeletedAt.Before(deletedBefore) {
+			purged[id] = true
+			delete(r.store.incidents, id)
+			delete(r.store.versions, id)
+		}
+	}
+	if len(purged) == 0 {
+		return 0, nil
+	}
+
+	for checkID, links := range r.store.links {
+		links = slices.DeleteFunc(links, func(incidentID uuid.UUID) bool { return purged[incidentID] })
+		if len(links) == 0 {
+			delete(r.store.links, checkID)
+		} else {
+			r.store.links[checkID] = links
+		}
+	}
+
+	return len(purged), nil
+}
+
+func (r *memoryIncidentRepository) FindNearbyIncidents(ctx context.Context, latitude, longitude float64) ([]*domain.Incident, error) {
+	now := time.Now()
+	return r.selectIncidents(func(incident *domain.Incident) bool {
+		return activeAt(incident, now) && covers(incident, latitude, longitude)
+	}), nil
+}
+
+func (r *memoryIncidentRepository) GetStats(ctx context.Context, from, to time.Time) ([]*domain.IncidentStats, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	users := map[uuid.UUID]map[string]bool{}
+	checks := map[uuid.UUID]int{}
+	for _, check := range r.store.checks {
+		if check.CheckedAt.Before(from) || !check.CheckedAt.Before(to) {
+			continue
+		}
+		for _, incidentID := range r.store.links[check.ID] {
+			if users[incidentID] == nil {
+				users[incidentID] = map[string]bool{}
+			}
+			users[incidentID][check.UserID] = true
+			checks[incidentID]++
+		}
+	}
+
+	now := time.Now()
+	var stats []*domain.IncidentStats
+	for _, incident := range r.store.incidents {
+		if !activeAt(incident, now) {
+			continue
+		}
+		stats = append(stats, &domain.IncidentStats{
+			ZoneID:     incident.ID,
+			Title:      incident.Title,
+			UserCount:  len(users[incident.ID]),
+			CheckCount: checks[incident.ID],
+		})
+	}
+
+	slices.SortFunc(stats, func(a, b *domain.IncidentStats) int {
+		if a.UserCount != b.UserCount {
+			return b.UserCount - a.UserCount
+		}
+		return compareIDs(a.ZoneID, b.ZoneID)
+	})
+
+	return stats, nil
+}
+
+// GetTimeSeries считает статистику зоны по шагам, шаги без проверок не возвращаются.
+// Вход определяется по предыдущей проверке того же пользователя, в том числе сделанной до from.
+func (r *memoryIncidentRepository) GetTimeSeries(ctx context.Context, filter *domain.TimeSeriesFilter) ([]*domain.TimeSeriesPoint, error) {
+	truncate, err := bucketTruncate(filter.Bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	inside := func(check *domain.LocationCheck) bool {
+		return slices.Contains(r.store.links[check.ID], filter.IncidentID)
+	}
+
+	// пользователи, хотя бы раз попавшие в зону за период
+	users := map[string]bool{}
+	for _, check := range r.store.checks {
+		if !check.CheckedAt.Before(filter.From) && check.CheckedAt.Before(filter.To) && inside(check) {
+			users[check.UserID] = true
+		}
+	}
+
+	type bucket struct {
+		users   map[string]bool
+		checks  int
+		entries int
+	}
+	buckets := map[time.Time]*bucket{}
+
+	wasInside := map[string]bool{}
+	for _, check := range r.store.sortedChecks() {
+		if !users[check.UserID] || !check.CheckedAt.Before(filter.To) {
+			continue
+		}
+		isInside := inside(check)
+		if check.CheckedAt.Before(filter.From) {
+			// проверки до from только задают состояние на начало периода
+			wasInside[check.UserID] = isInside
+			continue
+		}
+
+		if isInside {
+			start := truncate(check.CheckedAt.UTC())
+			b := buckets[start]
+			if b == nil {
+				b = &bucket{users: map[string]bool{}}
+				buckets[start] = b
+			}
+			b.users[check.UserID] = true
+			b.checks++
+			if !wasInside[check.UserID] {
+				b.entries++
+			}
+		}
+		wasInside[check.UserID] = isInside
+	}
+
+	var points []*domain.TimeSeriesPoint
+	for start, b := range buckets {
+		points = append(points, &domain.TimeSeriesPoint{
+			Time:    start,
+			Users:   len(b.users),
+			Checks:  b.checks,
+			Entries: b.entries,
+		})
+	}
+	slices.SortFunc(points, func(a, b *domain.TimeSeriesPoint) int {
+		return a.Time.Compare(b.Time)
+	})
+
+	return points, nil
+}
+
+// bucketTruncate - аналог date_trunc(bucket, t, 'UTC')
+func bucketTruncate(bucket string) (func(time.Time) time.Time, error) {
+	switch bucket {
+	case domain.BucketMinute, domain.BucketHour:
+		step := domain.BucketDuration(bucket)
+		return func(t time.Time) time.Time { return t.Truncate(step) }, nil
+	case domain.BucketDay:
+		return func(t time.Time) time.Time {
+			return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: unknown bucket %q", domain.ErrInvalidFilter, bucket)
+}
+
+// FindByExternalID возвращает инциденты внешнего источника по его идентификатору.
+// Один внешний документ может породить несколько зон с ключами вида "id#2".
+// Удаленные зоны тоже возвращаются: ключ external_id за ними сохраняется до очистки.
+func (r *memoryIncidentRepository) FindByExternalID(ctx context.Context, source, externalID string) ([]*domain.Incident, error) {
+	incidents := r.selectIncidents(func(incident *domain.Incident) bool {
+		return incident.Source == source &&
+			(incident.ExternalID == externalID || strings.HasPrefix(incident.ExternalID, externalID+"#"))
+	})
+
+	slices.SortStableFunc(incidents, func(a, b *domain.Incident) int {
+		return strings.Compare(a.ExternalID, b.ExternalID)
+	})
+
+	return incidents, nil
+}
+
+// MarkSuperseded запоминает, что документ источника заменен документом supersededBy.
+// Повторная пометка не меняет первую запись.
+func (r *memoryIncidentRepository) MarkSuperseded(ctx context.Context, source, externalID, supersededBy string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	key := externalKey{source: source, externalID: externalID}
+	if _, ok := r.store.superseded[key]; !ok {
+		r.store.superseded[key] = supersededBy
+	}
+	return nil
+}
+
+// SupersededBy возвращает идентификатор документа, заменившего externalID, или пустую строку
+func (r *memoryIncidentRepository) SupersededBy(ctx context.Context, source, externalID string) (string, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.store.superseded[externalKey{source: source, externalID: externalID}], nil
+}
+
+// GetTile собирает векторный тайл с действующими зонами в слое "incidents".
+// Круги строятся многоугольником вокруг центра, полигоны берутся как есть.
+func (r *memoryIncidentRepository) GetTile(ctx context.Context, z, x, y int) ([]byte, error) {
+	// границы тайла в градусах
+	size := 2 * geo.MercatorExtent / math.Exp2(float64(z))
+	maxLat, minLon := geo.FromMercator(-geo.MercatorExtent+float64(x)*size, geo.MercatorExtent-float64(y)*size)
+	minLat, maxLon := geo.FromMercator(-geo.MercatorExtent+float64(x+1)*size, geo.MercatorExtent-float64(y+1)*size)
+
+	now := time.Now()
+	incidents := r.selectIncidents(func(incident *domain.Incident) bool {
+		if !activeAt(incident, now) {
+			return false
+		}
+		// ближайшая к центру зоны точка тайла
+		lat := math.Max(minLat, math.Min(maxLat, incident.Latitude))
+		lon := math.Max(minLon, math.Min(maxLon, incident.Longitude))
+		return geo.Distance(incident.Latitude, incident.Longitude, lat, lon) <= incident.Radius
+	})
+
+	layer := mvt.NewLayer("incidents", z, x, y)
+	for _, incident := range incidents {
+		ring := incident.Polygon
+		if ring == nil {
+			ring = geo.CirclePolygon(incident.Latitude, incident.Longitude, incident.Radius, memoryTileCircleVertices)
+		}
+		layer.AddPolygon(ring, []mvt.Property{
+			{Key: "id", Value: incident.ID.String()},
+			{Key: "title", Value: incident.Title},
+			{Key: "category", Value: incident.Category},
+			{Key: "severity", Value: incident.Severity},
+			{Key: "radius", Value: incident.Radius},
+			{Key: "version", Value: incident.Version},
+		})
+	}
+
+	return mvt.Encode(layer), nil
+}
+
+// GetAllAsOf возвращает инциденты в том виде, в котором они были на момент asOf
+func (r *memoryIncidentRepository) GetAllAsOf(ctx context.Context, asOf time.Time, limit, offset int) ([]*domain.Incident, error) {
+	return page(r.selectVersionsAsOf(asOf, notDeleted), limit, offset), nil
+}
+
+// FindNearbyIncidentsAsOf ищет зоны, которые были активны в точке на момент asOf
+func (r *memoryIncidentRepository) FindNearbyIncidentsAsOf(ctx context.Context, latitude, longitude float64, asOf time.Time) ([]*domain.Incident, error) {
+	return r.selectVersionsAsOf(asOf, func(incident *domain.Incident) bool {
+		return activeAt(incident, asOf) && covers(incident, latitude, longitude)
+	}), nil
+}
+
+func (r *memoryIncidentRepository) GetVersions(ctx context.Context, id uuid.UUID) ([]*domain.IncidentVersion, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	stored := r.store.versions[id]
+	if len(stored) == 0 {
+		return nil, fmt.Errorf("%w", domain.ErrIncidentNotFound)
+	}
+
+	versions := make([]*domain.IncidentVersion, len(stored))
+	for i, version := range stored {
+		clone := *version
+		clone.Incident = *cloneIncident(&version.Incident)
+		if version.ValidTo != nil {
+			validTo := *version.ValidTo
+			clone.ValidTo = &validTo
+		}
+		versions[i] = &clone
+	}
+
+	return versions, nil
+}
+
+// selectIncidents возвращает копии подходящих инцидентов, новые первыми; match == nil - все
+func (r *memoryIncidentRepository) selectIncidents(match func(*domain.Incident) bool) []*domain.Incident {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var incidents []*domain.Incident
+	for _, incident := range r.store.incidents {
+		if match == nil || match(incident) {
+			incidents = append(incidents, cloneIncident(incident))
+		}
+	}
+	slices.SortFunc(incidents, newestFirst)
+
+	return incidents
+}
+
+// selectVersionsAsOf - как selectIncidents, но по версиям, действовавшим на момент asOf
+func (r *memoryIncidentRepository) selectVersionsAsOf(asOf time.Time, match func(*domain.Incident) bool) []*domain.Incident {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var incidents []*domain.Incident
+	for _, versions := range r.store.versions {
+		for _, version := range versions {
+			if version.ValidFrom.After(asOf) || (version.ValidTo != nil && !version.ValidTo.After(asOf)) {
+				continue
+			}
+			if match == nil || match(&version.Incident) {
+				incidents = append(incidents, cloneIncident(&version.Incident))
+			}
+		}
+	}
+	slices.SortFunc(incidents, newestFirst)
+
+	return incidents
+}
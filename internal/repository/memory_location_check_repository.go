@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"geo-alert-core/internal/domain"
+	"geo-alert-core/internal/geo"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// realization in memory, shares the store with the incident repository
+type memoryLocationCheckRepository struct {
+	store *MemoryStore
+}
+
+// new realization in memory
+func NewMemoryLocationCheckRepository(store *MemoryStore) LocationCheckRepository {
+	return &memoryLocationCheckRepository{store: store}
+}
+
+func (r *memoryLocationCheckRepository) Create(ctx context.Context, check *domain.LocationCheck) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	check.ID = uuid.New()
+	check.CheckedAt = time.Now()
+	check.WebhookSent = false
+
+	stored := *check
+	stored.IncidentIDs = nil
+	r.store.checks = append(r.store.checks, &stored)
+
+	return nil
+}
+
+func (r *memoryLocationCheckRepository) LinkToIncidents(ctx context.Context, checkID uuid.UUID, incidentIDs []uuid.UUID) error {
+	if len(incidentIDs) == 0 {
+		return nil // no incidents to link
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	// same as foreign keys: the check and every incident must exist, nothing is linked otherwise
+	if !slices.ContainsFunc(r.store.checks, func(check *domain.LocationCheck) bool { return check.ID == checkID }) {
+		return fmt.Errorf("failed to link incident: %w", domain.ErrLocationCheckNotFound)
+	}
+	for _, incidentID := range incidentIDs {
+		if _, ok := r.store.incidents[incidentID]; !ok {
+			return fmt.Errorf("failed to link incident: %w", domain.ErrIncidentNotFound)
+		}
+	}
+
+	links := r.store.links[checkID]
+	for _, incidentID := range incidentIDs {
+		if !slices.Contains(links, incidentID) {
+			links = append(links, incidentID)
+		}
+	}
+	slices.SortFunc(links, compareIDs)
+	r.store.links[checkID] = links
+
+	return nil
+}
+
+// last check of user made not later than before
+func (r *memoryLocationCheckRepository) GetLatestByUser(ctx context.Context, userID string, before time.Time) (*domain.LocationCheck, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var latest *domain.LocationCheck
+	for _, check := range r.store.checks {
+		if check.UserID != userID || check.CheckedAt.After(before) {
+			continue
+		}
+		if latest == nil || check.CheckedAt.After(latest.CheckedAt) {
+			latest = check
+		}
+	}
+
+	if latest == nil {
+		return nil, fmt.Errorf("%w", domain.ErrLocationCheckNotFound)
+	}
+
+	check := *latest
+	return &check, nil
+}
+
+// checks for period ordered by user and time; fn is called without holding the lock
+func (r *memoryLocationCheckRepository) ForEach(ctx context.Context, filter *domain.LocationCheckFilter, fn func(*domain.LocationCheck) error) error {
+	r.store.mu.RLock()
+	var checks []*domain.LocationCheck
+	for _, stored := range r.store.sortedChecks() {
+		if stored.CheckedAt.Before(filter.From) || !stored.CheckedAt.Before(filter.To) {
+			continue
+		}
+		if filter.UserID != "" && stored.UserID != filter.UserID {
+			continue
+		}
+		check := *stored
+		check.IncidentIDs = slices.Clone(r.store.links[stored.ID])
+		checks = append(checks, &check)
+	}
+	r.store.mu.RUnlock()
+
+	for _, check := range checks {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(check); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// aggregate checks into geohash or hexagon cells, cells without checks are omitted
+func (r *memoryLocationCheckRepository) Heatmap(ctx context.Context, filter *domain.HeatmapFilter) ([]*domain.HeatmapCell, error) {
+	type cellKey struct {
+		hash string
+		i, j int
+	}
+
+	var cellOf func(check *domain.LocationCheck) cellKey
+	switch filter.Grid {
+	case domain.GridGeohash:
+		cellOf = func(check *domain.LocationCheck) cellKey {
+			return cellKey{hash: geo.Geohash(check.Latitude, check.Longitude, filter.Precision)}
+		}
+	case domain.GridHex:
+		// the grid is built in Web Mercator so cells are regular on the map
+		cellOf = func(check *domain.LocationCheck) cellKey {
+			x, y := geo.ToMercator(check.Latitude, check.Longitude)
+			i, j := geo.HexCell(x, y, filter.CellSize)
+			return cellKey{i: i, j: j}
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown grid %q", domain.ErrInvalidFilter, filter.Grid)
+	}
+
+	onlyHits := filter.OnlyHits || filter.IncidentID != nil
+	box := filter.BBox
+
+	type cellStats struct {
+		checks int
+		users  map[string]bool
+	}
+	stats := map[cellKey]*cellStats{}
+
+	r.store.mu.RLock()
+	for _, check := range r.store.checks {
+		if check.CheckedAt.Before(filter.From) || !check.CheckedAt.Before(filter.To) {
+			continue
+		}
+		if check.Longitude < box.MinLongitude || check.Longitude > box.MaxLongitude ||
+			check.Latitude < box.MinLatitude || check.Latitude > box.MaxLatitude {
+			continue
+		}
+		if onlyHits {
+			links := r.store.links[check.ID]
+			if len(links) == 0 || (filter.IncidentID != nil && !slices.Contains(links, *filter.IncidentID)) {
+				continue
+			}
+		}
+
+		key := cellOf(check)
+		cell := stats[key]
+		if cell == nil {
+			cell = &cellStats{users: map[string]bool{}}
+			stats[key] = cell
+		}
+		cell.checks++
+		cell.users[check.UserID] = true
+	}
+	r.store.mu.RUnlock()
+
+	keys := make([]cellKey, 0, len(stats))
+	for key := range stats {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b cellKey) int {
+		return cmp.Or(cmp.Compare(a.hash, b.hash), cmp.Compare(a.i, b.i), cmp.Compare(a.j, b.j))
+	})
+
+	cells := []*domain.HeatmapCell{}
+	for _, key := range keys {
+		cell := &domain.HeatmapCell{
+			Checks: stats[key].checks,
+			Users:  len(stats[key].users),
+		}
+
+		if filter.Grid == domain.GridGeohash {
+			minLat, minLon, maxLat, maxLon := geo.GeohashBounds(key.hash)
+			cell.Cell = key.hash
+			cell.Latitude = (minLat + maxLat) / 2
+			cell.Longitude = (minLon + maxLon) / 2
+			cell.Polygon = [][2]float64{{minLon, minLat}, {minLon, maxLat}, {maxLon, maxLat}, {maxLon, minLat}, {minLon, minLat}}
+		} else {
+			cell.Cell = strconv.Itoa(key.i) + "," + strconv.Itoa(key.j)
+			cell.Latitude, cell.Longitude = geo.FromMercator(geo.HexCenter(key.i, key.j, filter.CellSize))
+			for _, p := range geo.HexRing(key.i, key.j, filter.CellSize) {
+				lat, lon := geo.FromMercator(p[0], p[1])
+				cell.Polygon = append(cell.Polygon, [2]float64{lon, lat})
+			}
+		}
+
+		cells = append(cells, cell)
+	}
+
+	return cells, nil
+}
+
+// hits of zones with after < checked_at <= until, used by the stats rollup; fn is called without holding the lock
+func (r *memoryLocationCheckRepository) ForEachHit(ctx context.Context, after, until time.Time, fn func(*domain.IncidentHit) error) error {
+	r.store.mu.RLock()
+	var hits []*domain.IncidentHit
+	for _, check := range r.store.checks {
+		if !check.CheckedAt.After(after) || check.CheckedAt.After(until) {
+			continue
+		}
+		for _, incidentID := range r.store.links[check.ID] {
+			hits = append(hits, &domain.IncidentHit{IncidentID: incidentID, UserID: check.UserID, CheckedAt: check.CheckedAt})
+		}
+	}
+	r.store.mu.RUnlock()
+
+	slices.SortStableFunc(hits, func(a, b *domain.IncidentHit) int {
+		return a.CheckedAt.Compare(b.CheckedAt)
+	})
+
+	for _, hit := range hits {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(hit); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
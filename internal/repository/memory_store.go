@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"bytes"
+	"geo-alert-core/internal/domain"
+	"geo-alert-core/internal/geo"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MemoryStore - данные in-memory репозиториев: инциденты, их версии, проверки координат, ключи API
+// и журнал доставки вебхуков.
+// Общий для обоих репозиториев, как общая БД у postgres-реализаций: статистика по зонам
+// считается по проверкам. Данные живут до перезапуска процесса.
+type MemoryStore struct {
+	mu        sync.RWMutex
+	incidents map[uuid.UUID]*domain.Incident
+	versions  map[uuid.UUID][]*domain.IncidentVersion
+	checks    []*domain.LocationCheck
+	// зоны, в которые попала проверка, отсортированы по id
+	links map[uuid.UUID][]uuid.UUID
+	// замененные документы внешних источников -> заменивший документ
+	superseded map[externalKey]string
+	// выданные ключи API в порядке выдачи
+	apiKeys []*storedAPIKey
+	// доставки вебхуков в порядке создания
+	deliveries []*domain.WebhookDelivery
+}
+
+// storedAPIKey - ключ API и хэш, по которому он проверяется
+type storedAPIKey struct {
+	key  domain.APIKey
+	hash string
+}
+
+// externalKey - документ внешнего источника
+type externalKey struct {
+	source     string
+	externalID string
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		incidents:  map[uuid.UUID]*domain.Incident{},
+		versions:   map[uuid.UUID][]*domain.IncidentVersion{},
+		links:      map[uuid.UUID][]uuid.UUID{},
+		superseded: map[externalKey]string{},
+	}
+}
+
+// recordVersion закрывает текущую версию и добавляет новую, как триггер record_incident_version
+func (s *MemoryStore) recordVersion(incident *domain.Incident) {
+	versions := s.versions[incident.ID]
+	if n := len(versions); n > 0 {
+		validTo := incident.UpdatedAt
+		versions[n-1].ValidTo = &validTo
+	}
+
+	s.versions[incident.ID] = append(versions, &domain.IncidentVersion{
+		Version:   len(versions) + 1,
+		ValidFrom: incident.UpdatedAt,
+		Incident:  *cloneIncident(incident),
+	})
+}
+
+// sortedChecks возвращает проверки по пользователю и времени, как ORDER BY user_id, checked_at
+func (s *MemoryStore) sortedChecks() []*domain.LocationCheck {
+	checks := slices.Clone(s.checks)
+	slices.SortStableFunc(checks, func(a, b *domain.LocationCheck) int {
+		if c := strings.Compare(a.UserID, b.UserID); c != 0 {
+			return c
+		}
+		return a.CheckedAt.Compare(b.CheckedAt)
+	})
+	return checks
+}
+
+// cloneIncident копирует инцидент вместе с полигоном и временем действия и удаления,
+// чтобы вызывающий код не менял данные хранилища
+func cloneIncident(incident *domain.Incident) *domain.Incident {
+	clone := *incident
+	clone.Polygon = slices.Clone(incident.Polygon)
+	if incident.EffectiveAt != nil {
+		effectiveAt := *incident.EffectiveAt
+		clone.EffectiveAt = &effectiveAt
+	}
+	if incident.ExpiresAt != nil {
+		expiresAt := *incident.ExpiresAt
+		clone.ExpiresAt = &expiresAt
+	}
+	if incident.DeletedAt != nil {
+		deletedAt := *incident.DeletedAt
+		clone.DeletedAt = &deletedAt
+	}
+	return &clone
+}
+
+// notDeleted - инцидент не помечен удаленным
+func notDeleted(incident *domain.Incident) bool {
+	return incident.DeletedAt == nil
+}
+
+// activeAt - зона включена, не удалена и момент at попадает в период ее действия
+func activeAt(incident *domain.Incident, at time.Time) bool {
+	return incident.IsActive && notDeleted(incident) &&
+		(incident.EffectiveAt == nil || !incident.EffectiveAt.After(at)) &&
+		(incident.ExpiresAt == nil || incident.ExpiresAt.After(at))
+}
+
+// covers - точка внутри круга зоны и, если задан, внутри ее полигона
+func covers(incident *domain.Incident, latitude, longitude float64) bool {
+	if geo.Distance(incident.Latitude, incident.Longitude, latitude, longitude) > incident.Radius {
+		return false
+	}
+	return incident.Polygon == nil || geo.PointInPolygon(latitude, longitude, incident.Polygon)
+}
+
+// newestFirst - порядок ORDER BY created_at DESC, при равенстве по id
+func newestFirst(a, b *domain.Incident) int {
+	if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+		return c
+	}
+	return compareIDs(a.ID, b.ID)
+}
+
+// compareIDs сравнивает uuid так же, как PostgreSQL: побайтно
+func compareIDs(a, b uuid.UUID) int {
+	return bytes.Compare(a[:], b[:])
+}
+
+// page возвращает срез [offset, offset+limit), как LIMIT/OFFSET
+func page[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return nil
+	}
+	items = items[max(offset, 0):]
+	if limit >= 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
@@ -0,0 +1,295 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"geo-alert-core/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMemoryRepositories() (*MemoryStore, IncidentRepository, LocationCheckRepository) {
+	store := NewMemoryStore()
+	return store, NewMemoryIncidentRepository(store), NewMemoryLocationCheckRepository(store)
+}
+
+func createIncident(t *testing.T, repo IncidentRepository, incident *domain.Incident) *domain.Incident {
+	t.Helper()
+	if incident.Severity == "" {
+		incident.Severity = domain.SeverityMedium
+	}
+	incident.IsActive = true
+	require.NoError(t, repo.Create(context.Background(), incident))
+	return incident
+}
+
+// addCheck добавляет проверку с заданным временем в обход Create, который ставит time.Now()
+func addCheck(store *MemoryStore, userID string, lat, lon float64, at time.Time, incidentIDs ...uuid.UUID) {
+	check := &domain.LocationCheck{ID: uuid.New(), UserID: userID, Latitude: lat, Longitude: lon, CheckedAt: at}
+	store.checks = append(store.checks, check)
+	if len(incidentIDs) > 0 {
+		store.links[check.ID] = incidentIDs
+	}
+}
+
+func TestMemoryIncidentRepository_UpdateAndVersions(t *testing.T) {
+	ctx := context.Background()
+	_, repo, _ := newMemoryRepositories()
+
+	incident := createIncident(t, repo, &domain.Incident{Title: "Пожар", Latitude: 55.75, Longitude: 37.61, Radius: 500})
+	assert.Equal(t, 1, incident.Version)
+
+	// изменение возвращенной копии не меняет хранилище
+	stored, err := repo.GetByID(ctx, incident.ID)
+	require.NoError(t, err)
+	stored.Title = "Изменено"
+	again, _ := repo.GetByID(ctx, incident.ID)
+	assert.Equal(t, "Пожар", again.Title)
+
+	require.NoError(t, repo.Update(ctx, incident.ID, stored))
+	assert.Equal(t, 2, stored.Version)
+
+	// устаревшая версия
+	incident.Title = "Другое"
+	assert.ErrorIs(t, repo.Update(ctx, incident.ID, incident), domain.ErrVersionConflict)
+	assert.ErrorIs(t, repo.Update(ctx, uuid.New(), incident), domain.ErrIncidentNotFound)
+
+	require.NoError(t, repo.Delete(ctx, incident.ID))
+	_, err = repo.GetByID(ctx, incident.ID)
+	assert.ErrorIs(t, err, domain.ErrIncidentNotFound)
+
+	versions, err := repo.GetVersions(ctx, incident.ID)
+	require.NoError(t, err)
+	require.Len(t, versions, 3)
+	assert.NotNil(t, versions[2].Incident.DeletedAt)
+	assert.True(t, versions[2].Incident.IsActive)
+	assert.Equal(t, "Пожар", versions[0].Incident.Title)
+	assert.Equal(t, "Изменено", versions[1].Incident.Title)
+	assert.Equal(t, *versions[0].ValidTo, versions[1].ValidFrom)
+	assert.Nil(t, versions[2].ValidTo)
+
+	// на момент первой версии зона была активна и называлась по-старому
+	asOf := versions[0].ValidFrom
+	nearby, err := repo.FindNearbyIncidentsAsOf(ctx, 55.75, 37.61, asOf)
+	require.NoError(t, err)
+	require.Len(t, nearby, 1)
+	assert.Equal(t, "Пожар", nearby[0].Title)
+
+	nearby, err = repo.FindNearbyIncidentsAsOf(ctx, 55.75, 37.61, time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, nearby)
+
+	_, err = repo.GetVersions(ctx, uuid.New())
+	assert.ErrorIs(t, err, domain.ErrIncidentNotFound)
+}
+
+func TestMemoryIncidentRepository_FindNearbyIncidents(t *testing.T) {
+	ctx := context.Background()
+	_, repo, _ := newMemoryRepositories()
+
+	circle := createIncident(t, repo, &domain.Incident{Title: "Круг", Latitude: 55.75, Longitude: 37.61, Radius: 500})
+	square := createIncident(t, repo, &domain.Incident{
+		Title: "Квадрат", Latitude: 55.75, Longitude: 37.61, Radius: 1000,
+		Polygon: [][2]float64{{37.60, 55.745}, {37.62, 55.745}, {37.62, 55.755}, {37.60, 55.755}, {37.60, 55.745}},
+	})
+	future := time.Now().Add(time.Hour)
+	createIncident(t, repo, &domain.Incident{Title: "Позже", Latitude: 55.75, Longitude: 37.61, Radius: 500, EffectiveAt: &future})
+
+	found, err := repo.FindNearbyIncidents(ctx, 55.75, 37.61)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{circle.ID, square.ID}, ids(found))
+
+	// внутри круга квадрата, но вне полигона
+	found, err = repo.FindNearbyIncidents(ctx, 55.758, 37.61)
+	require.NoError(t, err)
+	assert.Empty(t, found)
+
+	active, err := repo.GetActiveIncidents(ctx)
+	require.NoError(t, err)
+	assert.Len(t, active, 2)
+}
+
+func TestMemoryIncidentRepository_ListCursor(t *testing.T) {
+	ctx := context.Background()
+	_, repo, _ := newMemoryRepositories()
+
+	for _, radius := range []float64{300, 100, 200, 100, 500} {
+		createIncident(t, repo, &domain.Incident{Title: "Зона", Description: "Утечка газа", Latitude: 55.75, Longitude: 37.61, Radius: radius})
+	}
+	createIncident(t, repo, &domain.Incident{Title: "Пожар", Latitude: 55.75, Longitude: 37.61, Radius: 50})
+
+	filter := &domain.IncidentFilter{Query: "газ", SortBy: domain.SortByRadius, Limit: 2, IncludeTotal: true}
+	var radii []float64
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 5)
+		result, err := repo.List(ctx, filter)
+		require.NoError(t, err)
+		assert.Equal(t, 5, *result.Total)
+		for _, incident := range result.Items {
+			radii = append(radii, incident.Radius)
+		}
+		if result.NextCursor == nil {
+			break
+		}
+		filter.Cursor = result.NextCursor
+	}
+	assert.Equal(t, []float64{100, 100, 200, 300, 500}, radii)
+
+	_, err := repo.List(ctx, &domain.IncidentFilter{SortBy: domain.SortByDistance, Limit: 10})
+	assert.ErrorIs(t, err, domain.ErrInvalidFilter)
+}
+
+func TestMemoryIncidentRepository_Stats(t *testing.T) {
+	ctx := context.Background()
+	store, repo, _ := newMemoryRepositories()
+
+	zone := createIncident(t, repo, &domain.Incident{Title: "Зона", Latitude: 55.75, Longitude: 37.61, Radius: 500})
+	quiet := createIncident(t, repo, &domain.Incident{Title: "Тихо", Latitude: 10, Longitude: 10, Radius: 500})
+
+	from := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	addCheck(store, "u1", 55.75, 37.61, from.Add(-time.Minute), zone.ID) // до периода: уже внутри
+	addCheck(store, "u1", 55.75, 37.61, from.Add(5*time.Minute), zone.ID)
+	addCheck(store, "u2", 55.75, 37.61, from.Add(10*time.Minute), zone.ID)
+	addCheck(store, "u2", 0, 0, from.Add(70*time.Minute))
+	addCheck(store, "u2", 55.75, 37.61, from.Add(80*time.Minute), zone.ID)
+
+	stats, err := repo.GetStats(ctx, from, from.Add(2*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+	assert.Equal(t, zone.ID, stats[0].ZoneID)
+	assert.Equal(t, 2, stats[0].UserCount)
+	assert.Equal(t, 3, stats[0].CheckCount)
+	assert.Equal(t, quiet.ID, stats[1].ZoneID)
+	assert.Equal(t, 0, stats[1].UserCount)
+
+	points, err := repo.GetTimeSeries(ctx, &domain.TimeSeriesFilter{
+		IncidentID: zone.ID, From: from, To: from.Add(2 * time.Hour), Bucket: domain.BucketHour,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []*domain.TimeSeriesPoint{
+		{Time: from, Users: 2, Checks: 2, Entries: 1},
+		{Time: from.Add(time.Hour), Users: 1, Checks: 1, Entries: 1},
+	}, points)
+}
+
+func TestMemoryLocationCheckRepository(t *testing.T) {
+	ctx := context.Background()
+	store, incidents, checks := newMemoryRepositories()
+
+	zone := createIncident(t, incidents, &domain.Incident{Title: "Зона", Latitude: 55.75, Longitude: 37.61, Radius: 500})
+
+	check := &domain.LocationCheck{UserID: "u1", Latitude: 55.75, Longitude: 37.61}
+	require.NoError(t, checks.Create(ctx, check))
+	require.NoError(t, checks.LinkToIncidents(ctx, check.ID, []uuid.UUID{zone.ID, zone.ID}))
+	assert.ErrorIs(t, checks.LinkToIncidents(ctx, uuid.New(), []uuid.UUID{zone.ID}), domain.ErrLocationCheckNotFound)
+	assert.ErrorIs(t, checks.LinkToIncidents(ctx, check.ID, []uuid.UUID{uuid.New()}), domain.ErrIncidentNotFound)
+
+	latest, err := checks.GetLatestByUser(ctx, "u1", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, check.ID, latest.ID)
+	_, err = checks.GetLatestByUser(ctx, "u1", check.CheckedAt.Add(-time.Second))
+	assert.ErrorIs(t, err, domain.ErrLocationCheckNotFound)
+
+	addCheck(store, "u0", 55.7501, 37.6101, check.CheckedAt.Add(-time.Minute))
+
+	var exported []*domain.LocationCheck
+	err = checks.ForEach(ctx, &domain.LocationCheckFilter{From: check.CheckedAt.Add(-time.Hour), To: time.Now().Add(time.Second)},
+		func(c *domain.LocationCheck) error {
+			exported = append(exported, c)
+			return nil
+		})
+	require.NoError(t, err)
+	require.Len(t, exported, 2)
+	assert.Equal(t, "u0", exported[0].UserID)
+	assert.Equal(t, []uuid.UUID{zone.ID}, exported[1].IncidentIDs)
+
+	var hits []*domain.IncidentHit
+	err = checks.ForEachHit(ctx, check.CheckedAt.Add(-time.Hour), time.Now(), func(hit *domain.IncidentHit) error {
+		hits = append(hits, hit)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	assert.Equal(t, zone.ID, hits[0].IncidentID)
+
+	for _, grid := range []domain.HeatmapFilter{
+		{Grid: domain.GridGeohash, Precision: 5},
+		{Grid: domain.GridHex, CellSize: 5000},
+	} {
+		filter := grid
+		filter.BBox = domain.BoundingBox{MinLongitude: 37, MinLatitude: 55, MaxLongitude: 38, MaxLatitude: 56}
+		filter.From, filter.To = check.CheckedAt.Add(-time.Hour), time.Now().Add(time.Second)
+
+		cells, err := checks.Heatmap(ctx, &filter)
+		require.NoError(t, err)
+		require.Len(t, cells, 1, filter.Grid)
+		assert.Equal(t, 2, cells[0].Checks)
+		assert.Equal(t, 2, cells[0].Users)
+		assert.InDelta(t, 55.75, cells[0].Latitude, 0.05)
+		assert.InDelta(t, 37.61, cells[0].Longitude, 0.05)
+
+		filter.OnlyHits = true
+		cells, err = checks.Heatmap(ctx, &filter)
+		require.NoError(t, err)
+		require.Len(t, cells, 1)
+		assert.Equal(t, 1, cells[0].Checks)
+	}
+}
+
+func TestMemoryIncidentRepository_GetTile(t *testing.T) {
+	ctx := context.Background()
+	_, repo, _ := newMemoryRepositories()
+
+	createIncident(t, repo, &domain.Incident{Title: "Зона", Latitude: 55.75, Longitude: 37.61, Radius: 500})
+
+	// тайл 10/618/320 содержит центр зоны, в соседний 10/619/320 заходит ее край, 10/0/0 пустой
+	for _, x := range []int{618, 619} {
+		tile, err := repo.GetTile(ctx, 10, x, 320)
+		require.NoError(t, err)
+		assert.Contains(t, string(tile), "incidents")
+	}
+
+	tile, err := repo.GetTile(ctx, 10, 0, 0)
+	require.NoError(t, err)
+	assert.Empty(t, tile)
+}
+
+func TestMemoryStore_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	_, incidents, checks := newMemoryRepositories()
+	zone := createIncident(t, incidents, &domain.Incident{Title: "Зона", Latitude: 55.75, Longitude: 37.61, Radius: 500})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			check := &domain.LocationCheck{UserID: "u", Latitude: 55.75, Longitude: 37.61}
+			assert.NoError(t, checks.Create(ctx, check))
+			found, err := incidents.FindNearbyIncidents(ctx, check.Latitude, check.Longitude)
+			assert.NoError(t, err)
+			assert.NoError(t, checks.LinkToIncidents(ctx, check.ID, ids(found)))
+			_, err = incidents.GetStats(ctx, time.Now().Add(-time.Hour), time.Now())
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	stats, err := incidents.GetStats(ctx, time.Now().Add(-time.Hour), time.Now().Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, zone.ID, stats[0].ZoneID)
+	assert.Equal(t, 20, stats[0].CheckCount)
+}
+
+func ids(incidents []*domain.Incident) []uuid.UUID {
+	result := make([]uuid.UUID, len(incidents))
+	for i, incident := range incidents {
+		result[i] = incident.ID
+	}
+	return result
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"geo-alert-core/internal/domain"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// realization in memory, deliveries live in the shared store
+type memoryWebhookDeliveryRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryWebhookDeliveryRepository(store *MemoryStore) WebhookDeliveryRepository {
+	return &memoryWebhookDeliveryRepository{store: store}
+}
+
+func (r *memoryWebhookDeliveryRepository) Create(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delivery.ID = uuid.New()
+	delivery.CreatedAt = time.Now()
+
+	r.store.deliveries = append(r.store.deliveries, cloneWebhookDelivery(delivery))
+	return nil
+}
+
+func (r *memoryWebhookDeliveryRepository) Update(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, stored := range r.store.deliveries {
+		if stored.ID != delivery.ID {
+			continue
+		}
+		updated := cloneWebhookDelivery(delivery)
+		stored.Status = updated.Status
+		stored.Attempts = updated.Attempts
+		stored.LastError = updated.LastError
+		stored.FinishedAt = updated.FinishedAt
+		return nil
+	}
+	return fmt.Errorf("%w", domain.ErrWebhookDeliveryNotFound)
+}
+
+func (r *memoryWebhookDeliveryRepository) List(ctx context.Context, filter *domain.WebhookDeliveryFilter) ([]*domain.WebhookDelivery, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	deliveries := []*domain.WebhookDelivery{}
+	for i := len(r.store.deliveries) - 1; i >= 0 && len(deliveries) < filter.Limit; i-- {
+		delivery := r.store.deliveries[i]
+		if filter.Status == "" || delivery.Status == filter.Status {
+			deliveries = append(deliveries, cloneWebhookDelivery(delivery))
+		}
+	}
+	return deliveries, nil
+}
+
+func (r *memoryWebhookDeliveryRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	kept := r.store.deliveries[:0]
+	for _, delivery := range r.store.deliveries {
+		if !delivery.CreatedAt.Before(before) {
+			kept = append(kept, delivery)
+		}
+	}
+	purged := int64(len(r.store.deliveries) - len(kept))
+	clear(r.store.deliveries[len(kept):])
+	r.store.deliveries = kept
+	return purged, nil
+}
+
+func cloneWebhookDelivery(delivery *domain.WebhookDelivery) *domain.WebhookDelivery {
+	clone := *delivery
+	clone.IncidentIDs = slices.Clone(nonNilStrings(delivery.IncidentIDs))
+	if delivery.FinishedAt != nil {
+		finishedAt := *delivery.FinishedAt
+		clone.FinishedAt = &finishedAt
+	}
+	return &clone
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"geo-alert-core/internal/metrics"
+	"geo-alert-core/internal/tracing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// observeQuery открывает спан запроса к БД, а по завершении пишет его длительность в метрики:
+//
+//	ctx, end := observeQuery(ctx, "incidents", "create")
+//	defer end()
+func observeQuery(ctx context.Context, repository, operation string) (context.Context, func()) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, repository+"."+operation,
+		semconv.DBSystemPostgreSQL,
+		attribute.String("db.operation.name", operation),
+	)
+	return ctx, func() {
+		metrics.ObserveQuery(repository, operation, start)
+		span.End()
+	}
+}
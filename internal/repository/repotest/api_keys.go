@@ -0,0 +1,82 @@
+package repotest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"geo-alert-core/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RunAPIKeyRepository проверяет контракт APIKeyRepository
+func RunAPIKeyRepository(t *testing.T, factory Factory) {
+	ctx := context.Background()
+
+	t.Run("Create and FindByHash", func(t *testing.T) {
+		repo := factory(t).APIKeys
+		hash := strings.Repeat("a", 64)
+
+		before := time.Now()
+		key := &domain.APIKey{Name: "mobile", Prefix: "aaaaaaaa"}
+		require.NoError(t, repo.Create(ctx, key, hash))
+		assert.NotEqual(t, uuid.Nil, key.ID)
+		assert.WithinRange(t, key.CreatedAt, before.Add(-timePrecision), time.Now())
+
+		found, err := repo.FindByHash(ctx, hash)
+		require.NoError(t, err)
+		assert.Equal(t, key.ID, found.ID)
+		assert.Equal(t, "mobile", found.Name)
+		assert.Equal(t, "aaaaaaaa", found.Prefix)
+		assert.Nil(t, found.RevokedAt)
+
+		_, err = repo.FindByHash(ctx, strings.Repeat("b", 64))
+		assert.ErrorIs(t, err, domain.ErrAPIKeyNotFound)
+
+		// хэш уникален
+		assert.Error(t, repo.Create(ctx, &domain.APIKey{Name: "copy", Prefix: "aaaaaaaa"}, hash))
+	})
+
+	t.Run("List and Revoke", func(t *testing.T) {
+		repo := factory(t).APIKeys
+
+		first := &domain.APIKey{Name: "first", Prefix: "11111111"}
+		require.NoError(t, repo.Create(ctx, first, strings.Repeat("1", 64)))
+		time.Sleep(2 * time.Millisecond)
+		second := &domain.APIKey{Name: "second", Prefix: "22222222"}
+		require.NoError(t, repo.Create(ctx, second, strings.Repeat("2", 64)))
+
+		require.NoError(t, repo.Revoke(ctx, first.ID))
+		// повторный отзыв не меняет время отзыва
+		keys, err := repo.List(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, keys[1].RevokedAt)
+		revokedAt := *keys[1].RevokedAt
+		require.NoError(t, repo.Revoke(ctx, first.ID))
+		assert.ErrorIs(t, repo.Revoke(ctx, uuid.New()), domain.ErrAPIKeyNotFound)
+
+		// отозванный ключ не проходит проверку, но остается в списке
+		_, err = repo.FindByHash(ctx, strings.Repeat("1", 64))
+		assert.ErrorIs(t, err, domain.ErrAPIKeyNotFound)
+
+		keys, err = repo.List(ctx)
+		require.NoError(t, err)
+		require.Len(t, keys, 2)
+		assert.Equal(t, second.ID, keys[0].ID)
+		assert.Nil(t, keys[0].RevokedAt)
+		assert.Equal(t, first.ID, keys[1].ID)
+		require.NotNil(t, keys[1].RevokedAt)
+		assert.True(t, revokedAt.Equal(*keys[1].RevokedAt))
+	})
+
+	t.Run("List is empty, not nil", func(t *testing.T) {
+		keys, err := factory(t).APIKeys.List(ctx)
+		require.NoError(t, err)
+		assert.NotNil(t, keys)
+		assert.Empty(t, keys)
+	})
+}
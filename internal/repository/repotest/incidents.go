@@ -0,0 +1,698 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"geo-alert-core/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RunIncidentRepository проверяет контракт IncidentRepository
+func RunIncidentRepository(t *testing.T, factory Factory) {
+	ctx := context.Background()
+
+	t.Run("Create and GetByID", func(t *testing.T) {
+		repo := factory(t).Incidents
+
+		effectiveAt := time.Now().Add(-time.Hour).UTC()
+		incident := newIncident("Пожар", 500)
+		incident.Description = "Горит склад"
+		incident.ExternalID = "alert-1"
+		incident.EffectiveAt = &effectiveAt
+		incident.Urgency = "Immediate"
+		incident.Polygon = [][2]float64{{37.6, 55.74}, {37.62, 55.74}, {37.62, 55.76}, {37.6, 55.76}, {37.6, 55.74}}
+
+		before := time.Now()
+		create(t, repo, incident)
+		assert.NotEqual(t, uuid.Nil, incident.ID)
+		assert.Equal(t, 1, incident.Version)
+		assert.WithinRange(t, incident.CreatedAt, before, time.Now())
+		assert.Equal(t, incident.CreatedAt, incident.UpdatedAt)
+
+		stored, err := repo.GetByID(ctx, incident.ID)
+		require.NoError(t, err)
+		assert.Equal(t, incident.Title, stored.Title)
+		assert.Equal(t, incident.Description, stored.Description)
+		assert.Equal(t, incident.Latitude, stored.Latitude)
+		assert.Equal(t, incident.Longitude, stored.Longitude)
+		assert.Equal(t, incident.Radius, stored.Radius)
+		assert.Equal(t, incident.Polygon, stored.Polygon)
+		assert.Equal(t, incident.Category, stored.Category)
+		assert.Equal(t, incident.Severity, stored.Severity)
+		assert.True(t, stored.IsActive)
+		assert.Equal(t, incident.Source, stored.Source)
+		assert.Equal(t, incident.ExternalID, stored.ExternalID)
+		require.NotNil(t, stored.EffectiveAt)
+		assert.WithinDuration(t, effectiveAt, *stored.EffectiveAt, timePrecision)
+		assert.Nil(t, stored.ExpiresAt)
+		assert.Equal(t, incident.Urgency, stored.Urgency)
+		assert.Equal(t, 1, stored.Version)
+		assert.WithinDuration(t, incident.CreatedAt, stored.CreatedAt, timePrecision)
+
+		// изменение полученной копии не влияет на хранилище
+		stored.Title = "Изменено"
+		again, err := repo.GetByID(ctx, incident.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Пожар", again.Title)
+	})
+
+	t.Run("Create keeps preset ID", func(t *testing.T) {
+		repo := factory(t).Incidents
+
+		id := uuid.New()
+		incident := newIncident("Импорт", 100)
+		incident.ID = id
+		create(t, repo, incident)
+		assert.Equal(t, id, incident.ID)
+
+		// повтор с тем же id - ошибка, а не перезапись
+		duplicate := newIncident("Дубль", 100)
+		duplicate.ID = id
+		assert.Error(t, repo.Create(ctx, duplicate))
+
+		stored, err := repo.GetByID(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, "Импорт", stored.Title)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		repo := factory(t).Incidents
+		missing := uuid.New()
+
+		_, err := repo.GetByID(ctx, missing)
+		assert.ErrorIs(t, err, domain.ErrIncidentNotFound)
+
+		assert.ErrorIs(t, repo.Update(ctx, missing, newIncident("Нет", 100)), domain.ErrIncidentNotFound)
+		assert.ErrorIs(t, repo.Delete(ctx, missing), domain.ErrIncidentNotFound)
+		assert.ErrorIs(t, repo.Restore(ctx, missing), domain.ErrIncidentNotFound)
+
+		_, err = repo.GetVersions(ctx, missing)
+		assert.ErrorIs(t, err, domain.ErrIncidentNotFound)
+	})
+
+	t.Run("Update checks version", func(t *testing.T) {
+		repo := factory(t).Incidents
+		incident := create(t, repo, newIncident("Пожар", 500))
+
+		first, err := repo.GetByID(ctx, incident.ID)
+		require.NoError(t, err)
+		second, err := repo.GetByID(ctx, incident.ID)
+		require.NoError(t, err)
+
+		first.Title = "Пожар потушен"
+		first.Radius = 200
+		require.NoError(t, repo.Update(ctx, incident.ID, first))
+		assert.Equal(t, 2, first.Version)
+		assert.True(t, first.UpdatedAt.After(incident.UpdatedAt))
+
+		// вторая копия прочитана до изменения
+		second.Title = "Другое"
+		assert.ErrorIs(t, repo.Update(ctx, incident.ID, second), domain.ErrVersionConflict)
+
+		stored, err := repo.GetByID(ctx, incident.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Пожар потушен", stored.Title)
+		assert.Equal(t, 200.0, stored.Radius)
+		assert.Equal(t, 2, stored.Version)
+		assert.WithinDuration(t, incident.CreatedAt, stored.CreatedAt, timePrecision)
+	})
+
+	t.Run("Delete marks incident deleted", func(t *testing.T) {
+		repo := factory(t).Incidents
+		incident := create(t, repo, newIncident("Пожар", 500))
+		deactivated := newIncident("Выключена", 500)
+		deactivated.IsActive = false
+		create(t, repo, deactivated)
+
+		require.NoError(t, repo.Delete(ctx, incident.ID))
+
+		// удаленная зона пропадает отовсюду, выключенная остается в списках
+		_, err := repo.GetByID(ctx, incident.ID)
+		assert.ErrorIs(t, err, domain.ErrIncidentNotFound)
+
+		all, err := repo.GetAll(ctx, 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{deactivated.ID}, ids(all))
+
+		page, err := repo.List(ctx, &domain.IncidentFilter{Limit: 10, IncludeTotal: true})
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{deactivated.ID}, ids(page.Items))
+		assert.Equal(t, 1, *page.Total)
+
+		// удаленные зоны видны только отдельным списком
+		page, err = repo.List(ctx, &domain.IncidentFilter{Deleted: true, Limit: 10, IncludeTotal: true})
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{incident.ID}, ids(page.Items))
+		assert.Equal(t, 1, *page.Total)
+		require.NotNil(t, page.Items[0].DeletedAt)
+
+		active, err := repo.GetActiveIncidents(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, active)
+
+		nearby, err := repo.FindNearbyIncidents(ctx, centerLat, centerLon)
+		require.NoError(t, err)
+		assert.Empty(t, nearby)
+
+		stats, err := repo.GetStats(ctx, time.Now().Add(-time.Hour), time.Now())
+		require.NoError(t, err)
+		assert.Empty(t, stats)
+
+		// повторное удаление и изменение удаленной зоны - как для несуществующей
+		assert.ErrorIs(t, repo.Delete(ctx, incident.ID), domain.ErrIncidentNotFound)
+		assert.ErrorIs(t, repo.Update(ctx, incident.ID, incident), domain.ErrIncidentNotFound)
+
+		// история и ключ внешнего источника сохраняются
+		versions, err := repo.GetVersions(ctx, incident.ID)
+		require.NoError(t, err)
+		require.Len(t, versions, 2)
+		require.NotNil(t, versions[1].Incident.DeletedAt)
+		assert.True(t, versions[1].Incident.IsActive)
+		assert.Equal(t, 2, versions[1].Incident.Version)
+	})
+
+	t.Run("Restore", func(t *testing.T) {
+		repo := factory(t).Incidents
+		incident := create(t, repo, newIncident("Пожар", 500))
+		require.NoError(t, repo.Delete(ctx, incident.ID))
+
+		require.NoError(t, repo.Restore(ctx, incident.ID))
+
+		stored, err := repo.GetByID(ctx, incident.ID)
+		require.NoError(t, err)
+		assert.Nil(t, stored.DeletedAt)
+		assert.True(t, stored.IsActive)
+		assert.Equal(t, 3, stored.Version)
+
+		nearby, err := repo.FindNearbyIncidents(ctx, centerLat, centerLon)
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{incident.ID}, ids(nearby))
+
+		// неудаленный инцидент не меняется
+		require.NoError(t, repo.Restore(ctx, incident.ID))
+		stored, err = repo.GetByID(ctx, incident.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 3, stored.Version)
+
+		versions, err := repo.GetVersions(ctx, incident.ID)
+		require.NoError(t, err)
+		assert.Len(t, versions, 3)
+	})
+
+	t.Run("Purge", func(t *testing.T) {
+		repos := factory(t)
+		purged := create(t, repos.Incidents, newIncident("Удалена давно", 500))
+		kept := create(t, repos.Incidents, newIncident("Удалена недавно", 500))
+		alive := create(t, repos.Incidents, newIncident("Действует", 500))
+
+		c := check(t, repos.LocationChecks, "u1", centerLat, centerLon, purged.ID, kept.ID, alive.ID)
+
+		require.NoError(t, repos.Incidents.Delete(ctx, purged.ID))
+		time.Sleep(2 * time.Millisecond)
+		cutoff := time.Now()
+		time.Sleep(2 * time.Millisecond)
+		require.NoError(t, repos.Incidents.Delete(ctx, kept.ID))
+
+		count, err := repos.Incidents.Purge(ctx, cutoff)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+
+		count, err = repos.Incidents.Purge(ctx, cutoff)
+		require.NoError(t, err)
+		assert.Zero(t, count)
+
+		// очищенный инцидент не восстановить, история удалена вместе с ним
+		assert.ErrorIs(t, repos.Incidents.Restore(ctx, purged.ID), domain.ErrIncidentNotFound)
+		_, err = repos.Incidents.GetVersions(ctx, purged.ID)
+		assert.ErrorIs(t, err, domain.ErrIncidentNotFound)
+
+		require.NoError(t, repos.Incidents.Restore(ctx, kept.ID))
+
+		// проверка остается, пропадает только ее связь с очищенной зоной
+		var checks []*domain.LocationCheck
+		require.NoError(t, repos.LocationChecks.ForEach(ctx, &domain.LocationCheckFilter{
+			From: c.CheckedAt.Add(-time.Minute),
+			To:   time.Now().Add(time.Minute),
+		}, func(check *domain.LocationCheck) error {
+			checks = append(checks, check)
+			return nil
+		}))
+		require.Len(t, checks, 1)
+		assert.ElementsMatch(t, []uuid.UUID{kept.ID, alive.ID}, checks[0].IncidentIDs)
+	})
+
+	t.Run("GetAll orders newest first", func(t *testing.T) {
+		repo := factory(t).Incidents
+		first, second, third := newIncident("1", 100), newIncident("2", 100), newIncident("3", 100)
+		createInOrder(t, repo, first, second, third)
+
+		page, err := repo.GetAll(ctx, 2, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{third.ID, second.ID}, ids(page))
+
+		page, err = repo.GetAll(ctx, 2, 2)
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{first.ID}, ids(page))
+
+		page, err = repo.GetAll(ctx, 2, 10)
+		require.NoError(t, err)
+		assert.Empty(t, page)
+	})
+
+	t.Run("FindNearbyIncidents", func(t *testing.T) {
+		repo := factory(t).Incidents
+
+		circle := create(t, repo, newIncident("Круг", 500))
+
+		square := newIncident("Квадрат", 1000)
+		square.Polygon = [][2]float64{{37.6, 55.745}, {37.62, 55.745}, {37.62, 55.755}, {37.6, 55.755}, {37.6, 55.745}}
+		create(t, repo, square)
+
+		future := time.Now().Add(time.Hour)
+		notYet := newIncident("Еще не началась", 500)
+		notYet.EffectiveAt = &future
+		create(t, repo, notYet)
+
+		past := time.Now().Add(-time.Hour)
+		expired := newIncident("Закончилась", 500)
+		expired.ExpiresAt = &past
+		create(t, repo, expired)
+
+		inactive := newIncident("Выключена", 500)
+		inactive.IsActive = false
+		create(t, repo, inactive)
+
+		found, err := repo.FindNearbyIncidents(ctx, centerLat, centerLon)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []uuid.UUID{circle.ID, square.ID}, ids(found))
+
+		// ~440 м к северу: в круге квадрата, но за его полигоном
+		found, err = repo.FindNearbyIncidents(ctx, 55.754, centerLon)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []uuid.UUID{circle.ID, square.ID}, ids(found))
+		found, err = repo.FindNearbyIncidents(ctx, 55.757, centerLon)
+		require.NoError(t, err)
+		assert.Empty(t, found)
+
+		active, err := repo.GetActiveIncidents(ctx)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []uuid.UUID{circle.ID, square.ID}, ids(active))
+	})
+
+	t.Run("List filters and cursor", func(t *testing.T) {
+		repo := factory(t).Incidents
+
+		var gas []*domain.Incident
+		for _, radius := range []float64{300, 100, 200, 100, 500} {
+			incident := newIncident("Утечка газа", radius)
+			incident.Category = "gas"
+			gas = append(gas, incident)
+		}
+		createInOrder(t, repo, gas...)
+
+		other := newIncident("Пожар", 50)
+		other.Severity = domain.SeverityCritical
+		create(t, repo, other)
+
+		filter := &domain.IncidentFilter{Category: "gas", SortBy: domain.SortByRadius, Limit: 2, IncludeTotal: true}
+		var radii []float64
+		seen := map[uuid.UUID]bool{}
+		for pages := 0; ; pages++ {
+			require.Less(t, pages, 5, "cursor does not advance")
+			page, err := repo.List(ctx, filter)
+			require.NoError(t, err)
+			require.NotNil(t, page.Total)
+			assert.Equal(t, 5, *page.Total)
+			for _, incident := range page.Items {
+				assert.False(t, seen[incident.ID], "incident returned twice")
+				seen[incident.ID] = true
+				radii = append(radii, incident.Radius)
+			}
+			if page.NextCursor == nil {
+				break
+			}
+			filter.Cursor = page.NextCursor
+		}
+		assert.Equal(t, []float64{100, 100, 200, 300, 500}, radii)
+
+		page, err := repo.List(ctx, &domain.IncidentFilter{
+			Severities: []string{domain.SeverityCritical},
+			SortBy:     domain.SortByCreatedAt,
+			SortDesc:   true,
+			Limit:      10,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{other.ID}, ids(page.Items))
+		assert.Nil(t, page.Total)
+		assert.Nil(t, page.NextCursor)
+
+		inactive := false
+		page, err = repo.List(ctx, &domain.IncidentFilter{IsActive: &inactive, Limit: 10})
+		require.NoError(t, err)
+		assert.Empty(t, page.Items)
+
+		_, err = repo.List(ctx, &domain.IncidentFilter{SortBy: domain.SortByDistance, Limit: 10})
+		assert.ErrorIs(t, err, domain.ErrInvalidFilter)
+	})
+
+	t.Run("FindByExternalID", func(t *testing.T) {
+		repo := factory(t).Incidents
+
+		var created []*domain.Incident
+		for _, externalID := range []string{"alert-1", "alert-1#2", "alert-10"} {
+			incident := newIncident(externalID, 100)
+			incident.Source = domain.SourceCAP
+			incident.ExternalID = externalID
+			created = append(created, create(t, repo, incident))
+		}
+		manual := newIncident("Вручную", 100)
+		manual.ExternalID = "alert-1"
+		create(t, repo, manual)
+
+		found, err := repo.FindByExternalID(ctx, domain.SourceCAP, "alert-1")
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{created[0].ID, created[1].ID}, ids(found))
+
+		// удаленная зона держит свой ключ до очистки
+		require.NoError(t, repo.Delete(ctx, created[1].ID))
+		found, err = repo.FindByExternalID(ctx, domain.SourceCAP, "alert-1")
+		require.NoError(t, err)
+		require.Equal(t, []uuid.UUID{created[0].ID, created[1].ID}, ids(found))
+		assert.Nil(t, found[0].DeletedAt)
+		assert.NotNil(t, found[1].DeletedAt)
+
+		found, err = repo.FindByExternalID(ctx, domain.SourceCAP, "missing")
+		require.NoError(t, err)
+		assert.Empty(t, found)
+
+		// % и _ в идентификаторе - обычные символы, а не шаблон
+		for _, externalID := range []string{"alert_2#2", "alertX2#2"} {
+			incident := newIncident(externalID, 100)
+			incident.Source = domain.SourceCAP
+			incident.ExternalID = externalID
+			create(t, repo, incident)
+		}
+		found, err = repo.FindByExternalID(ctx, domain.SourceCAP, "alert_2")
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, "alert_2#2", found[0].ExternalID)
+
+		found, err = repo.FindByExternalID(ctx, domain.SourceCAP, "alert%")
+		require.NoError(t, err)
+		assert.Empty(t, found)
+	})
+
+	t.Run("GetFeedEntries", func(t *testing.T) {
+		repo := factory(t).Incidents
+		start := time.Now()
+
+		expiredAt := start.Add(-time.Minute).Truncate(time.Second)
+		longExpiredAt := start.Add(-48 * time.Hour).Truncate(time.Second)
+		fresh := newIncident("Новая", 100)
+		updated := newIncident("Измененная", 100)
+		deactivated := newIncident("Выключенная", 100)
+		deleted := newIncident("Удаленная", 100)
+		expired := newIncident("Истекшая", 100)
+		expired.ExpiresAt = &expiredAt
+		longExpired := newIncident("Давно истекшая", 100)
+		longExpired.ExpiresAt = &longExpiredAt
+		createInOrder(t, repo, fresh, updated, deactivated, deleted, expired, longExpired)
+		firstUpdatedAt := updated.UpdatedAt
+
+		time.Sleep(2 * time.Millisecond)
+		updated.Radius = 200
+		require.NoError(t, repo.Update(ctx, updated.ID, updated))
+		deactivated.IsActive = false
+		require.NoError(t, repo.Update(ctx, deactivated.ID, deactivated))
+		require.NoError(t, repo.Delete(ctx, deleted.ID))
+
+		entries, err := repo.GetFeedEntries(ctx, start.Add(-time.Hour))
+		require.NoError(t, err)
+		byID := make(map[uuid.UUID]*domain.FeedEntry, len(entries))
+		var order []uuid.UUID
+		for _, entry := range entries {
+			byID[entry.Incident.ID] = entry
+			order = append(order, entry.Incident.ID)
+		}
+		// давно истекшая зона уже не попадает в ленту
+		assert.Equal(t, []uuid.UUID{expired.ID, deleted.ID, deactivated.ID, updated.ID, fresh.ID}, order)
+
+		assert.Nil(t, byID[fresh.ID].Previous)
+		assert.Nil(t, byID[expired.ID].Previous)
+
+		prev := byID[updated.ID].Previous
+		require.NotNil(t, prev)
+		assert.Equal(t, 1, prev.Version)
+		assert.True(t, prev.Published)
+		assert.WithinDuration(t, firstUpdatedAt, prev.UpdatedAt, timePrecision)
+		assert.Equal(t, 2, byID[updated.ID].Incident.Version)
+
+		assert.False(t, byID[deactivated.ID].Incident.IsActive)
+		require.NotNil(t, byID[deactivated.ID].Previous)
+		assert.True(t, byID[deactivated.ID].Previous.Published)
+
+		assert.NotNil(t, byID[deleted.ID].Incident.DeletedAt)
+		require.NotNil(t, byID[deleted.ID].Previous)
+		assert.True(t, byID[deleted.ID].Previous.Published)
+
+		// снятые раньше endedSince не возвращаются, действующие - всегда
+		entries, err = repo.GetFeedEntries(ctx, time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		order = order[:0]
+		for _, entry := range entries {
+			order = append(order, entry.Incident.ID)
+		}
+		assert.Equal(t, []uuid.UUID{updated.ID, fresh.ID}, order)
+	})
+
+	t.Run("MarkSuperseded", func(t *testing.T) {
+		repo := factory(t).Incidents
+
+		supersededBy, err := repo.SupersededBy(ctx, domain.SourceCAP, "alert-1")
+		require.NoError(t, err)
+		assert.Empty(t, supersededBy)
+
+		require.NoError(t, repo.MarkSuperseded(ctx, domain.SourceCAP, "alert-1", "alert-2"))
+		// повторная пометка не меняет первую запись
+		require.NoError(t, repo.MarkSuperseded(ctx, domain.SourceCAP, "alert-1", "alert-3"))
+
+		supersededBy, err = repo.SupersededBy(ctx, domain.SourceCAP, "alert-1")
+		require.NoError(t, err)
+		assert.Equal(t, "alert-2", supersededBy)
+
+		supersededBy, err = repo.SupersededBy(ctx, domain.SourceManual, "alert-1")
+		require.NoError(t, err)
+		assert.Empty(t, supersededBy)
+	})
+
+	t.Run("versions and point-in-time queries", func(t *testing.T) {
+		repo := factory(t).Incidents
+		incident := create(t, repo, newIncident("Пожар", 500))
+
+		incident.Title = "Пожар потушен"
+		time.Sleep(2 * time.Millisecond)
+		require.NoError(t, repo.Update(ctx, incident.ID, incident))
+		time.Sleep(2 * time.Millisecond)
+		require.NoError(t, repo.Delete(ctx, incident.ID))
+
+		versions, err := repo.GetVersions(ctx, incident.ID)
+		require.NoError(t, err)
+		require.Len(t, versions, 3)
+		for i, version := range versions {
+			assert.Equal(t, i+1, version.Version)
+			assert.Equal(t, i+1, version.Incident.Version)
+		}
+		assert.Equal(t, "Пожар", versions[0].Incident.Title)
+		assert.Equal(t, "Пожар потушен", versions[1].Incident.Title)
+		assert.NotNil(t, versions[2].Incident.DeletedAt)
+		require.NotNil(t, versions[0].ValidTo)
+		assert.True(t, versions[0].ValidTo.Equal(versions[1].ValidFrom))
+		assert.Nil(t, versions[2].ValidTo)
+
+		// на момент первой версии зона действовала под старым названием
+		asOf := versions[0].ValidFrom
+		all, err := repo.GetAllAsOf(ctx, asOf, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, all, 1)
+		assert.Equal(t, "Пожар", all[0].Title)
+
+		nearby, err := repo.FindNearbyIncidentsAsOf(ctx, centerLat, centerLon, asOf)
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{incident.ID}, ids(nearby))
+
+		// тот же момент с другим смещением (as_of=...+14:00 или ...-10:00) - та же версия
+		for _, zone := range []*time.Location{time.FixedZone("UTC+14", 14*60*60), time.FixedZone("UTC-10", -10*60*60)} {
+			all, err = repo.GetAllAsOf(ctx, asOf.In(zone), 10, 0)
+			require.NoError(t, err)
+			require.Len(t, all, 1)
+			assert.Equal(t, "Пожар", all[0].Title)
+
+			nearby, err = repo.FindNearbyIncidentsAsOf(ctx, centerLat, centerLon, asOf.In(zone))
+			require.NoError(t, err)
+			assert.Equal(t, []uuid.UUID{incident.ID}, ids(nearby))
+		}
+
+		nearby, err = repo.FindNearbyIncidentsAsOf(ctx, centerLat, centerLon, time.Now())
+		require.NoError(t, err)
+		assert.Empty(t, nearby)
+
+		all, err = repo.GetAllAsOf(ctx, asOf.Add(-time.Hour), 10, 0)
+		require.NoError(t, err)
+		assert.Empty(t, all)
+	})
+
+	t.Run("GetStats counts checks inside the window", func(t *testing.T) {
+		repos := factory(t)
+		zone := create(t, repos.Incidents, newIncident("Зона", 500))
+		quiet := create(t, repos.Incidents, newIncident("Тихая зона", 500))
+		inactive := newIncident("Выключена", 500)
+		inactive.IsActive = false
+		create(t, repos.Incidents, inactive)
+		// вне периода действия зоны в статистику не попадают
+		expired := newIncident("Истекла", 500)
+		expiredAt := time.Now().Add(-time.Hour)
+		expired.ExpiresAt = &expiredAt
+		create(t, repos.Incidents, expired)
+		future := newIncident("Еще не действует", 500)
+		effectiveAt := time.Now().Add(time.Hour)
+		future.EffectiveAt = &effectiveAt
+		create(t, repos.Incidents, future)
+
+		start := time.Now()
+		check(t, repos.LocationChecks, "u1", centerLat, centerLon, zone.ID)
+		check(t, repos.LocationChecks, "u1", centerLat, centerLon, zone.ID)
+		check(t, repos.LocationChecks, "u2", centerLat, centerLon, zone.ID, inactive.ID)
+		check(t, repos.LocationChecks, "u3", 10, 10)
+
+		stats, err := repos.Incidents.GetStats(ctx, start.Add(-time.Minute), time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		byZone := map[uuid.UUID]*domain.IncidentStats{}
+		for _, stat := range stats {
+			byZone[stat.ZoneID] = stat
+		}
+		require.Len(t, byZone, 2, "only active zones are reported")
+		assert.Equal(t, zone.ID, stats[0].ZoneID, "sorted by user count")
+		assert.Equal(t, 2, byZone[zone.ID].UserCount)
+		assert.Equal(t, 3, byZone[zone.ID].CheckCount)
+		assert.Equal(t, "Зона", byZone[zone.ID].Title)
+		assert.Equal(t, 0, byZone[quiet.ID].UserCount)
+		assert.Equal(t, 0, byZone[quiet.ID].CheckCount)
+
+		// окно [from, to) без проверок
+		stats, err = repos.Incidents.GetStats(ctx, time.Now().Add(time.Hour), time.Now().Add(2*time.Hour))
+		require.NoError(t, err)
+		require.Len(t, stats, 2)
+		for _, stat := range stats {
+			assert.Zero(t, stat.UserCount)
+			assert.Zero(t, stat.CheckCount)
+		}
+	})
+
+	t.Run("GetStats with a non-UTC period", func(t *testing.T) {
+		repos := factory(t)
+		zone := create(t, repos.Incidents, newIncident("Зона", 500))
+
+		start := time.Now()
+		check(t, repos.LocationChecks, "u1", centerLat, centerLon, zone.ID)
+
+		// тот же момент с другим смещением - тот же период, время не сдвигается на пояс
+		far := time.FixedZone("UTC+14", 14*60*60)
+		stats, err := repos.Incidents.GetStats(ctx, start.Add(-time.Minute).In(far), time.Now().Add(time.Minute).In(far))
+		require.NoError(t, err)
+		require.Len(t, stats, 1)
+		assert.Equal(t, 1, stats[0].CheckCount)
+	})
+
+	t.Run("GetTimeSeries", func(t *testing.T) {
+		repos := factory(t)
+		zone := create(t, repos.Incidents, newIncident("Зона", 500))
+
+		start := time.Now()
+		check(t, repos.LocationChecks, "u1", centerLat, centerLon, zone.ID)
+		check(t, repos.LocationChecks, "u1", centerLat, centerLon, zone.ID)
+		check(t, repos.LocationChecks, "u1", 10, 10)
+		check(t, repos.LocationChecks, "u1", centerLat, centerLon, zone.ID)
+		check(t, repos.LocationChecks, "u2", centerLat, centerLon, zone.ID)
+		check(t, repos.LocationChecks, "u3", 10, 10)
+
+		points, err := repos.Incidents.GetTimeSeries(ctx, &domain.TimeSeriesFilter{
+			IncidentID: zone.ID,
+			From:       start.Add(-24 * time.Hour),
+			To:         time.Now().Add(24 * time.Hour),
+			Bucket:     domain.BucketDay,
+		})
+		require.NoError(t, err)
+
+		// проверки могли попасть в соседние сутки, если тест идет в полночь UTC
+		var total domain.TimeSeriesPoint
+		for _, point := range points {
+			assert.Equal(t, point.Time.UTC().Truncate(24*time.Hour), point.Time)
+			total.Checks += point.Checks
+			total.Entries += point.Entries
+		}
+		assert.Equal(t, 4, total.Checks)
+		assert.Equal(t, 3, total.Entries, "u1 enters twice, u2 once")
+		if len(points) == 1 {
+			assert.Equal(t, 2, points[0].Users)
+		}
+
+		points, err = repos.Incidents.GetTimeSeries(ctx, &domain.TimeSeriesFilter{
+			IncidentID: uuid.New(),
+			From:       start.Add(-time.Hour),
+			To:         time.Now().Add(time.Hour),
+			Bucket:     domain.BucketHour,
+		})
+		require.NoError(t, err)
+		assert.Empty(t, points)
+	})
+
+	t.Run("GetTile", func(t *testing.T) {
+		repo := factory(t).Incidents
+		create(t, repo, newIncident("Зона", 500))
+
+		// тайл 10/618/320 содержит центр зоны
+		tile, err := repo.GetTile(ctx, 10, 618, 320)
+		require.NoError(t, err)
+		assert.NotEmpty(t, tile)
+
+		tile, err = repo.GetTile(ctx, 10, 0, 0)
+		require.NoError(t, err)
+		assert.Empty(t, tile)
+	})
+
+	t.Run("GetTile low zoom", func(t *testing.T) {
+		// тайлы z/x/y, в которых зона должна быть (true) или отсутствовать (false)
+		cases := []struct {
+			name     string
+			lat, lon float64
+			tiles    map[[3]int]bool
+		}{
+			{"anywhere on z=0", 60, 170, map[[3]int]bool{{0, 0, 0}: true, {1, 1, 0}: true, {1, 0, 0}: false}},
+			{"southern hemisphere", -33.86, -70.65, map[[3]int]bool{{0, 0, 0}: true, {1, 0, 1}: true, {1, 0, 0}: false, {1, 1, 1}: false}},
+			// зона пересекает границу тайлов z=1 и попадает в оба
+			{"prime meridian", 45, 0.002, map[[3]int]bool{{1, 0, 0}: true, {1, 1, 0}: true, {1, 0, 1}: false, {1, 1, 1}: false}},
+			{"equator", 0.002, 90, map[[3]int]bool{{1, 1, 0}: true, {1, 1, 1}: true, {1, 0, 0}: false, {1, 0, 1}: false}},
+		}
+
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				repo := factory(t).Incidents
+				// на малых зумах пиксель - километры, маленькая зона схлопывается и в тайл не попадает
+				zone := newIncident("Зона", 50000)
+				zone.Latitude, zone.Longitude = tc.lat, tc.lon
+				create(t, repo, zone)
+
+				for tile, want := range tc.tiles {
+					data, err := repo.GetTile(ctx, tile[0], tile[1], tile[2])
+					require.NoError(t, err)
+					assert.Equal(t, want, len(data) > 0, "tile %d/%d/%d", tile[0], tile[1], tile[2])
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,236 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"geo-alert-core/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RunLocationCheckRepository проверяет контракт LocationCheckRepository
+func RunLocationCheckRepository(t *testing.T, factory Factory) {
+	ctx := context.Background()
+
+	t.Run("Create and GetLatestByUser", func(t *testing.T) {
+		repo := factory(t).LocationChecks
+
+		_, err := repo.GetLatestByUser(ctx, "u1", time.Now())
+		assert.ErrorIs(t, err, domain.ErrLocationCheckNotFound)
+
+		before := time.Now()
+		first := check(t, repo, "u1", centerLat, centerLon)
+		assert.NotEqual(t, uuid.Nil, first.ID)
+		assert.WithinRange(t, first.CheckedAt, before, time.Now())
+		assert.False(t, first.WebhookSent)
+
+		second := check(t, repo, "u1", 55.76, 37.62)
+		check(t, repo, "u2", centerLat, centerLon)
+
+		latest, err := repo.GetLatestByUser(ctx, "u1", time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, second.ID, latest.ID)
+		assert.Equal(t, 55.76, latest.Latitude)
+		assert.Equal(t, 37.62, latest.Longitude)
+
+		// before включительно: проверка ровно в этот момент учитывается
+		latest, err = repo.GetLatestByUser(ctx, "u1", first.CheckedAt)
+		require.NoError(t, err)
+		assert.Equal(t, first.ID, latest.ID)
+
+		// момент с другим смещением - тот же момент
+		latest, err = repo.GetLatestByUser(ctx, "u1", first.CheckedAt.In(time.FixedZone("UTC+14", 14*60*60)))
+		require.NoError(t, err)
+		assert.Equal(t, first.ID, latest.ID)
+
+		_, err = repo.GetLatestByUser(ctx, "u1", before.Add(-time.Second))
+		assert.ErrorIs(t, err, domain.ErrLocationCheckNotFound)
+	})
+
+	t.Run("LinkToIncidents", func(t *testing.T) {
+		repos := factory(t)
+		first := create(t, repos.Incidents, newIncident("Первая", 500))
+		second := create(t, repos.Incidents, newIncident("Вторая", 500))
+
+		c := check(t, repos.LocationChecks, "u1", centerLat, centerLon, first.ID)
+
+		// повторная связь не дублируется, пустой список ничего не меняет
+		require.NoError(t, repos.LocationChecks.LinkToIncidents(ctx, c.ID, []uuid.UUID{first.ID, second.ID}))
+		require.NoError(t, repos.LocationChecks.LinkToIncidents(ctx, c.ID, nil))
+
+		// связь с несуществующей зоной отклоняется целиком
+		assert.Error(t, repos.LocationChecks.LinkToIncidents(ctx, c.ID, []uuid.UUID{uuid.New()}))
+		assert.Error(t, repos.LocationChecks.LinkToIncidents(ctx, uuid.New(), []uuid.UUID{first.ID}))
+
+		var checks []*domain.LocationCheck
+		err := repos.LocationChecks.ForEach(ctx, &domain.LocationCheckFilter{
+			From: c.CheckedAt.Add(-time.Minute),
+			To:   time.Now().Add(time.Minute),
+		}, func(check *domain.LocationCheck) error {
+			checks = append(checks, check)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Len(t, checks, 1)
+		assert.ElementsMatch(t, []uuid.UUID{first.ID, second.ID}, checks[0].IncidentIDs)
+	})
+
+	t.Run("ForEach", func(t *testing.T) {
+		repos := factory(t)
+		zone := create(t, repos.Incidents, newIncident("Зона", 500))
+
+		start := time.Now()
+		b1 := check(t, repos.LocationChecks, "b", centerLat, centerLon, zone.ID)
+		a1 := check(t, repos.LocationChecks, "a", 10, 10)
+		b2 := check(t, repos.LocationChecks, "b", 10, 10)
+		a2 := check(t, repos.LocationChecks, "a", centerLat, centerLon, zone.ID)
+
+		collect := func(filter *domain.LocationCheckFilter) []*domain.LocationCheck {
+			t.Helper()
+			var checks []*domain.LocationCheck
+			require.NoError(t, repos.LocationChecks.ForEach(ctx, filter, func(check *domain.LocationCheck) error {
+				checks = append(checks, check)
+				return nil
+			}))
+			return checks
+		}
+		checkIDs := func(checks []*domain.LocationCheck) []uuid.UUID {
+			result := make([]uuid.UUID, len(checks))
+			for i, check := range checks {
+				result[i] = check.ID
+			}
+			return result
+		}
+
+		// сначала по пользователю, затем по времени
+		all := collect(&domain.LocationCheckFilter{From: start, To: time.Now().Add(time.Minute)})
+		assert.Equal(t, []uuid.UUID{a1.ID, a2.ID, b1.ID, b2.ID}, checkIDs(all))
+		assert.Empty(t, all[0].IncidentIDs)
+		assert.Equal(t, []uuid.UUID{zone.ID}, all[1].IncidentIDs)
+
+		assert.Equal(t, []uuid.UUID{b1.ID, b2.ID},
+			checkIDs(collect(&domain.LocationCheckFilter{UserID: "b", From: start, To: time.Now().Add(time.Minute)})))
+
+		// окно [From, To)
+		assert.Equal(t, []uuid.UUID{a1.ID, b2.ID},
+			checkIDs(collect(&domain.LocationCheckFilter{From: a1.CheckedAt, To: a2.CheckedAt})))
+
+		stop := errors.New("stop")
+		calls := 0
+		err := repos.LocationChecks.ForEach(ctx, &domain.LocationCheckFilter{From: start, To: time.Now().Add(time.Minute)},
+			func(*domain.LocationCheck) error {
+				calls++
+				return stop
+			})
+		assert.ErrorIs(t, err, stop)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("ForEachHit", func(t *testing.T) {
+		repos := factory(t)
+		first := create(t, repos.Incidents, newIncident("Первая", 500))
+		second := create(t, repos.Incidents, newIncident("Вторая", 500))
+
+		c1 := check(t, repos.LocationChecks, "u1", centerLat, centerLon, first.ID, second.ID)
+		check(t, repos.LocationChecks, "u2", 10, 10)
+		c3 := check(t, repos.LocationChecks, "u2", centerLat, centerLon, first.ID)
+
+		collect := func(after, until time.Time) []*domain.IncidentHit {
+			t.Helper()
+			var hits []*domain.IncidentHit
+			require.NoError(t, repos.LocationChecks.ForEachHit(ctx, after, until, func(hit *domain.IncidentHit) error {
+				hits = append(hits, hit)
+				return nil
+			}))
+			return hits
+		}
+
+		hits := collect(c1.CheckedAt.Add(-time.Second), time.Now())
+		require.Len(t, hits, 3)
+		assert.ElementsMatch(t, []uuid.UUID{first.ID, second.ID}, []uuid.UUID{hits[0].IncidentID, hits[1].IncidentID})
+		assert.Equal(t, "u1", hits[0].UserID)
+		assert.Equal(t, first.ID, hits[2].IncidentID)
+		assert.Equal(t, "u2", hits[2].UserID)
+		assert.WithinDuration(t, c3.CheckedAt, hits[2].CheckedAt, timePrecision)
+
+		// окно (after, until]: проверка ровно в after не повторяется, ровно в until - учитывается
+		hits = collect(c1.CheckedAt, c3.CheckedAt)
+		require.Len(t, hits, 1)
+		assert.Equal(t, "u2", hits[0].UserID)
+
+		assert.Empty(t, collect(c3.CheckedAt, time.Now()))
+	})
+
+	t.Run("Heatmap", func(t *testing.T) {
+		repos := factory(t)
+		zone := create(t, repos.Incidents, newIncident("Зона", 500))
+		other := create(t, repos.Incidents, newIncident("Другая", 500))
+
+		start := time.Now()
+		check(t, repos.LocationChecks, "u1", centerLat, centerLon, zone.ID)
+		check(t, repos.LocationChecks, "u1", centerLat, centerLon, zone.ID)
+		check(t, repos.LocationChecks, "u2", centerLat, centerLon, other.ID)
+		check(t, repos.LocationChecks, "u3", centerLat, centerLon)
+		check(t, repos.LocationChecks, "u4", 10, 10)
+
+		filter := &domain.HeatmapFilter{
+			Grid:      domain.GridGeohash,
+			Precision: 5,
+			BBox:      domain.BoundingBox{MinLongitude: 37, MinLatitude: 55, MaxLongitude: 38, MaxLatitude: 56},
+			From:      start,
+			To:        time.Now().Add(time.Minute),
+		}
+
+		cells, err := repos.LocationChecks.Heatmap(ctx, filter)
+		require.NoError(t, err)
+		require.Len(t, cells, 1)
+		assert.Equal(t, "ucftp", cells[0].Cell)
+		assert.Equal(t, 4, cells[0].Checks)
+		assert.Equal(t, 3, cells[0].Users)
+		assert.Len(t, cells[0].Polygon, 5)
+		assert.InDelta(t, centerLat, cells[0].Latitude, 0.05)
+		assert.InDelta(t, centerLon, cells[0].Longitude, 0.05)
+
+		filter.OnlyHits = true
+		cells, err = repos.LocationChecks.Heatmap(ctx, filter)
+		require.NoError(t, err)
+		require.Len(t, cells, 1)
+		assert.Equal(t, 3, cells[0].Checks)
+		assert.Equal(t, 2, cells[0].Users)
+
+		filter.IncidentID = &zone.ID
+		cells, err = repos.LocationChecks.Heatmap(ctx, filter)
+		require.NoError(t, err)
+		require.Len(t, cells, 1)
+		assert.Equal(t, 2, cells[0].Checks)
+		assert.Equal(t, 1, cells[0].Users)
+
+		filter.From = time.Now().Add(time.Hour)
+		filter.To = time.Now().Add(2 * time.Hour)
+		cells, err = repos.LocationChecks.Heatmap(ctx, filter)
+		require.NoError(t, err)
+		assert.Empty(t, cells)
+
+		hex := &domain.HeatmapFilter{
+			Grid:     domain.GridHex,
+			CellSize: 1000,
+			BBox:     domain.BoundingBox{MinLongitude: -180, MinLatitude: -85, MaxLongitude: 180, MaxLatitude: 85},
+			From:     start,
+			To:       time.Now().Add(time.Minute),
+		}
+		cells, err = repos.LocationChecks.Heatmap(ctx, hex)
+		require.NoError(t, err)
+		require.Len(t, cells, 2)
+		total := 0
+		for _, cell := range cells {
+			total += cell.Checks
+			assert.Len(t, cell.Polygon, 7)
+		}
+		assert.Equal(t, 5, total)
+	})
+}
@@ -0,0 +1,108 @@
+// Package repotest - контрактные тесты репозиториев. Одни и те же проверки запускаются
+// для каждого хранилища, поэтому поведение, на которое рассчитывают сервисы (мягкое
+// удаление, ошибки "не найдено", окно статистики, ...), одинаково в памяти и в PostgreSQL.
+//
+// Для нового хранилища достаточно вызвать Run из его теста:
+//
+//	repotest.Run(t, func(t *testing.T) repotest.Repositories { ... })
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"geo-alert-core/internal/domain"
+	"geo-alert-core/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// Repositories - репозитории одного хранилища, работающие с общими данными
+type Repositories struct {
+	Incidents         repository.IncidentRepository
+	LocationChecks    repository.LocationCheckRepository
+	APIKeys           repository.APIKeyRepository
+	WebhookDeliveries repository.WebhookDeliveryRepository
+}
+
+// Factory возвращает репозитории над пустым хранилищем. Вызывается для каждого подтеста,
+// подтесты между собой данные не делят.
+type Factory func(t *testing.T) Repositories
+
+// Run проверяет контракт всех репозиториев хранилища
+func Run(t *testing.T, factory Factory) {
+	t.Run("IncidentRepository", func(t *testing.T) {
+		RunIncidentRepository(t, factory)
+	})
+	t.Run("LocationCheckRepository", func(t *testing.T) {
+		RunLocationCheckRepository(t, factory)
+	})
+	t.Run("APIKeyRepository", func(t *testing.T) {
+		RunAPIKeyRepository(t, factory)
+	})
+	t.Run("WebhookDeliveryRepository", func(t *testing.T) {
+		RunWebhookDeliveryRepository(t, factory)
+	})
+}
+
+// Координаты в тестах - с точностью, которую хранят колонки DECIMAL в PostgreSQL
+const (
+	centerLat = 55.75
+	centerLon = 37.61
+)
+
+// timePrecision - PostgreSQL хранит время с точностью до микросекунды
+const timePrecision = time.Microsecond
+
+// newIncident - действующая круглая зона в центре
+func newIncident(title string, radius float64) *domain.Incident {
+	return &domain.Incident{
+		Title:     title,
+		Latitude:  centerLat,
+		Longitude: centerLon,
+		Radius:    radius,
+		Category:  "fire",
+		Severity:  domain.SeverityMedium,
+		IsActive:  true,
+		Source:    domain.SourceManual,
+	}
+}
+
+func create(t *testing.T, repo repository.IncidentRepository, incident *domain.Incident) *domain.Incident {
+	t.Helper()
+	require.NoError(t, repo.Create(context.Background(), incident))
+	return incident
+}
+
+// createInOrder создает инциденты с заметным интервалом, чтобы порядок по created_at был однозначным
+func createInOrder(t *testing.T, repo repository.IncidentRepository, incidents ...*domain.Incident) {
+	t.Helper()
+	for _, incident := range incidents {
+		create(t, repo, incident)
+		time.Sleep(2 * time.Millisecond)
+	}
+}
+
+// check создает проверку и связывает ее с зонами, как LocationService
+func check(t *testing.T, repo repository.LocationCheckRepository, userID string, lat, lon float64, incidentIDs ...uuid.UUID) *domain.LocationCheck {
+	t.Helper()
+	ctx := context.Background()
+
+	c := &domain.LocationCheck{UserID: userID, Latitude: lat, Longitude: lon}
+	require.NoError(t, repo.Create(ctx, c))
+	require.NoError(t, repo.LinkToIncidents(ctx, c.ID, incidentIDs))
+
+	// следующая проверка получит заметно более позднее время
+	time.Sleep(2 * time.Millisecond)
+	return c
+}
+
+func ids(incidents []*domain.Incident) []uuid.UUID {
+	result := make([]uuid.UUID, len(incidents))
+	for i, incident := range incidents {
+		result[i] = incident.ID
+	}
+	return result
+}
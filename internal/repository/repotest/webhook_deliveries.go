@@ -0,0 +1,113 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"geo-alert-core/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RunWebhookDeliveryRepository проверяет контракт WebhookDeliveryRepository
+func RunWebhookDeliveryRepository(t *testing.T, factory Factory) {
+	ctx := context.Background()
+
+	t.Run("Create and Update", func(t *testing.T) {
+		repo := factory(t).WebhookDeliveries
+
+		before := time.Now()
+		delivery := newDelivery("user1", "a")
+		require.NoError(t, repo.Create(ctx, delivery))
+		assert.NotEqual(t, uuid.Nil, delivery.ID)
+		assert.WithinRange(t, delivery.CreatedAt, before.Add(-timePrecision), time.Now())
+
+		finishedAt := time.Now().Truncate(timePrecision)
+		delivery.Status = domain.DeliveryFailed
+		delivery.Attempts = 3
+		delivery.LastError = "webhook returned status 500"
+		delivery.FinishedAt = &finishedAt
+		require.NoError(t, repo.Update(ctx, delivery))
+
+		deliveries, err := repo.List(ctx, &domain.WebhookDeliveryFilter{Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		found := deliveries[0]
+		assert.Equal(t, delivery.ID, found.ID)
+		assert.Equal(t, "user1", found.UserID)
+		assert.Equal(t, []string{"a"}, found.IncidentIDs)
+		assert.Equal(t, "http://receiver/webhook", found.URL)
+		assert.Equal(t, domain.DeliveryFailed, found.Status)
+		assert.Equal(t, 3, found.Attempts)
+		assert.Equal(t, "webhook returned status 500", found.LastError)
+		require.NotNil(t, found.FinishedAt)
+		assert.True(t, finishedAt.Equal(*found.FinishedAt))
+
+		unknown := newDelivery("user1")
+		unknown.ID = uuid.New()
+		assert.ErrorIs(t, repo.Update(ctx, unknown), domain.ErrWebhookDeliveryNotFound)
+	})
+
+	t.Run("List filters by status and limit", func(t *testing.T) {
+		repo := factory(t).WebhookDeliveries
+
+		var created []*domain.WebhookDelivery
+		for _, status := range []string{domain.DeliveryDelivered, domain.DeliveryFailed, domain.DeliveryDelivered} {
+			delivery := newDelivery("user1")
+			delivery.Status = status
+			require.NoError(t, repo.Create(ctx, delivery))
+			created = append(created, delivery)
+			time.Sleep(2 * time.Millisecond)
+		}
+
+		deliveries, err := repo.List(ctx, &domain.WebhookDeliveryFilter{Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, deliveries, 3)
+		assert.Equal(t, created[2].ID, deliveries[0].ID)
+		assert.Equal(t, created[0].ID, deliveries[2].ID)
+
+		deliveries, err = repo.List(ctx, &domain.WebhookDeliveryFilter{Status: domain.DeliveryDelivered, Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, created[2].ID, deliveries[0].ID)
+
+		deliveries, err = repo.List(ctx, &domain.WebhookDeliveryFilter{Status: domain.DeliveryPending, Limit: 10})
+		require.NoError(t, err)
+		assert.NotNil(t, deliveries)
+		assert.Empty(t, deliveries)
+	})
+
+	t.Run("Purge", func(t *testing.T) {
+		repo := factory(t).WebhookDeliveries
+
+		old := newDelivery("user1")
+		require.NoError(t, repo.Create(ctx, old))
+		time.Sleep(2 * time.Millisecond)
+		cutoff := time.Now()
+		time.Sleep(2 * time.Millisecond)
+		fresh := newDelivery("user2")
+		require.NoError(t, repo.Create(ctx, fresh))
+
+		purged, err := repo.Purge(ctx, cutoff)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), purged)
+
+		deliveries, err := repo.List(ctx, &domain.WebhookDeliveryFilter{Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, fresh.ID, deliveries[0].ID)
+	})
+}
+
+// newDelivery - доставка в процессе отправки
+func newDelivery(userID string, incidentIDs ...string) *domain.WebhookDelivery {
+	return &domain.WebhookDelivery{
+		UserID:      userID,
+		IncidentIDs: incidentIDs,
+		URL:         "http://receiver/webhook",
+		Status:      domain.DeliveryPending,
+	}
+}
@@ -0,0 +1,22 @@
+package repository
+
+import "time"
+
+// Колонки времени в PostgreSQL - TIMESTAMP без пояса, в них хранится время UTC.
+// При записи в TIMESTAMP Postgres отбрасывает смещение, поэтому местное время сервера
+// или время клиента с +03:00 сохранилось бы как есть и сдвинуло бы все сравнения.
+// Все время, которое пишется в базу или сравнивается с колонками, переводится в UTC здесь.
+
+// utcNow - текущее время для записи в базу
+func utcNow() time.Time {
+	return time.Now().UTC()
+}
+
+// utcTime переводит необязательное время в UTC, nil остается NULL
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"geo-alert-core/internal/domain"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookDeliveryRepository - журнал доставки вебхуков
+type WebhookDeliveryRepository interface {
+	// Create записывает новую доставку, задает ей id и время создания
+	Create(ctx context.Context, delivery *domain.WebhookDelivery) error
+	// Update сохраняет состояние, число попыток, ошибку и время завершения.
+	// Неизвестный id - ErrWebhookDeliveryNotFound
+	Update(ctx context.Context, delivery *domain.WebhookDelivery) error
+	// List возвращает доставки по фильтру, новые первыми
+	List(ctx context.Context, filter *domain.WebhookDeliveryFilter) ([]*domain.WebhookDelivery, error)
+	// Purge удаляет доставки, созданные раньше before, и возвращает их число
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+type postgresWebhookDeliveryRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresWebhookDeliveryRepository(db *sql.DB) WebhookDeliveryRepository {
+	return &postgresWebhookDeliveryRepository{db: db}
+}
+
+func (r *postgresWebhookDeliveryRepository) Create(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	ctx, end := observeQuery(ctx, "webhook_deliveries", "create")
+	defer end()
+
+	incidentIDs, err := json.Marshal(nonNilStrings(delivery.IncidentIDs))
+	if err != nil {
+		return fmt.Errorf("failed to marshal incident ids: %w", err)
+	}
+
+	delivery.ID = uuid.New()
+	delivery.CreatedAt = utcNow()
+
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (id, user_id, incident_ids, url, status, attempts, last_error, created_at, finished_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, delivery.ID, delivery.UserID, incidentIDs, delivery.URL, delivery.Status,
+		delivery.Attempts, delivery.LastError, delivery.CreatedAt, utcTime(delivery.FinishedAt))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+
+	return nil
+}
+
+func (r *postgresWebhookDeliveryRepository) Update(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	ctx, end := observeQuery(ctx, "webhook_deliveries", "update")
+	defer end()
+
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, last_error = $3, finished_at = $4
+		WHERE id = $5
+	`, delivery.Status, delivery.Attempts, delivery.LastError, utcTime(delivery.FinishedAt), delivery.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w", domain.ErrWebhookDeliveryNotFound)
+	}
+
+	return nil
+}
+
+func (r *postgresWebhookDeliveryRepository) List(ctx context.Context, filter *domain.WebhookDeliveryFilter) ([]*domain.WebhookDelivery, error) {
+	ctx, end := observeQuery(ctx, "webhook_deliveries", "list")
+	defer end()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, user_id, incident_ids, url, status, attempts, last_error, created_at, finished_at
+		FROM webhook_deliveries
+		WHERE $1 = '' OR status = $1
+		ORDER BY created_at DESC, id
+		LIMIT $2
+	`, filter.Status, filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []*domain.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func (r *postgresWebhookDeliveryRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	ctx, end := observeQuery(ctx, "webhook_deliveries", "purge")
+	defer end()
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM webhook_deliveries WHERE created_at < $1`, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge webhook deliveries: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return purged, nil
+}
+
+func scanWebhookDelivery(row rowScanner) (*domain.WebhookDelivery, error) {
+	var delivery domain.WebhookDelivery
+	var incidentIDs []byte
+	var finishedAt sql.NullTime
+	if err := row.Scan(
+		&delivery.ID, &delivery.UserID, &incidentIDs, &delivery.URL, &delivery.Status,
+		&delivery.Attempts, &delivery.LastError, &delivery.CreatedAt, &finishedAt,
+	); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(incidentIDs, &delivery.IncidentIDs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal incident ids: %w", err)
+	}
+	if finishedAt.Valid {
+		delivery.FinishedAt = &finishedAt.Time
+	}
+	return &delivery, nil
+}
+
+// nonNilStrings - пустой список пишется в JSONB как [], а не null
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"geo-alert-core/internal/domain"
+	"geo-alert-core/internal/repository"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// apiKeyPrefixLength - сколько первых символов ключа показывается в списке
+const apiKeyPrefixLength = 8
+
+// APIKeyService выдает, отзывает и проверяет ключи API.
+// Ключ из 32 случайных байт показывается один раз при выдаче, хранится только его SHA-256:
+// для случайного ключа медленный хэш не нужен, а проверка укладывается в один запрос по индексу.
+type APIKeyService struct {
+	repo repository.APIKeyRepository
+}
+
+func NewAPIKeyService(repo repository.APIKeyRepository) *APIKeyService {
+	return &APIKeyService{repo: repo}
+}
+
+// IssueAPIKey выдает новый ключ
+func (s *APIKeyService) IssueAPIKey(ctx context.Context, req *domain.CreateAPIKeyRequest) (*domain.IssuedAPIKey, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", domain.ErrInvalidAPIKey)
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("failed to generate api key: %w", err)
+	}
+	secret := hex.EncodeToString(buf)
+
+	issued := &domain.IssuedAPIKey{
+		APIKey: domain.APIKey{Name: name, Prefix: secret[:apiKeyPrefixLength]},
+		Key:    secret,
+	}
+	if err := s.repo.Create(ctx, &issued.APIKey, hashAPIKey(secret)); err != nil {
+		return nil, err
+	}
+
+	return issued, nil
+}
+
+func (s *APIKeyService) ListAPIKeys(ctx context.Context) ([]*domain.APIKey, error) {
+	return s.repo.List(ctx)
+}
+
+// RevokeAPIKey отзывает ключ, запросы с ним сразу перестают проходить
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	return s.repo.Revoke(ctx, id)
+}
+
+// ValidateAPIKey проверяет выданный ключ (ключ из API_KEY проверяет middleware)
+func (s *APIKeyService) ValidateAPIKey(ctx context.Context, key string) (bool, error) {
+	_, err := s.repo.FindByHash(ctx, hashAPIKey(key))
+	if errors.Is(err, domain.ErrAPIKeyNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"geo-alert-core/internal/domain"
+	"geo-alert-core/internal/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyService_IssueValidateRevoke(t *testing.T) {
+	ctx := context.Background()
+	service := NewAPIKeyService(repository.NewMemoryAPIKeyRepository(repository.NewMemoryStore()))
+
+	issued, err := service.IssueAPIKey(ctx, &domain.CreateAPIKeyRequest{Name: " mobile app "})
+	require.NoError(t, err)
+	assert.Len(t, issued.Key, 64)
+	assert.Equal(t, "mobile app", issued.Name)
+	assert.Equal(t, issued.Key[:8], issued.Prefix)
+
+	ok, err := service.ValidateAPIKey(ctx, issued.Key)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = service.ValidateAPIKey(ctx, issued.Prefix)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// в списке ключа нет, только его начало
+	keys, err := service.ListAPIKeys(ctx)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, issued.ID, keys[0].ID)
+
+	require.NoError(t, service.RevokeAPIKey(ctx, issued.ID))
+	ok, err = service.ValidateAPIKey(ctx, issued.Key)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestAPIKeyService_IssueRequiresName(t *testing.T) {
+	service := NewAPIKeyService(repository.NewMemoryAPIKeyRepository(repository.NewMemoryStore()))
+
+	_, err := service.IssueAPIKey(context.Background(), &domain.CreateAPIKeyRequest{Name: "  "})
+	assert.ErrorIs(t, err, domain.ErrInvalidAPIKey)
+}
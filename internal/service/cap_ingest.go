@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"geo-alert-core/internal/domain"
+	"geo-alert-core/internal/format/cap"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IngestCAPAlert применяет CAP-предупреждение к зонам.
+// Alert создает или обновляет зоны с ключом sender,identifier, Update дополнительно
+// снимает зоны предупреждений из references, Cancel снимает зоны из references.
+// Предупреждения из references запоминаются как замененные: если такое предупреждение
+// доставят позже (лента отдает новые первыми, повтор после перезапуска), оно пропускается.
+// Учебные, тестовые и служебные сообщения (status != Actual, Ack, Error) пропускаются.
+func (s *IncidentService) IngestCAPAlert(ctx context.Context, alert *cap.Alert) (*domain.CAPIngestResult, error) {
+	result := &domain.CAPIngestResult{
+		Identifier:  alert.Identifier,
+		MsgType:     alert.MsgType,
+		Created:     []uuid.UUID{},
+		Updated:     []uuid.UUID{},
+		Deactivated: []uuid.UUID{},
+	}
+
+	if alert.Status != cap.StatusActual {
+		result.Ignored = true
+		result.Reason = fmt.Sprintf("status %s is not processed", alert.Status)
+		return result, nil
+	}
+
+	refs, err := alert.ReferencedAlerts()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidIncident, err)
+	}
+
+	switch alert.MsgType {
+	case cap.MsgTypeAlert, cap.MsgTypeUpdate:
+		incidents, err := alert.Incidents()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrInvalidIncident, err)
+		}
+		// проверяем все зоны до записи, чтобы не применить предупреждение частично
+		for _, incident := range incidents {
+			if err := s.prepareIncident(incident); err != nil {
+				return nil, fmt.Errorf("area %s: %w", incident.ExternalID, err)
+			}
+		}
+
+		supersededBy, err := s.repo.SupersededBy(ctx, domain.SourceCAP, alert.Key())
+		if err != nil {
+			return nil, err
+		}
+		if supersededBy != "" {
+			result.Ignored = true
+			result.Reason = fmt.Sprintf("alert was superseded by %s", supersededBy)
+			return result, nil
+		}
+
+		if err := s.upsertCAPIncidents(ctx, alert.Key(), incidents, result); err != nil {
+			return result, err
+		}
+		if alert.MsgType == cap.MsgTypeUpdate {
+			for _, ref := range refs {
+				if ref.Key() == alert.Key() {
+					continue
+				}
+				if err := s.supersedeCAPAlert(ctx, ref.Key(), alert.Key(), result); err != nil {
+					return result, err
+				}
+			}
+		}
+	case cap.MsgTypeCancel:
+		if len(refs) == 0 {
+			return nil, fmt.Errorf("%w: cancel without references", domain.ErrInvalidIncident)
+		}
+		for _, ref := range refs {
+			if err := s.supersedeCAPAlert(ctx, ref.Key(), alert.Key(), result); err != nil {
+				return result, err
+			}
+		}
+	default:
+		result.Ignored = true
+		result.Reason = fmt.Sprintf("msgType %s is not processed", alert.MsgType)
+		return result, nil
+	}
+
+	if result.Changed() {
+		s.invalidateCache(ctx)
+	}
+
+	return result, nil
+}
+
+// upsertCAPIncidents сверяет зоны предупреждения с уже сохраненными по ключу external_id.
+// Зоны, которых больше нет в предупреждении, снимаются.
+func (s *IncidentService) upsertCAPIncidents(ctx context.Context, key string, incidents []*domain.Incident, result *domain.CAPIngestResult) error {
+	existing, err := s.repo.FindByExternalID(ctx, domain.SourceCAP, key)
+	if err != nil {
+		return err
+	}
+	byKey := make(map[string]*domain.Incident, len(existing))
+	for _, incident := range existing {
+		byKey[incident.ExternalID] = incident
+	}
+
+	for _, incident := range incidents {
+		current, ok := byKey[incident.ExternalID]
+		delete(byKey, incident.ExternalID)
+
+		if !ok {
+			if err := s.repo.Create(ctx, incident); err != nil {
+				return fmt.Errorf("failed to create incident for %s: %w", incident.ExternalID, err)
+			}
+			result.Created = append(result.Created, incident.ID)
+			continue
+		}
+
+		// зону удалил оператор: обновления источника ее не возвращают, нужен restore
+		if current.DeletedAt != nil || sameCAPIncident(current, incident) {
+			result.Unchanged++
+			continue
+		}
+
+		incident.ID = current.ID
+		incident.Version = current.Version
+		incident.CreatedAt = current.CreatedAt
+		if err := s.repo.Update(ctx, incident.ID, incident); err != nil {
+			return fmt.Errorf("failed to update incident for %s: %w", incident.ExternalID, err)
+		}
+		result.Updated = append(result.Updated, incident.ID)
+	}
+
+	for _, stale := range byKey {
+		if err := s.deactivateIncident(ctx, stale, result); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// supersedeCAPAlert помечает предупреждение с ключом key замененным и снимает его зоны
+func (s *IncidentService) supersedeCAPAlert(ctx context.Context, key, supersededBy string, result *domain.CAPIngestResult) error {
+	if err := s.repo.MarkSuperseded(ctx, domain.SourceCAP, key, supersededBy); err != nil {
+		return err
+	}
+	return s.deactivateCAPIncidents(ctx, key, result)
+}
+
+func (s *IncidentService) deactivateCAPIncidents(ctx context.Context, key string, result *domain.CAPIngestResult) error {
+	incidents, err := s.repo.FindByExternalID(ctx, domain.SourceCAP, key)
+	if err != nil {
+		return err
+	}
+	for _, incident := range incidents {
+		if err := s.deactivateIncident(ctx, incident, result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deactivateIncident выключает зону, но не удаляет ее: снятые источником зоны остаются в списке
+func (s *IncidentService) deactivateIncident(ctx context.Context, incident *domain.Incident, result *domain.CAPIngestResult) error {
+	if !incident.IsActive || incident.DeletedAt != nil {
+		return nil
+	}
+	incident.IsActive = false
+	if err := s.repo.Update(ctx, incident.ID, incident); err != nil {
+		return fmt.Errorf("failed to deactivate incident %s: %w", incident.ID, err)
+	}
+	result.Deactivated = append(result.Deactivated, incident.ID)
+	return nil
+}
+
+// Точность хранения: координаты DECIMAL(10, 8), радиус DECIMAL(10, 2)
+const (
+	coordTolerance  = 1e-7
+	radiusTolerance = 0.01
+)
+
+// sameCAPIncident - повторная доставка того же предупреждения не должна плодить версии.
+// Числа сравниваются с допуском, т.к. после сохранения они округляются.
+func sameCAPIncident(a, b *domain.Incident) bool {
+	return a.Title == b.Title &&
+		a.Description == b.Description &&
+		math.Abs(a.Latitude-b.Latitude) < coordTolerance &&
+		math.Abs(a.Longitude-b.Longitude) < coordTolerance &&
+		math.Abs(a.Radius-b.Radius) < radiusTolerance &&
+		slices.EqualFunc(a.Polygon, b.Polygon, func(p, q [2]float64) bool {
+			return math.Abs(p[0]-q[0]) < coordTolerance && math.Abs(p[1]-q[1]) < coordTolerance
+		}) &&
+		a.Category == b.Category &&
+		a.Severity == b.Severity &&
+		a.IsActive == b.IsActive &&
+		a.Urgency == b.Urgency &&
+		a.Certainty == b.Certainty &&
+		sameTime(a.EffectiveAt, b.EffectiveAt) &&
+		sameTime(a.ExpiresAt, b.ExpiresAt)
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"geo-alert-core/internal/domain"
+	"geo-alert-core/internal/format/cap"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// фикстуры общие с пакетом разбора
+func loadCAPFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile("../format/cap/testdata/" + name)
+	require.NoError(t, err)
+	return data
+}
+
+func parseCAPFixture(t *testing.T, name string) *cap.Alert {
+	t.Helper()
+	alert, err := cap.Parse(strings.NewReader(string(loadCAPFixture(t, name))))
+	require.NoError(t, err)
+	return alert
+}
+
+func TestIncidentService_IngestCAPAlert_Create(t *testing.T) {
+	mockRepo := new(MockIncidentRepository)
+	service := NewIncidentService(mockRepo)
+
+	mockRepo.On("SupersededBy", mock.Anything, domain.SourceCAP, "alerts@mchs.example,MCHS-2024-0001").Return("", nil)
+	mockRepo.On("FindByExternalID", mock.Anything, domain.SourceCAP, "alerts@mchs.example,MCHS-2024-0001").Return([]*domain.Incident{}, nil)
+	mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(i *domain.Incident) bool {
+		return i.Source == domain.SourceCAP && i.Radius > 0
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*domain.Incident).ID = uuid.New()
+	}).Return(nil).Twice()
+
+	result, err := service.IngestCAPAlert(context.Background(), parseCAPFixture(t, "alert.xml"))
+	require.NoError(t, err)
+	assert.Len(t, result.Created, 2)
+	assert.Empty(t, result.Updated)
+	assert.False(t, result.Ignored)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestIncidentService_IngestCAPAlert_Redelivery(t *testing.T) {
+	alert := parseCAPFixture(t, "alert.xml")
+	stored, err := alert.Incidents()
+	require.NoError(t, err)
+
+	mockRepo := new(MockIncidentRepository)
+	service := NewIncidentService(mockRepo)
+	for _, incident := range stored {
+		require.NoError(t, service.prepareIncident(incident))
+		incident.ID = uuid.New()
+		incident.Version = 1
+	}
+	// вторая зона поменялась на стороне источника
+	stored[1].Radius = 900
+
+	mockRepo.On("SupersededBy", mock.Anything, domain.SourceCAP, "alerts@mchs.example,MCHS-2024-0001").Return("", nil)
+	mockRepo.On("FindByExternalID", mock.Anything, domain.SourceCAP, "alerts@mchs.example,MCHS-2024-0001").Return(stored, nil)
+	mockRepo.On("Update", mock.Anything, stored[1].ID, mock.MatchedBy(func(i *domain.Incident) bool {
+		return i.Version == 1 && i.Radius == 1500
+	})).Return(nil).Once()
+
+	result, err := service.IngestCAPAlert(context.Background(), alert)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Unchanged)
+	assert.Equal(t, []uuid.UUID{stored[1].ID}, result.Updated)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestIncidentService_IngestCAPAlert_KeyIncludesSender(t *testing.T) {
+	mockRepo := new(MockIncidentRepository)
+	service := NewIncidentService(mockRepo)
+
+	// тот же identifier у другого отправителя - другое предупреждение, зоны МЧС не трогаются
+	alert := parseCAPFixture(t, "alert.xml")
+	alert.Sender = "alerts@weather.example"
+	mockRepo.On("SupersededBy", mock.Anything, domain.SourceCAP, "alerts@weather.example,MCHS-2024-0001").Return("", nil)
+	mockRepo.On("FindByExternalID", mock.Anything, domain.SourceCAP, "alerts@weather.example,MCHS-2024-0001").Return([]*domain.Incident{}, nil)
+	mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(i *domain.Incident) bool {
+		return strings.HasPrefix(i.ExternalID, "alerts@weather.example,MCHS-2024-0001")
+	})).Return(nil).Twice()
+
+	result, err := service.IngestCAPAlert(context.Background(), alert)
+	require.NoError(t, err)
+	assert.Len(t, result.Created, 2)
+	mockRepo.AssertNotCalled(t, "FindByExternalID", mock.Anything, domain.SourceCAP, "alerts@mchs.example,MCHS-2024-0001")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestIncidentService_IngestCAPAlert_SkipsDeleted(t *testing.T) {
+	alert := parseCAPFixture(t, "alert.xml")
+	stored, err := alert.Incidents()
+	require.NoError(t, err)
+
+	mockRepo := new(MockIncidentRepository)
+	service := NewIncidentService(mockRepo)
+	deletedAt := time.Now()
+	for _, incident := range stored {
+		require.NoError(t, service.prepareIncident(incident))
+		incident.ID = uuid.New()
+		incident.Version = 2
+		incident.Radius = 900
+		incident.DeletedAt = &deletedAt
+	}
+
+	mockRepo.On("SupersededBy", mock.Anything, domain.SourceCAP, "alerts@mchs.example,MCHS-2024-0001").Return("", nil)
+	mockRepo.On("FindByExternalID", mock.Anything, domain.SourceCAP, "alerts@mchs.example,MCHS-2024-0001").Return(stored, nil)
+
+	// удаленные оператором зоны не обновляются и не создаются заново
+	result, err := service.IngestCAPAlert(context.Background(), alert)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Unchanged)
+	assert.False(t, result.Changed())
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestIncidentService_IngestCAPAlert_UpdateSupersedesReferenced(t *testing.T) {
+	mockRepo := new(MockIncidentRepository)
+	service := NewIncidentService(mockRepo)
+
+	oldID, oldInactiveID := uuid.New(), uuid.New()
+	mockRepo.On("SupersededBy", mock.Anything, domain.SourceCAP, "alerts@mchs.example,MCHS-2024-0002").Return("", nil)
+	mockRepo.On("MarkSuperseded", mock.Anything, domain.SourceCAP, "alerts@mchs.example,MCHS-2024-0001", "alerts@mchs.example,MCHS-2024-0002").Return(nil).Once()
+	mockRepo.On("FindByExternalID", mock.Anything, domain.SourceCAP, "alerts@mchs.example,MCHS-2024-0002").Return([]*domain.Incident{}, nil)
+	mockRepo.On("FindByExternalID", mock.Anything, domain.SourceCAP, "alerts@mchs.example,MCHS-2024-0001").Return([]*domain.Incident{
+		{ID: oldID, ExternalID: "alerts@mchs.example,MCHS-2024-0001", IsActive: true},
+		{ID: oldInactiveID, ExternalID: "alerts@mchs.example,MCHS-2024-0001#2", IsActive: false},
+	}, nil)
+	mockRepo.On("Create", mock.Anything, mock.Anything).Return(nil).Once()
+	mockRepo.On("Update", mock.Anything, oldID, mock.MatchedBy(func(i *domain.Incident) bool { return !i.IsActive })).Return(nil).Once()
+
+	result, err := service.IngestCAPAlert(context.Background(), parseCAPFixture(t, "update.xml"))
+	require.NoError(t, err)
+	assert.Len(t, result.Created, 1)
+	assert.Equal(t, []uuid.UUID{oldID}, result.Deactivated)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestIncidentService_IngestCAPAlert_Cancel(t *testing.T) {
+	mockRepo := new(MockIncidentRepository)
+	service := NewIncidentService(mockRepo)
+
+	id := uuid.New()
+	mockRepo.On("MarkSuperseded", mock.Anything, domain.SourceCAP, "alerts@mchs.example,MCHS-2024-0002", "alerts@mchs.example,MCHS-2024-0003").Return(nil).Once()
+	mockRepo.On("FindByExternalID", mock.Anything, domain.SourceCAP, "alerts@mchs.example,MCHS-2024-0002").Return([]*domain.Incident{
+		{ID: id, ExternalID: "alerts@mchs.example,MCHS-2024-0002", IsActive: true},
+	}, nil)
+	mockRepo.On("Update", mock.Anything, id, mock.MatchedBy(func(i *domain.Incident) bool { return !i.IsActive })).Return(nil).Once()
+
+	result, err := service.IngestCAPAlert(context.Background(), parseCAPFixture(t, "cancel.xml"))
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{id}, result.Deactivated)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestIncidentService_IngestCAPAlert_Superseded(t *testing.T) {
+	mockRepo := new(MockIncidentRepository)
+	service := NewIncidentService(mockRepo)
+
+	// обновление пришло раньше: запоздавшее исходное предупреждение не включает зоны снова
+	mockRepo.On("SupersededBy", mock.Anything, domain.SourceCAP, "alerts@mchs.example,MCHS-2024-0001").Return("alerts@mchs.example,MCHS-2024-0002", nil)
+
+	result, err := service.IngestCAPAlert(context.Background(), parseCAPFixture(t, "alert.xml"))
+	require.NoError(t, err)
+	assert.True(t, result.Ignored)
+	assert.Contains(t, result.Reason, "alerts@mchs.example,MCHS-2024-0002")
+	mockRepo.AssertNotCalled(t, "FindByExternalID", mock.Anything, mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestIncidentService_IngestCAPAlert_Ignored(t *testing.T) {
+	mockRepo := new(MockIncidentRepository)
+	service := NewIncidentService(mockRepo)
+
+	alert := parseCAPFixture(t, "alert.xml")
+	alert.Status = "Exercise"
+
+	result, err := service.IngestCAPAlert(context.Background(), alert)
+	require.NoError(t, err)
+	assert.True(t, result.Ignored)
+	mockRepo.AssertNotCalled(t, "FindByExternalID", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestIncidentService_IngestCAPAlert_InvalidArea(t *testing.T) {
+	mockRepo := new(MockIncidentRepository)
+	service := NewIncidentService(mockRepo)
+
+	alert := parseCAPFixture(t, "alert.xml")
+	alert.Infos[0].Areas[0].Circles = []string{"95,37.5 1"}
+
+	_, err := service.IngestCAPAlert(context.Background(), alert)
+	assert.ErrorIs(t, err, domain.ErrInvalidCoordinates)
+	// ни одна зона не записана
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"geo-alert-core/internal/domain"
+	"geo-alert-core/internal/format/cap"
+	"geo-alert-core/internal/logging"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// maxCAPDocumentSize - ограничение на размер ленты или предупреждения
+const maxCAPDocumentSize = 10 << 20
+
+// CAPIngester применяет разобранное предупреждение (реализуется IncidentService)
+type CAPIngester interface {
+	IngestCAPAlert(ctx context.Context, alert *cap.Alert) (*domain.CAPIngestResult, error)
+}
+
+// CAPPoller периодически забирает CAP-ленту и применяет новые предупреждения.
+// По адресу может лежать одно предупреждение или Atom-лента со ссылками/вложенными предупреждениями.
+type CAPPoller struct {
+	feedURL  string
+	interval time.Duration
+	client   *http.Client
+	ingester CAPIngester
+
+	// уже обработанные предупреждения (identifier+sent) и ссылки из прошлого опроса
+	seen map[string]bool
+}
+
+func NewCAPPoller(feedURL string, interval time.Duration, ingester CAPIngester) *CAPPoller {
+	return &CAPPoller{
+		feedURL:  feedURL,
+		interval: interval,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		ingester: ingester,
+		seen:     make(map[string]bool),
+	}
+}
+
+// Run опрашивает ленту до отмены контекста
+func (p *CAPPoller) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := p.Poll(ctx); err != nil {
+			logging.FromContext(ctx).Error("cap feed poll failed", "url", p.feedURL, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll выполняет один опрос и возвращает число примененных предупреждений.
+// Предупреждения применяются по времени отправки, а не в порядке ленты: иначе Update или Cancel
+// из ленты "новые первыми" применится раньше исходного Alert.
+// Ошибка одного предупреждения не прерывает обработку остальных.
+func (p *CAPPoller) Poll(ctx context.Context) (int, error) {
+	data, err := p.fetch(ctx, p.feedURL)
+	if err != nil {
+		return 0, err
+	}
+	feed, err := cap.ParseDocument(data)
+	if err != nil {
+		return 0, err
+	}
+
+	// запоминаем только то, что есть в текущей ленте, чтобы набор не рос бесконечно
+	seen := make(map[string]bool)
+	var pending []pendingAlert
+	var firstErr error
+
+	for _, alert := range feed.Alerts {
+		key := alertKey(alert)
+		seen[key] = true
+		if !p.seen[key] {
+			pending = append(pending, pendingAlert{key: key, alert: alert})
+		}
+	}
+
+	for _, link := range feed.Links {
+		ref, err := p.resolve(link)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if p.seen[ref] {
+			seen[ref] = true
+			continue
+		}
+
+		alert, err := p.fetchAlert(ctx, ref)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		seen[ref] = true
+		pending = append(pending, pendingAlert{key: ref, alert: alert})
+	}
+
+	slices.SortStableFunc(pending, func(a, b pendingAlert) int {
+		return a.alert.SentTime().Compare(b.alert.SentTime())
+	})
+
+	applied := 0
+	for _, item := range pending {
+		if _, err := p.ingester.IngestCAPAlert(ctx, item.alert); err != nil {
+			// некорректное предупреждение не исправится само, остальное повторим на следующем опросе
+			if !isValidationError(err) {
+				delete(seen, item.key)
+			}
+			if firstErr == nil {
+				firstErr = fmt.Errorf("alert %s: %w", item.alert.Identifier, err)
+			}
+			continue
+		}
+		applied++
+	}
+
+	p.seen = seen
+	return applied, firstErr
+}
+
+// pendingAlert - новое предупреждение ленты и ключ, под которым оно запоминается
+type pendingAlert struct {
+	key   string
+	alert *cap.Alert
+}
+
+func (p *CAPPoller) fetch(ctx context.Context, target string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/cap+xml, application/atom+xml, application/xml")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", target, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: status %d", target, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCAPDocumentSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", target, err)
+	}
+	if len(data) > maxCAPDocumentSize {
+		return nil, fmt.Errorf("document %s is larger than %d bytes", target, maxCAPDocumentSize)
+	}
+	return data, nil
+}
+
+func (p *CAPPoller) fetchAlert(ctx context.Context, target string) (*cap.Alert, error) {
+	data, err := p.fetch(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	alert, err := cap.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", target, err)
+	}
+	return alert, nil
+}
+
+// resolve - ссылки в ленте могут быть относительными
+func (p *CAPPoller) resolve(link string) (string, error) {
+	base, err := url.Parse(p.feedURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid feed url: %w", err)
+	}
+	ref, err := url.Parse(link)
+	if err != nil {
+		return "", fmt.Errorf("invalid alert link %q: %w", link, err)
+	}
+	return base.ResolveReference(ref).String(), nil
+}
+
+func alertKey(alert *cap.Alert) string {
+	return alert.Sender + "," + alert.Identifier + "," + alert.Sent
+}
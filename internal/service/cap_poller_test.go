@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"geo-alert-core/internal/domain"
+	"geo-alert-core/internal/format/cap"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingIngester struct {
+	mu     sync.Mutex
+	alerts []string
+}
+
+func (r *recordingIngester) IngestCAPAlert(ctx context.Context, alert *cap.Alert) (*domain.CAPIngestResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.alerts = append(r.alerts, alert.Identifier)
+	return &domain.CAPIngestResult{Identifier: alert.Identifier}, nil
+}
+
+func TestCAPPoller_Poll(t *testing.T) {
+	var alertRequests int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/feed", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/atom+xml")
+		w.Write(loadCAPFixture(t, "feed.xml"))
+	})
+	mux.HandleFunc("/alerts/MCHS-2024-0001.xml", func(w http.ResponseWriter, r *http.Request) {
+		alertRequests++
+		w.Header().Set("Content-Type", "application/cap+xml")
+		w.Write(loadCAPFixture(t, "alert.xml"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ingester := &recordingIngester{}
+	poller := NewCAPPoller(server.URL+"/feed", 0, ingester)
+
+	applied, err := poller.Poll(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, applied)
+	assert.ElementsMatch(t, []string{"MCHS-2024-0001", "MCHS-2024-0002"}, ingester.alerts)
+
+	// повторный опрос той же ленты ничего не применяет и не скачивает ссылки заново
+	applied, err = poller.Poll(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, applied)
+	assert.Equal(t, 1, alertRequests)
+}
+
+func TestCAPPoller_Poll_NewestFirst(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/feed", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/atom+xml")
+		w.Write(loadCAPFixture(t, "feed_newest_first.xml"))
+	})
+	mux.HandleFunc("/alerts/MCHS-2024-0001.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/cap+xml")
+		w.Write(loadCAPFixture(t, "alert.xml"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ingester := &recordingIngester{}
+	poller := NewCAPPoller(server.URL+"/feed", 0, ingester)
+
+	// исходное предупреждение применяется раньше обновления и отмены, хотя в ленте оно последнее
+	applied, err := poller.Poll(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, applied)
+	assert.Equal(t, []string{"MCHS-2024-0001", "MCHS-2024-0002", "MCHS-2024-0003"}, ingester.alerts)
+}
+
+func TestCAPPoller_Poll_FeedError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	poller := NewCAPPoller(server.URL, 0, &recordingIngester{})
+	_, err := poller.Poll(context.Background())
+	assert.Error(t, err)
+}
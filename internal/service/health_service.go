@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"geo-alert-core/internal/domain"
+	"sync"
+	"time"
+)
+
+// DefaultHealthCheckTimeout - сколько ждем ответа одной зависимости
+const DefaultHealthCheckTimeout = 2 * time.Second
+
+// HealthCheckFunc проверяет зависимость и может вернуть подробности (версию, размер очереди, ...)
+type HealthCheckFunc func(ctx context.Context) (map[string]any, error)
+
+type healthCheck struct {
+	name     string
+	required bool
+	check    HealthCheckFunc
+}
+
+// HealthService опрашивает зависимости для /system/ready
+type HealthService struct {
+	service string
+	timeout time.Duration
+	checks  []healthCheck
+	now     func() time.Time
+}
+
+func NewHealthService(serviceName string, timeout time.Duration) *HealthService {
+	if timeout <= 0 {
+		timeout = DefaultHealthCheckTimeout
+	}
+	return &HealthService{
+		service: serviceName,
+		timeout: timeout,
+		now:     time.Now,
+	}
+}
+
+// AddCheck регистрирует проверку. Отказ обязательной зависимости делает сервис неготовым,
+// необязательной - только переводит его в degraded.
+func (s *HealthService) AddCheck(name string, required bool, check HealthCheckFunc) {
+	s.checks = append(s.checks, healthCheck{name: name, required: required, check: check})
+}
+
+// Ready проверяет все зависимости параллельно, каждую - со своим таймаутом
+func (s *HealthService) Ready(ctx context.Context) *domain.HealthReport {
+	report := &domain.HealthReport{
+		Status:       domain.HealthOK,
+		Service:      s.service,
+		CheckedAt:    s.now().UTC(),
+		Dependencies: make([]*domain.DependencyHealth, len(s.checks)),
+	}
+
+	var wg sync.WaitGroup
+	for i, check := range s.checks {
+		wg.Add(1)
+		go func(i int, check healthCheck) {
+			defer wg.Done()
+			report.Dependencies[i] = s.run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	for _, dep := range report.Dependencies {
+		if dep.Status == domain.DependencyUp {
+			continue
+		}
+		if dep.Required {
+			report.Status = domain.HealthUnavailable
+		} else if report.Status == domain.HealthOK {
+			report.Status = domain.HealthDegraded
+		}
+	}
+
+	return report
+}
+
+func (s *HealthService) run(ctx context.Context, check healthCheck) *domain.DependencyHealth {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	type outcome struct {
+		details map[string]any
+		err     error
+	}
+	done := make(chan outcome, 1)
+
+	start := time.Now()
+	go func() {
+		details, err := check.check(ctx)
+		done <- outcome{details, err}
+	}()
+
+	// проверка, которая не следит за ctx, не должна задерживать ответ
+	var details map[string]any
+	var err error
+	select {
+	case out := <-done:
+		details, err = out.details, out.err
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := &domain.DependencyHealth{
+		Name:      check.name,
+		Status:    domain.DependencyUp,
+		Required:  check.required,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+		Details:   details,
+	}
+	if err != nil {
+		result.Status = domain.DependencyDown
+		result.Error = err.Error()
+	}
+	return result
+}
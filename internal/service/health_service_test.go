@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"geo-alert-core/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func upCheck(details map[string]any) HealthCheckFunc {
+	return func(ctx context.Context) (map[string]any, error) {
+		return details, nil
+	}
+}
+
+func downCheck(ctx context.Context) (map[string]any, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestHealthService_Ready(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(s *HealthService)
+		expected string
+	}{
+		{
+			name: "all dependencies up",
+			setup: func(s *HealthService) {
+				s.AddCheck("postgres", true, upCheck(nil))
+				s.AddCheck("webhooks", false, upCheck(nil))
+			},
+			expected: domain.HealthOK,
+		},
+		{
+			name: "optional dependency down",
+			setup: func(s *HealthService) {
+				s.AddCheck("postgres", true, upCheck(nil))
+				s.AddCheck("webhooks", false, downCheck)
+			},
+			expected: domain.HealthDegraded,
+		},
+		{
+			name: "required dependency down",
+			setup: func(s *HealthService) {
+				s.AddCheck("postgres", true, downCheck)
+				s.AddCheck("webhooks", false, downCheck)
+			},
+			expected: domain.HealthUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewHealthService("geo-alert-core", time.Second)
+			tt.setup(service)
+
+			report := service.Ready(context.Background())
+			assert.Equal(t, tt.expected, report.Status)
+			assert.Len(t, report.Dependencies, 2)
+		})
+	}
+}
+
+func TestHealthService_Ready_Details(t *testing.T) {
+	service := NewHealthService("geo-alert-core", time.Second)
+	service.AddCheck("postgis", true, upCheck(map[string]any{"version": "3.4.2"}))
+	service.AddCheck("redis", true, downCheck)
+
+	report := service.Ready(context.Background())
+	require.Len(t, report.Dependencies, 2)
+
+	// порядок - как при регистрации
+	postgis, redis := report.Dependencies[0], report.Dependencies[1]
+	assert.Equal(t, "postgis", postgis.Name)
+	assert.Equal(t, domain.DependencyUp, postgis.Status)
+	assert.Equal(t, "3.4.2", postgis.Details["version"])
+	assert.Empty(t, postgis.Error)
+
+	assert.Equal(t, "redis", redis.Name)
+	assert.Equal(t, domain.DependencyDown, redis.Status)
+	assert.True(t, redis.Required)
+	assert.Equal(t, "connection refused", redis.Error)
+}
+
+func TestHealthService_Ready_Timeout(t *testing.T) {
+	service := NewHealthService("geo-alert-core", 20*time.Millisecond)
+	release := make(chan struct{})
+	defer close(release)
+
+	// проверка не следит за ctx
+	service.AddCheck("postgres", true, func(ctx context.Context) (map[string]any, error) {
+		<-release
+		return nil, nil
+	})
+
+	start := time.Now()
+	report := service.Ready(context.Background())
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, domain.HealthUnavailable, report.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Dependencies[0].Error)
+}
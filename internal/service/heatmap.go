@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"geo-alert-core/internal/domain"
+	"math"
+)
+
+const (
+	// MaxHeatmapCells - сколько ячеек сетки может покрыть bbox, иначе нужно укрупнить сетку
+	MaxHeatmapCells = 50000
+
+	DefaultGeohashPrecision = 6
+	DefaultHexCellSize      = 500.0
+
+	// Web Mercator не определена у полюсов
+	maxMercatorLatitude = 85.05112878
+	earthRadius         = 6378137.0
+)
+
+// Heatmap считает плотность проверок координат по ячейкам geohash или шестиугольной сетки
+func (s *LocationService) Heatmap(ctx context.Context, filter *domain.HeatmapFilter) (*domain.Heatmap, error) {
+	if err := validateHeatmapFilter(filter); err != nil {
+		return nil, err
+	}
+
+	cells, err := s.checkRepo.Heatmap(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build heatmap: %w", err)
+	}
+
+	heatmap := &domain.Heatmap{
+		Grid:  filter.Grid,
+		From:  filter.From,
+		To:    filter.To,
+		Cells: cells,
+	}
+	if filter.Grid == domain.GridGeohash {
+		heatmap.Precision = filter.Precision
+	} else {
+		heatmap.CellSize = filter.CellSize
+	}
+
+	return heatmap, nil
+}
+
+// validateHeatmapFilter проверяет фильтр и подставляет размер сетки по умолчанию
+func validateHeatmapFilter(filter *domain.HeatmapFilter) error {
+	if !filter.From.Before(filter.To) {
+		return fmt.Errorf("%w: from must be before to", domain.ErrInvalidFilter)
+	}
+
+	box := filter.BBox
+	if box.MinLatitude < -90 || box.MaxLatitude > 90 || box.MinLongitude < -180 || box.MaxLongitude > 180 {
+		return fmt.Errorf("%w: bbox is outside of valid coordinates", domain.ErrInvalidFilter)
+	}
+	if box.MinLatitude >= box.MaxLatitude || box.MinLongitude >= box.MaxLongitude {
+		return fmt.Errorf("%w: bbox min corner must be below and left of max corner", domain.ErrInvalidFilter)
+	}
+
+	var cells float64
+	switch filter.Grid {
+	case "", domain.GridGeohash:
+		filter.Grid = domain.GridGeohash
+		if filter.Precision == 0 {
+			filter.Precision = DefaultGeohashPrecision
+		}
+		if filter.Precision < 1 || filter.Precision > 12 {
+			return fmt.Errorf("%w: geohash precision must be between 1 and 12", domain.ErrInvalidFilter)
+		}
+		cells = geohashCellCount(box, filter.Precision)
+	case domain.GridHex:
+		if filter.CellSize == 0 {
+			filter.CellSize = DefaultHexCellSize
+		}
+		if filter.CellSize < 0 {
+			return fmt.Errorf("%w: cell_size must be positive", domain.ErrInvalidFilter)
+		}
+		if box.MinLatitude < -maxMercatorLatitude || box.MaxLatitude > maxMercatorLatitude {
+			return fmt.Errorf("%w: hex grid requires bbox latitude between -%.2f and %.2f", domain.ErrInvalidFilter, maxMercatorLatitude, maxMercatorLatitude)
+		}
+		cells = hexCellCount(box, filter.CellSize)
+	default:
+		return fmt.Errorf("%w: grid must be geohash or hex", domain.ErrInvalidFilter)
+	}
+
+	if cells > MaxHeatmapCells {
+		return fmt.Errorf("%w: bbox covers about %.0f cells, limit is %d - shrink bbox or use a coarser grid",
+			domain.ErrInvalidFilter, cells, MaxHeatmapCells)
+	}
+
+	return nil
+}
+
+// geohashCellCount - оценка числа ячеек geohash в bbox: нечетные биты кодируют долготу, четные широту
+func geohashCellCount(box domain.BoundingBox, precision int) float64 {
+	bits := 5 * precision
+	cellWidth := 360 / math.Pow(2, float64((bits+1)/2))
+	cellHeight := 180 / math.Pow(2, float64(bits/2))
+	return (math.Floor((box.MaxLongitude-box.MinLongitude)/cellWidth) + 1) *
+		(math.Floor((box.MaxLatitude-box.MinLatitude)/cellHeight) + 1)
+}
+
+// hexCellCount - оценка числа шестиугольников со стороной size, покрывающих bbox в Web Mercator
+func hexCellCount(box domain.BoundingBox, size float64) float64 {
+	mercatorY := func(lat float64) float64 {
+		return earthRadius * math.Log(math.Tan(math.Pi/4+lat*math.Pi/360))
+	}
+	width := earthRadius * (box.MaxLongitude - box.MinLongitude) * math.Pi / 180
+	height := mercatorY(box.MaxLatitude) - mercatorY(box.MinLatitude)
+
+	// соседние центры отстоят на 1.5*size по горизонтали и на sqrt(3)*size по вертикали
+	return (math.Floor(width/(1.5*size)) + 2) * (math.Floor(height/(math.Sqrt(3)*size)) + 2)
+}
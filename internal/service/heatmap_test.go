@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"geo-alert-core/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocationService_Heatmap_Defaults(t *testing.T) {
+	checkRepo := new(MockLocationCheckRepository)
+	service := NewLocationService(new(MockIncidentRepository), checkRepo, nil)
+
+	to := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	moscow := domain.BoundingBox{MinLongitude: 37.5, MinLatitude: 55.7, MaxLongitude: 37.7, MaxLatitude: 55.8}
+	cells := []*domain.HeatmapCell{{Cell: "ucfv0j", Checks: 12, Users: 3}}
+
+	checkRepo.On("Heatmap", mock.Anything, mock.MatchedBy(func(f *domain.HeatmapFilter) bool {
+		return f.Grid == domain.GridGeohash && f.Precision == DefaultGeohashPrecision
+	})).Return(cells, nil).Once()
+
+	heatmap, err := service.Heatmap(context.Background(), &domain.HeatmapFilter{
+		BBox: moscow, From: to.Add(-time.Hour), To: to,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, domain.GridGeohash, heatmap.Grid)
+	assert.Equal(t, DefaultGeohashPrecision, heatmap.Precision)
+	assert.Equal(t, cells, heatmap.Cells)
+
+	checkRepo.On("Heatmap", mock.Anything, mock.MatchedBy(func(f *domain.HeatmapFilter) bool {
+		return f.Grid == domain.GridHex && f.CellSize == DefaultHexCellSize
+	})).Return([]*domain.HeatmapCell{}, nil).Once()
+
+	heatmap, err = service.Heatmap(context.Background(), &domain.HeatmapFilter{
+		Grid: domain.GridHex, BBox: moscow, From: to.Add(-time.Hour), To: to,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultHexCellSize, heatmap.CellSize)
+	checkRepo.AssertExpectations(t)
+}
+
+func TestLocationService_Heatmap_Validation(t *testing.T) {
+	checkRepo := new(MockLocationCheckRepository)
+	service := NewLocationService(new(MockIncidentRepository), checkRepo, nil)
+
+	to := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	moscow := domain.BoundingBox{MinLongitude: 37.5, MinLatitude: 55.7, MaxLongitude: 37.7, MaxLatitude: 55.8}
+	world := domain.BoundingBox{MinLongitude: -180, MinLatitude: -90, MaxLongitude: 180, MaxLatitude: 90}
+
+	tests := []struct {
+		name   string
+		filter domain.HeatmapFilter
+	}{
+		{"empty time range", domain.HeatmapFilter{BBox: moscow, From: to, To: to}},
+		{"missing bbox", domain.HeatmapFilter{From: to.Add(-time.Hour), To: to}},
+		{"inverted bbox", domain.HeatmapFilter{BBox: domain.BoundingBox{MinLongitude: 37.7, MinLatitude: 55.7, MaxLongitude: 37.5, MaxLatitude: 55.8}, From: to.Add(-time.Hour), To: to}},
+		{"unknown grid", domain.HeatmapFilter{Grid: "square", BBox: moscow, From: to.Add(-time.Hour), To: to}},
+		{"precision too high", domain.HeatmapFilter{Precision: 13, BBox: moscow, From: to.Add(-time.Hour), To: to}},
+		{"too many geohash cells", domain.HeatmapFilter{Precision: 5, BBox: world, From: to.Add(-time.Hour), To: to}},
+		{"too many hexagons", domain.HeatmapFilter{Grid: domain.GridHex, CellSize: 10, BBox: moscow, From: to.Add(-time.Hour), To: to}},
+		{"hex near pole", domain.HeatmapFilter{Grid: domain.GridHex, CellSize: 100000, BBox: world, From: to.Add(-time.Hour), To: to}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.Heatmap(context.Background(), &tt.filter)
+			assert.ErrorIs(t, err, domain.ErrInvalidFilter)
+		})
+	}
+	checkRepo.AssertNotCalled(t, "Heatmap", mock.Anything, mock.Anything)
+}
+
+func TestGeohashCellCount(t *testing.T) {
+	world := domain.BoundingBox{MinLongitude: -180, MinLatitude: -90, MaxLongitude: 180, MaxLatitude: 90}
+	// precision 1 делит мир на 8x4 ячеек (плюс граница)
+	assert.Equal(t, 9.0*5.0, geohashCellCount(world, 1))
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"geo-alert-core/internal/domain"
+	"geo-alert-core/internal/geo"
+	"iter"
+
+	"github.com/google/uuid"
+)
+
+// exportPageSize - размер страницы при выгрузке всех инцидентов по фильтру
+const exportPageSize = 500
+
+// ExportIncidents проходит по всем инцидентам, подходящим под фильтр, постранично по курсору.
+// Лимит и курсор фильтра игнорируются.
+func (s *IncidentService) ExportIncidents(ctx context.Context, filter *domain.IncidentFilter, fn func(*domain.Incident) error) error {
+	if err := s.validateFilter(filter); err != nil {
+		return err
+	}
+
+	page := *filter
+	page.Limit = exportPageSize
+	page.Offset = 0
+	page.Cursor = nil
+	page.IncludeTotal = false
+	if page.SortBy == "" {
+		page.SortBy = domain.SortByCreatedAt
+	}
+
+	for {
+		result, err := s.repo.List(ctx, &page)
+		if err != nil {
+			return err
+		}
+
+		for _, incident := range result.Items {
+			if err := fn(incident); err != nil {
+				return err
+			}
+		}
+
+		if result.NextCursor == nil {
+			return nil
+		}
+		page.Cursor = result.NextCursor
+	}
+}
+
+// ImportIncidents создает или обновляет инциденты. Объект с id существующего инцидента
+// обновляет его, объект с id удаленного отклоняется, остальные создаются (с переданным id, если он есть).
+// Ошибки валидации попадают в отчет, ошибка хранилища прерывает импорт.
+func (s *IncidentService) ImportIncidents(ctx context.Context, items iter.Seq[domain.ImportItem], opts domain.ImportOptions) (*domain.ImportReport, error) {
+	report := &domain.ImportReport{DryRun: opts.DryRun, RejectedOnly: opts.RejectedOnly, Results: []domain.ImportResult{}}
+	changed := false
+
+	for item := range items {
+		if item.Err != nil {
+			report.Add(item.Index, uuid.Nil, domain.ImportRejected, item.Err)
+			continue
+		}
+
+		status, err := s.importIncident(ctx, item.Incident, opts.DryRun)
+		if err != nil {
+			if isValidationError(err) {
+				report.Add(item.Index, item.Incident.ID, domain.ImportRejected, err)
+				continue
+			}
+			return report, fmt.Errorf("import stopped at item %d: %w", item.Index, err)
+		}
+
+		report.Add(item.Index, item.Incident.ID, status, nil)
+		changed = true
+	}
+
+	if changed && !opts.DryRun {
+		s.invalidateCache(ctx)
+	}
+
+	return report, nil
+}
+
+func (s *IncidentService) importIncident(ctx context.Context, incident *domain.Incident, dryRun bool) (string, error) {
+	if err := s.prepareIncident(incident); err != nil {
+		return "", err
+	}
+
+	if incident.ID != uuid.Nil {
+		existing, err := s.repo.GetByID(ctx, incident.ID)
+		if err != nil && !errors.Is(err, domain.ErrIncidentNotFound) {
+			return "", err
+		}
+
+		if existing != nil {
+			if dryRun {
+				return domain.ImportUpdated, nil
+			}
+
+			incident.Version = existing.Version
+			incident.CreatedAt = existing.CreatedAt
+			if err := s.repo.Update(ctx, incident.ID, incident); err != nil {
+				return "", err
+			}
+			return domain.ImportUpdated, nil
+		}
+
+		// id удаленного инцидента занят до очистки, создать его заново нельзя.
+		// Импорт не снимает удаление сам: это делается явно через restore.
+		versions, err := s.repo.GetVersions(ctx, incident.ID)
+		if err != nil && !errors.Is(err, domain.ErrIncidentNotFound) {
+			return "", err
+		}
+		if len(versions) > 0 {
+			return "", fmt.Errorf("%w: incident %s is deleted, restore it before import", domain.ErrInvalidIncident, incident.ID)
+		}
+	}
+
+	if dryRun {
+		return domain.ImportCreated, nil
+	}
+
+	if err := s.repo.Create(ctx, incident); err != nil {
+		return "", err
+	}
+	return domain.ImportCreated, nil
+}
+
+// prepareIncident проверяет инцидент из внешнего источника и дополняет значения по умолчанию.
+// Для полигона центр и радиус вычисляются по вершинам.
+func (s *IncidentService) prepareIncident(incident *domain.Incident) error {
+	if incident.Polygon != nil {
+		ring, err := geo.CloseRing(incident.Polygon)
+		if err != nil {
+			return fmt.Errorf("%w: %v", domain.ErrInvalidIncident, err)
+		}
+		incident.Polygon = ring
+		incident.Latitude, incident.Longitude, incident.Radius = geo.BoundingCircle(ring)
+	}
+
+	if err := s.validateCoordinates(incident.Latitude, incident.Longitude); err != nil {
+		return err
+	}
+	if incident.Radius <= 0 {
+		return domain.ErrInvalidRadius
+	}
+
+	if incident.Severity == "" {
+		incident.Severity = domain.SeverityMedium
+	}
+	if !domain.IsValidSeverity(incident.Severity) {
+		return domain.ErrInvalidSeverity
+	}
+
+	if incident.Source == "" {
+		incident.Source = domain.SourceManual
+	}
+	if incident.EffectiveAt != nil && incident.ExpiresAt != nil && !incident.ExpiresAt.After(*incident.EffectiveAt) {
+		return fmt.Errorf("%w: expires must be after effective", domain.ErrInvalidIncident)
+	}
+
+	return nil
+}
+
+func isValidationError(err error) bool {
+	return errors.Is(err, domain.ErrInvalidIncident) ||
+		errors.Is(err, domain.ErrInvalidCoordinates) ||
+		errors.Is(err, domain.ErrInvalidRadius) ||
+		errors.Is(err, domain.ErrInvalidSeverity) ||
+		errors.Is(err, domain.ErrVersionConflict)
+}
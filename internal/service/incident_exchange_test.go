@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"geo-alert-core/internal/domain"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIncidentService_ImportIncidents(t *testing.T) {
+	existingID := uuid.New()
+
+	items := func() []domain.ImportItem {
+		return []domain.ImportItem{
+			{Index: 0, Incident: &domain.Incident{Title: "new", Latitude: 55.7, Longitude: 37.6, Radius: 100}},
+			{Index: 1, Incident: &domain.Incident{ID: existingID, Title: "existing", Latitude: 55.7, Longitude: 37.6, Radius: 50}},
+			{Index: 2, Incident: &domain.Incident{Title: "bad", Latitude: 95, Longitude: 37.6, Radius: 100}},
+			{Index: 3, Err: errors.New("invalid incident data: geometry is required")},
+			{Index: 4, Incident: &domain.Incident{Title: "polygon", Polygon: [][2]float64{{37.60, 55.74}, {37.62, 55.74}, {37.62, 55.76}}}},
+			{Index: 5, Incident: &domain.Incident{Title: "line", Polygon: [][2]float64{{37.60, 55.74}, {37.61, 55.75}, {37.62, 55.76}}}},
+		}
+	}
+
+	setup := func() *MockIncidentRepository {
+		mockRepo := new(MockIncidentRepository)
+		mockRepo.On("GetByID", mock.Anything, existingID).Return(&domain.Incident{ID: existingID, Version: 4}, nil)
+		return mockRepo
+	}
+
+	t.Run("apply", func(t *testing.T) {
+		mockRepo := setup()
+		mockRepo.On("Create", mock.Anything, mock.Anything).Return(nil).Twice()
+		mockRepo.On("Update", mock.Anything, existingID, mock.MatchedBy(func(i *domain.Incident) bool {
+			return i.Version == 4
+		})).Return(nil).Once()
+
+		report, err := NewIncidentService(mockRepo).ImportIncidents(context.Background(), slices.Values(items()), domain.ImportOptions{})
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, report.Created)
+		assert.Equal(t, 1, report.Updated)
+		assert.Equal(t, 3, report.Rejected)
+		assert.Equal(t, domain.ImportRejected, report.Results[2].Status)
+		assert.Equal(t, domain.ImportRejected, report.Results[5].Status)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		mockRepo := setup()
+
+		report, err := NewIncidentService(mockRepo).ImportIncidents(context.Background(), slices.Values(items()), domain.ImportOptions{DryRun: true})
+
+		require.NoError(t, err)
+		assert.True(t, report.DryRun)
+		assert.Equal(t, 2, report.Created)
+		assert.Equal(t, 1, report.Updated)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("rejected only", func(t *testing.T) {
+		mockRepo := setup()
+
+		report, err := NewIncidentService(mockRepo).ImportIncidents(context.Background(), slices.Values(items()), domain.ImportOptions{DryRun: true, RejectedOnly: true})
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, report.Created)
+		assert.Equal(t, 3, report.Rejected)
+		require.Len(t, report.Results, 3)
+		assert.Equal(t, 2, report.Results[0].Index)
+		assert.Equal(t, 3, report.Results[1].Index)
+		assert.Equal(t, 5, report.Results[2].Index)
+	})
+
+	t.Run("storage failure stops import", func(t *testing.T) {
+		mockRepo := setup()
+		mockRepo.On("Create", mock.Anything, mock.Anything).Return(errors.New("connection refused"))
+
+		report, err := NewIncidentService(mockRepo).ImportIncidents(context.Background(), slices.Values(items()), domain.ImportOptions{})
+
+		assert.Error(t, err)
+		assert.Empty(t, report.Results)
+	})
+}
+
+func TestIncidentService_ImportIncidents_PolygonCenter(t *testing.T) {
+	mockRepo := new(MockIncidentRepository)
+	mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(i *domain.Incident) bool {
+		return len(i.Polygon) == 5 && i.Radius > 0 && i.Latitude > 55.74 && i.Latitude < 55.76
+	})).Return(nil)
+
+	square := [][2]float64{{37.60, 55.74}, {37.62, 55.74}, {37.62, 55.76}, {37.60, 55.76}}
+	report, err := NewIncidentService(mockRepo).ImportIncidents(context.Background(), slices.Values([]domain.ImportItem{
+		{Incident: &domain.Incident{Title: "square", Polygon: square}},
+	}), domain.ImportOptions{})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Created)
+	mockRepo.AssertExpectations(t)
+}
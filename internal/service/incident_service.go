@@ -2,24 +2,46 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"geo-alert-core/internal/domain"
 	"geo-alert-core/internal/repository"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+// CacheInvalidator - кэш, который нужно сбрасывать при изменении зон
+type CacheInvalidator interface {
+	InvalidateCache(ctx context.Context) error
+}
+
+// DefaultDeletedRetention - сколько удаленные инциденты хранятся до очистки
+const DefaultDeletedRetention = 30 * 24 * time.Hour
+
+// FeedCancelWindow - сколько снятая зона остается в публичных лентах с сообщением об отмене
+const FeedCancelWindow = 24 * time.Hour
+
 type IncidentService struct {
-	repo            repository.IncidentRepository
-	locationService *LocationService // Для инвалидации кэша
+	repo             repository.IncidentRepository
+	invalidators     []CacheInvalidator // Для инвалидации кэша
+	deletedRetention time.Duration
 }
 
 func NewIncidentService(repo repository.IncidentRepository) *IncidentService {
-	return &IncidentService{repo: repo}
+	return &IncidentService{repo: repo, deletedRetention: DefaultDeletedRetention}
+}
+
+// SetDeletedRetention задает срок хранения удаленных инцидентов для PurgeDeletedIncidents
+func (s *IncidentService) SetDeletedRetention(retention time.Duration) {
+	s.deletedRetention = retention
 }
 
-func (s *IncidentService) SetLocationService(locationService *LocationService) {
-	s.locationService = locationService
+// AddCacheInvalidator подписывает кэш на изменения зон (тайлы и т.д.)
+func (s *IncidentService) AddCacheInvalidator(invalidator CacheInvalidator) {
+	s.invalidators = append(s.invalidators, invalidator)
 }
 
 func (s *IncidentService) CreateIncident(ctx context.Context, req *domain.CreateIncidentRequest) (*domain.Incident, error) {
@@ -31,13 +53,24 @@ func (s *IncidentService) CreateIncident(ctx context.Context, req *domain.Create
 		return nil, domain.ErrInvalidRadius
 	}
 
+	severity := req.Severity
+	if severity == "" {
+		severity = domain.SeverityMedium
+	}
+	if !domain.IsValidSeverity(severity) {
+		return nil, domain.ErrInvalidSeverity
+	}
+
 	incident := &domain.Incident{
 		Title:       req.Title,
 		Description: req.Description,
 		Latitude:    req.Latitude,
 		Longitude:   req.Longitude,
 		Radius:      req.Radius,
+		Category:    req.Category,
+		Severity:    severity,
 		IsActive:    true,
+		Source:      domain.SourceManual,
 	}
 
 	if err := s.repo.Create(ctx, incident); err != nil {
@@ -45,9 +78,7 @@ func (s *IncidentService) CreateIncident(ctx context.Context, req *domain.Create
 	}
 
 	// Инвалидируем кэш активных инцидентов
-	if s.locationService != nil {
-		_ = s.locationService.InvalidateCache(ctx)
-	}
+	s.invalidateCache(ctx)
 
 	return incident, nil
 }
@@ -60,6 +91,12 @@ func (s *IncidentService) GetIncident(ctx context.Context, id uuid.UUID) (*domai
 	return incident, nil
 }
 
+// GetFeedEntries возвращает зоны для публичных лент: действующие сейчас
+// и снятые за последние FeedCancelWindow, чтобы подписчики получили отмену
+func (s *IncidentService) GetFeedEntries(ctx context.Context) ([]*domain.FeedEntry, error) {
+	return s.repo.GetFeedEntries(ctx, time.Now().Add(-FeedCancelWindow))
+}
+
 func (s *IncidentService) GetAllIncidents(ctx context.Context, page, pageSize int) ([]*domain.Incident, error) {
 	if page < 1 {
 		page = 1
@@ -72,12 +109,141 @@ func (s *IncidentService) GetAllIncidents(ctx context.Context, page, pageSize in
 	return s.repo.GetAll(ctx, pageSize, offset)
 }
 
-func (s *IncidentService) UpdateIncident(ctx context.Context, id uuid.UUID, req *domain.UpdateIncidentRequest) (*domain.Incident, error) {
+// ListIncidents возвращает страницу инцидентов по фильтру и токен следующей страницы.
+// cursorToken - значение next_cursor из предыдущего ответа (пустая строка - первая страница).
+func (s *IncidentService) ListIncidents(ctx context.Context, filter *domain.IncidentFilter, cursorToken string) (*domain.IncidentPage, string, error) {
+	if filter.Limit < 1 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	filter.Query = strings.TrimSpace(filter.Query)
+	if filter.SortBy == "" {
+		// при поиске по умолчанию сначала самые релевантные
+		filter.SortBy = domain.SortByCreatedAt
+		if filter.Query != "" {
+			filter.SortBy = domain.SortByRelevance
+		}
+		filter.SortDesc = true
+	}
+
+	if err := s.validateFilter(filter); err != nil {
+		return nil, "", err
+	}
+
+	if cursorToken != "" {
+		cursor, err := decodeCursor(cursorToken)
+		if err != nil {
+			return nil, "", err
+		}
+		if cursor.SortBy != filter.SortBy || cursor.SortDesc != filter.SortDesc {
+			return nil, "", fmt.Errorf("%w: cursor was issued for a different sort order", domain.ErrInvalidFilter)
+		}
+		filter.Cursor = cursor
+	}
+
+	page, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, "", err
+	}
+
+	nextCursor := ""
+	if page.NextCursor != nil {
+		nextCursor = encodeCursor(page.NextCursor)
+	}
+
+	return page, nextCursor, nil
+}
+
+func (s *IncidentService) validateFilter(filter *domain.IncidentFilter) error {
+	for _, severity := range filter.Severities {
+		if !domain.IsValidSeverity(severity) {
+			return fmt.Errorf("%w: %v", domain.ErrInvalidFilter, domain.ErrInvalidSeverity)
+		}
+	}
+
+	if box := filter.BBox; box != nil {
+		if err := s.validateCoordinates(box.MinLatitude, box.MinLongitude); err != nil {
+			return fmt.Errorf("%w: bbox: %v", domain.ErrInvalidFilter, err)
+		}
+		if err := s.validateCoordinates(box.MaxLatitude, box.MaxLongitude); err != nil {
+			return fmt.Errorf("%w: bbox: %v", domain.ErrInvalidFilter, err)
+		}
+		if box.MinLatitude > box.MaxLatitude || box.MinLongitude > box.MaxLongitude {
+			return fmt.Errorf("%w: bbox min corner must be below and left of max corner", domain.ErrInvalidFilter)
+		}
+	}
+
+	if near := filter.Near; near != nil {
+		if err := s.validateCoordinates(near.Latitude, near.Longitude); err != nil {
+			return fmt.Errorf("%w: near: %v", domain.ErrInvalidFilter, err)
+		}
+		if near.Meters < 0 {
+			return fmt.Errorf("%w: distance must not be negative", domain.ErrInvalidFilter)
+		}
+	}
+
+	if filter.SortBy == domain.SortByDistance && filter.Near == nil {
+		return fmt.Errorf("%w: sort by distance requires near_lat and near_lon", domain.ErrInvalidFilter)
+	}
+
+	if filter.SortBy == domain.SortByRelevance && filter.Query == "" {
+		return fmt.Errorf("%w: sort by relevance requires q", domain.ErrInvalidFilter)
+	}
+
+	return nil
+}
+
+// курсор для клиента непрозрачен: base64 от JSON
+func encodeCursor(cursor *domain.IncidentCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(token string) (*domain.IncidentCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", domain.ErrInvalidFilter)
+	}
+
+	var cursor domain.IncidentCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", domain.ErrInvalidFilter)
+	}
+
+	return &cursor, nil
+}
+
+// GetAllIncidentsAsOf - список инцидентов в том виде, в каком он был на момент asOf
+func (s *IncidentService) GetAllIncidentsAsOf(ctx context.Context, asOf time.Time, page, pageSize int) ([]*domain.Incident, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	offset := (page - 1) * pageSize
+	return s.repo.GetAllAsOf(ctx, asOf, pageSize, offset)
+}
+
+func (s *IncidentService) GetIncidentVersions(ctx context.Context, id uuid.UUID) ([]*domain.IncidentVersion, error) {
+	return s.repo.GetVersions(ctx, id)
+}
+
+// UpdateIncident применяет изменения, если текущая версия инцидента равна expectedVersion.
+// expectedVersion == 0 означает "любая версия" (If-Match: *).
+func (s *IncidentService) UpdateIncident(ctx context.Context, id uuid.UUID, req *domain.UpdateIncidentRequest, expectedVersion int) (*domain.Incident, error) {
 	incident, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
+	if expectedVersion != 0 && incident.Version != expectedVersion {
+		return nil, domain.ErrVersionConflict
+	}
+
 	if req.Title != nil {
 		incident.Title = *req.Title
 	}
@@ -96,6 +262,15 @@ func (s *IncidentService) UpdateIncident(ctx context.Context, id uuid.UUID, req
 		}
 		incident.Radius = *req.Radius
 	}
+	if req.Category != nil {
+		incident.Category = *req.Category
+	}
+	if req.Severity != nil {
+		if !domain.IsValidSeverity(*req.Severity) {
+			return nil, domain.ErrInvalidSeverity
+		}
+		incident.Severity = *req.Severity
+	}
 	if req.IsActive != nil {
 		incident.IsActive = *req.IsActive
 	}
@@ -106,14 +281,17 @@ func (s *IncidentService) UpdateIncident(ctx context.Context, id uuid.UUID, req
 		}
 	}
 
+	// Явно заданные центр или радиус превращают полигональную зону в круг
+	if req.Latitude != nil || req.Longitude != nil || req.Radius != nil {
+		incident.Polygon = nil
+	}
+
 	if err := s.repo.Update(ctx, id, incident); err != nil {
 		return nil, fmt.Errorf("failed to update incident: %w", err)
 	}
 
 	// Инвалидируем кэш активных инцидентов
-	if s.locationService != nil {
-		_ = s.locationService.InvalidateCache(ctx)
-	}
+	s.invalidateCache(ctx)
 
 	return incident, nil
 }
@@ -124,13 +302,50 @@ func (s *IncidentService) DeleteIncident(ctx context.Context, id uuid.UUID) erro
 	}
 
 	// Инвалидируем кэш активных инцидентов
-	if s.locationService != nil {
-		_ = s.locationService.InvalidateCache(ctx)
-	}
+	s.invalidateCache(ctx)
 
 	return nil
 }
 
+// RestoreIncident отменяет удаление инцидента. Восстановление неудаленного инцидента - не ошибка.
+func (s *IncidentService) RestoreIncident(ctx context.Context, id uuid.UUID) (*domain.Incident, error) {
+	if err := s.repo.Restore(ctx, id); err != nil {
+		return nil, err
+	}
+
+	// Зона снова может действовать
+	s.invalidateCache(ctx)
+
+	return s.repo.GetByID(ctx, id)
+}
+
+// PurgeDeletedIncidents физически удаляет инциденты, удаленные раньше срока хранения.
+// retention == nil - срок из настроек сервиса.
+func (s *IncidentService) PurgeDeletedIncidents(ctx context.Context, retention *time.Duration) (*domain.PurgeResult, error) {
+	keep := s.deletedRetention
+	if retention != nil {
+		keep = *retention
+	}
+	if keep < 0 {
+		return nil, fmt.Errorf("%w: retention must not be negative", domain.ErrInvalidFilter)
+	}
+
+	result := &domain.PurgeResult{DeletedBefore: time.Now().Add(-keep)}
+	purged, err := s.repo.Purge(ctx, result.DeletedBefore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge incidents: %w", err)
+	}
+	result.Purged = purged
+
+	return result, nil
+}
+
+func (s *IncidentService) invalidateCache(ctx context.Context) {
+	for _, invalidator := range s.invalidators {
+		_ = invalidator.InvalidateCache(ctx)
+	}
+}
+
 func (s *IncidentService) validateCoordinates(lat, lon float64) error {
 	if lat < -90 || lat > 90 {
 		return fmt.Errorf("%w: latitude must be between -90 and 90", domain.ErrInvalidCoordinates)
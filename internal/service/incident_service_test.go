@@ -4,6 +4,7 @@ import (
 	"context"
 	"geo-alert-core/internal/domain"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -48,16 +49,94 @@ func (m *MockIncidentRepository) Delete(ctx context.Context, id uuid.UUID) error
 	return args.Error(0)
 }
 
+func (m *MockIncidentRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockIncidentRepository) GetFeedEntries(ctx context.Context, endedSince time.Time) ([]*domain.FeedEntry, error) {
+	args := m.Called(ctx, endedSince)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.FeedEntry), args.Error(1)
+}
+
+func (m *MockIncidentRepository) MarkSuperseded(ctx context.Context, source, externalID, supersededBy string) error {
+	args := m.Called(ctx, source, externalID, supersededBy)
+	return args.Error(0)
+}
+
+func (m *MockIncidentRepository) SupersededBy(ctx context.Context, source, externalID string) (string, error) {
+	args := m.Called(ctx, source, externalID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockIncidentRepository) Purge(ctx context.Context, deletedBefore time.Time) (int, error) {
+	args := m.Called(ctx, deletedBefore)
+	return args.Int(0), args.Error(1)
+}
+
 func (m *MockIncidentRepository) FindNearbyIncidents(ctx context.Context, latitude, longitude float64) ([]*domain.Incident, error) {
 	args := m.Called(ctx, latitude, longitude)
 	return args.Get(0).([]*domain.Incident), args.Error(1)
 }
 
-func (m *MockIncidentRepository) GetStats(ctx context.Context, minutes int) ([]*domain.IncidentStats, error) {
-	args := m.Called(ctx, minutes)
+func (m *MockIncidentRepository) GetStats(ctx context.Context, from, to time.Time) ([]*domain.IncidentStats, error) {
+	args := m.Called(ctx, from, to)
 	return args.Get(0).([]*domain.IncidentStats), args.Error(1)
 }
 
+func (m *MockIncidentRepository) GetTimeSeries(ctx context.Context, filter *domain.TimeSeriesFilter) ([]*domain.TimeSeriesPoint, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.TimeSeriesPoint), args.Error(1)
+}
+
+func (m *MockIncidentRepository) List(ctx context.Context, filter *domain.IncidentFilter) (*domain.IncidentPage, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.IncidentPage), args.Error(1)
+}
+
+func (m *MockIncidentRepository) FindByExternalID(ctx context.Context, source, externalID string) ([]*domain.Incident, error) {
+	args := m.Called(ctx, source, externalID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Incident), args.Error(1)
+}
+
+func (m *MockIncidentRepository) GetTile(ctx context.Context, z, x, y int) ([]byte, error) {
+	args := m.Called(ctx, z, x, y)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *MockIncidentRepository) GetAllAsOf(ctx context.Context, asOf time.Time, limit, offset int) ([]*domain.Incident, error) {
+	args := m.Called(ctx, asOf, limit, offset)
+	return args.Get(0).([]*domain.Incident), args.Error(1)
+}
+
+func (m *MockIncidentRepository) FindNearbyIncidentsAsOf(ctx context.Context, latitude, longitude float64, asOf time.Time) ([]*domain.Incident, error) {
+	args := m.Called(ctx, latitude, longitude, asOf)
+	return args.Get(0).([]*domain.Incident), args.Error(1)
+}
+
+func (m *MockIncidentRepository) GetVersions(ctx context.Context, id uuid.UUID) ([]*domain.IncidentVersion, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.IncidentVersion), args.Error(1)
+}
+
 func TestIncidentService_CreateIncident(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -147,3 +226,170 @@ func TestIncidentService_GetAllIncidents(t *testing.T) {
 		})
 	}
 }
+
+func TestIncidentService_UpdateIncident_Version(t *testing.T) {
+	id := uuid.New()
+	title := "Updated"
+
+	tests := []struct {
+		name            string
+		expectedVersion int
+		repoErr         error
+		wantErr         error
+	}{
+		{"matching version", 3, nil, nil},
+		{"any version", 0, nil, nil},
+		{"stale version", 2, nil, domain.ErrVersionConflict},
+		{"concurrent update in db", 3, domain.ErrVersionConflict, domain.ErrVersionConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockIncidentRepository)
+			service := NewIncidentService(mockRepo)
+
+			mockRepo.On("GetByID", mock.Anything, id).Return(&domain.Incident{ID: id, Title: "Old", Radius: 100, Version: 3}, nil)
+			mockRepo.On("Update", mock.Anything, id, mock.Anything).Return(tt.repoErr)
+
+			incident, err := service.UpdateIncident(context.Background(), id, &domain.UpdateIncidentRequest{Title: &title}, tt.expectedVersion)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, title, incident.Title)
+		})
+	}
+}
+
+func TestIncidentService_RestoreIncident(t *testing.T) {
+	id := uuid.New()
+
+	t.Run("restored", func(t *testing.T) {
+		mockRepo := new(MockIncidentRepository)
+		service := NewIncidentService(mockRepo)
+
+		mockRepo.On("Restore", mock.Anything, id).Return(nil).Once()
+		mockRepo.On("GetByID", mock.Anything, id).Return(&domain.Incident{ID: id, Version: 3}, nil).Once()
+
+		incident, err := service.RestoreIncident(context.Background(), id)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, incident.Version)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockRepo := new(MockIncidentRepository)
+		service := NewIncidentService(mockRepo)
+
+		mockRepo.On("Restore", mock.Anything, id).Return(domain.ErrIncidentNotFound).Once()
+
+		_, err := service.RestoreIncident(context.Background(), id)
+		assert.ErrorIs(t, err, domain.ErrIncidentNotFound)
+		mockRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	})
+}
+
+func TestIncidentService_PurgeDeletedIncidents(t *testing.T) {
+	mockRepo := new(MockIncidentRepository)
+	service := NewIncidentService(mockRepo)
+	service.SetDeletedRetention(48 * time.Hour)
+
+	var deletedBefore time.Time
+	mockRepo.On("Purge", mock.Anything, mock.AnythingOfType("time.Time")).Run(func(args mock.Arguments) {
+		deletedBefore = args.Get(1).(time.Time)
+	}).Return(2, nil)
+
+	result, err := service.PurgeDeletedIncidents(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Purged)
+	assert.Equal(t, deletedBefore, result.DeletedBefore)
+	assert.WithinDuration(t, time.Now().Add(-48*time.Hour), deletedBefore, time.Minute)
+
+	// срок из запроса важнее настроек, 0 - все удаленные
+	retention := time.Duration(0)
+	_, err = service.PurgeDeletedIncidents(context.Background(), &retention)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), deletedBefore, time.Minute)
+
+	retention = -time.Hour
+	_, err = service.PurgeDeletedIncidents(context.Background(), &retention)
+	assert.ErrorIs(t, err, domain.ErrInvalidFilter)
+	mockRepo.AssertNumberOfCalls(t, "Purge", 2)
+}
+
+func TestIncidentService_ListIncidents_Cursor(t *testing.T) {
+	mockRepo := new(MockIncidentRepository)
+	service := NewIncidentService(mockRepo)
+
+	last := uuid.New()
+	mockRepo.On("List", mock.Anything, mock.MatchedBy(func(f *domain.IncidentFilter) bool {
+		return f.Cursor == nil
+	})).Return(&domain.IncidentPage{
+		Items: []*domain.Incident{{ID: last}},
+		NextCursor: &domain.IncidentCursor{
+			SortBy:   domain.SortByCreatedAt,
+			SortDesc: true,
+			Value:    "2024-05-01 14:05:00.123456",
+			ID:       last,
+		},
+	}, nil).Once()
+
+	_, token, err := service.ListIncidents(context.Background(), &domain.IncidentFilter{Limit: 1}, "")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, token)
+
+	// курсор следующего запроса передается в репозиторий как есть
+	mockRepo.On("List", mock.Anything, mock.MatchedBy(func(f *domain.IncidentFilter) bool {
+		return f.Cursor != nil && f.Cursor.ID == last && f.Cursor.Value == "2024-05-01 14:05:00.123456"
+	})).Return(&domain.IncidentPage{}, nil).Once()
+
+	_, next, err := service.ListIncidents(context.Background(), &domain.IncidentFilter{Limit: 1}, token)
+	assert.NoError(t, err)
+	assert.Empty(t, next)
+
+	// курсор от другой сортировки не принимается
+	_, _, err = service.ListIncidents(context.Background(), &domain.IncidentFilter{Limit: 1, SortBy: domain.SortByTitle}, token)
+	assert.ErrorIs(t, err, domain.ErrInvalidFilter)
+
+	_, _, err = service.ListIncidents(context.Background(), &domain.IncidentFilter{}, "not-a-cursor!")
+	assert.ErrorIs(t, err, domain.ErrInvalidFilter)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestIncidentService_ListIncidents_Validation(t *testing.T) {
+	service := NewIncidentService(new(MockIncidentRepository))
+
+	tests := []struct {
+		name   string
+		filter *domain.IncidentFilter
+	}{
+		{"unknown severity", &domain.IncidentFilter{Severities: []string{"huge"}}},
+		{"inverted bbox", &domain.IncidentFilter{BBox: &domain.BoundingBox{MinLongitude: 40, MinLatitude: 50, MaxLongitude: 30, MaxLatitude: 60}}},
+		{"distance sort without point", &domain.IncidentFilter{SortBy: domain.SortByDistance}},
+		{"negative distance", &domain.IncidentFilter{Near: &domain.NearPoint{Latitude: 55, Longitude: 37, Meters: -1}}},
+		{"relevance sort without query", &domain.IncidentFilter{SortBy: domain.SortByRelevance}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := service.ListIncidents(context.Background(), tt.filter, "")
+			assert.ErrorIs(t, err, domain.ErrInvalidFilter)
+		})
+	}
+}
+
+func TestIncidentService_ListIncidents_SearchDefaultsToRelevance(t *testing.T) {
+	mockRepo := new(MockIncidentRepository)
+	service := NewIncidentService(mockRepo)
+
+	mockRepo.On("List", mock.Anything, mock.MatchedBy(func(f *domain.IncidentFilter) bool {
+		return f.Query == "мост" && f.SortBy == domain.SortByRelevance && f.SortDesc
+	})).Return(&domain.IncidentPage{}, nil)
+
+	_, _, err := service.ListIncidents(context.Background(), &domain.IncidentFilter{Query: "  мост "}, "")
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
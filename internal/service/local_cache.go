@@ -0,0 +1,92 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// Кэш в памяти процесса на время, пока Redis не настроен или недоступен
+const (
+	localCacheTTL        = time.Minute
+	localCacheMaxEntries = 10000
+)
+
+// localCache - небольшой кэш с TTL в памяти процесса.
+// Инвалидация видна только этому экземпляру, поэтому TTL короче, чем у кэша в Redis:
+// на других экземплярах изменения зон появятся не позже чем через TTL.
+type localCache[V any] struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]localCacheEntry[V]
+	generation uint64 // растет при Clear
+	now        func() time.Time
+}
+
+type localCacheEntry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+func newLocalCache[V any](ttl time.Duration, maxEntries int) *localCache[V] {
+	return &localCache[V]{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]localCacheEntry[V]),
+		now:        time.Now,
+	}
+}
+
+func (c *localCache[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || !c.now().Before(entry.expiresAt) {
+		var zero V
+		return zero, false
+	}
+	return entry.value, true
+}
+
+// Generation запоминается до загрузки значения из БД и передается в Set
+func (c *localCache[V]) Generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// Set сохраняет значение, если с момента Generation кэш не сбрасывали,
+// иначе значение могло быть загружено до изменения зон
+func (c *localCache[V]) Set(key string, value V, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	now := c.now()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.maxEntries {
+		// сначала выбрасываем устаревшие записи, если их нет - любую
+		for k, entry := range c.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		for k := range c.entries {
+			if len(c.entries) < c.maxEntries {
+				break
+			}
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = localCacheEntry[V]{value: value, expiresAt: now.Add(c.ttl)}
+}
+
+func (c *localCache[V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]localCacheEntry[V])
+	c.generation++
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalCache(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	cache := newLocalCache[int](time.Minute, 2)
+	cache.now = func() time.Time { return now }
+
+	cache.Set("a", 1, cache.Generation())
+	value, ok := cache.Get("a")
+	require.True(t, ok)
+	assert.Equal(t, 1, value)
+
+	// не больше maxEntries записей
+	cache.Set("b", 2, cache.Generation())
+	cache.Set("c", 3, cache.Generation())
+	assert.Len(t, cache.entries, 2)
+
+	now = now.Add(time.Minute)
+	_, ok = cache.Get("c")
+	assert.False(t, ok)
+
+	// значение, загруженное до сброса кэша, не сохраняется
+	generation := cache.Generation()
+	cache.Clear()
+	cache.Set("a", 1, generation)
+	_, ok = cache.Get("a")
+	assert.False(t, ok)
+}
+
+func TestTileService_GetTile_RedisUnavailable(t *testing.T) {
+	mockRepo := new(MockIncidentRepository)
+	// на этом порту никто не слушает
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", DialTimeout: 100 * time.Millisecond, MaxRetries: -1})
+	defer client.Close()
+	service := NewTileService(mockRepo, client)
+
+	mockRepo.On("GetTile", mock.Anything, 3, 4, 2).Return([]byte{0x1a}, nil).Twice()
+
+	for i := 0; i < 2; i++ {
+		tile, err := service.GetTile(context.Background(), 3, 4, 2)
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0x1a}, tile)
+	}
+	mockRepo.AssertNumberOfCalls(t, "GetTile", 1)
+
+	// сброс кэша в памяти не зависит от Redis
+	assert.Error(t, service.InvalidateCache(context.Background()))
+	_, err := service.GetTile(context.Background(), 3, 4, 2)
+	require.NoError(t, err)
+	mockRepo.AssertNumberOfCalls(t, "GetTile", 2)
+}
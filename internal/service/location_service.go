@@ -2,41 +2,45 @@ package service
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"geo-alert-core/internal/domain"
 	"geo-alert-core/internal/infrastructure/webhook"
+	"geo-alert-core/internal/logging"
+	"geo-alert-core/internal/metrics"
 	"geo-alert-core/internal/repository"
+	"geo-alert-core/internal/tracing"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type LocationService struct {
 	incidentRepo  repository.IncidentRepository
 	checkRepo     repository.LocationCheckRepository
-	redisClient   *redis.Client
 	webhookSender *webhook.Sender
-	cacheTTL      time.Duration
 }
 
 func NewLocationService(
 	incidentRepo repository.IncidentRepository,
 	checkRepo repository.LocationCheckRepository,
-	redisClient *redis.Client,
 	webhookSender *webhook.Sender,
 ) *LocationService {
 	return &LocationService{
 		incidentRepo:  incidentRepo,
 		checkRepo:     checkRepo,
-		redisClient:   redisClient,
 		webhookSender: webhookSender,
-		cacheTTL:      5 * time.Minute,
 	}
 }
 
-func (s *LocationService) CheckLocation(ctx context.Context, req *domain.LocationCheckRequest) (*domain.LocationCheckResponse, error) {
+func (s *LocationService) CheckLocation(ctx context.Context, req *domain.LocationCheckRequest) (response *domain.LocationCheckResponse, err error) {
+	ctx, span := tracing.Start(ctx, "LocationService.CheckLocation", attribute.String("user.id", req.UserID))
+	ctx = logging.With(ctx, "user_id", req.UserID)
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
 	if req.Latitude < -90 || req.Latitude > 90 {
 		return nil, fmt.Errorf("invalid latitude")
 	}
@@ -44,7 +48,7 @@ func (s *LocationService) CheckLocation(ctx context.Context, req *domain.Locatio
 		return nil, fmt.Errorf("invalid longitude")
 	}
 
-	// Находим ближайшие инциденты напрямую
+	// Зоны ищутся в базе по индексу, без кэша: изменение зоны сразу видно всем проверкам
 	nearbyIncidents, err := s.incidentRepo.FindNearbyIncidents(ctx, req.Latitude, req.Longitude)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find nearby incidents: %w", err)
@@ -61,21 +65,31 @@ func (s *LocationService) CheckLocation(ctx context.Context, req *domain.Locatio
 		return nil, fmt.Errorf("failed to save location check: %w", err)
 	}
 
+	span.SetAttributes(attribute.Int("incidents.count", len(nearbyIncidents)))
+	ctx = logging.With(ctx, "check_id", check.ID)
+
 	// Связываем проверку с найденными инцидентами
 	if len(nearbyIncidents) > 0 {
 		incidentIDs := make([]uuid.UUID, len(nearbyIncidents))
 		for i, inc := range nearbyIncidents {
 			incidentIDs[i] = inc.ID
 		}
+		ctx = logging.With(ctx, "incident_ids", incidentIDs)
 
 		if err := s.checkRepo.LinkToIncidents(ctx, check.ID, incidentIDs); err != nil {
-			fmt.Printf("Failed to link incidents: %v\n", err)
+			logging.FromContext(ctx).Error("failed to link incidents", "error", err)
 		}
 	}
 
+	logging.FromContext(ctx).Debug("location checked", "has_danger", len(nearbyIncidents) > 0)
+
 	// Асинхронно отправляем вебхук (если есть инциденты)
 	if len(nearbyIncidents) > 0 {
-		go s.sendWebhookAsync(check, nearbyIncidents)
+		metrics.LocationChecks.WithLabelValues("hit").Inc()
+		// вебхук переживает ответ клиенту: отмену запроса не наследуем, спан и поля логов - да
+		go s.sendWebhookAsync(context.WithoutCancel(ctx), check, nearbyIncidents)
+	} else {
+		metrics.LocationChecks.WithLabelValues("miss").Inc()
 	}
 
 	return &domain.LocationCheckResponse{
@@ -84,42 +98,67 @@ func (s *LocationService) CheckLocation(ctx context.Context, req *domain.Locatio
 	}, nil
 }
 
-// получает активные инциденты с кэшированием в Redis
-func (s *LocationService) getActiveIncidentsCached(ctx context.Context) ([]*domain.Incident, error) {
-	// Если Redis не настроен, загружаем напрямую из БД
-	if s.redisClient == nil {
-		return s.incidentRepo.GetActiveIncidents(ctx)
+// ReplayLocation проверяет точку по геометрии зон, действовавшей на момент req.AsOf.
+// Проверка не сохраняется и вебхук не отправляется - это инструмент для разбора инцидентов.
+func (s *LocationService) ReplayLocation(ctx context.Context, req *domain.LocationReplayRequest) (*domain.LocationReplayResponse, error) {
+	response := &domain.LocationReplayResponse{
+		AsOf:   req.AsOf,
+		UserID: req.UserID,
+	}
+
+	switch {
+	case req.Latitude != nil && req.Longitude != nil:
+		response.Latitude = *req.Latitude
+		response.Longitude = *req.Longitude
+	case req.Latitude == nil && req.Longitude == nil && req.UserID != "":
+		// Берем последнюю известную позицию пользователя на тот момент
+		check, err := s.checkRepo.GetLatestByUser(ctx, req.UserID, req.AsOf)
+		if err != nil {
+			return nil, err
+		}
+		response.Latitude = check.Latitude
+		response.Longitude = check.Longitude
+		response.CheckedAt = &check.CheckedAt
+	default:
+		return nil, fmt.Errorf("%w: either latitude and longitude or user_id must be set", domain.ErrInvalidCoordinates)
 	}
 
-	cacheKey := "active_incidents"
-
-	cached, err := s.redisClient.Get(ctx, cacheKey).Result()
-	if err == nil {
-		var incidents []*domain.Incident
-		if err := json.Unmarshal([]byte(cached), &incidents); err == nil {
-			return incidents, nil
-		}
+	if response.Latitude < -90 || response.Latitude > 90 {
+		return nil, fmt.Errorf("%w: latitude must be between -90 and 90", domain.ErrInvalidCoordinates)
+	}
+	if response.Longitude < -180 || response.Longitude > 180 {
+		return nil, fmt.Errorf("%w: longitude must be between -180 and 180", domain.ErrInvalidCoordinates)
 	}
 
-	// Кэш не найден или ошибка, загружаем из БД
-	incidents, err := s.incidentRepo.GetActiveIncidents(ctx)
+	incidents, err := s.incidentRepo.FindNearbyIncidentsAsOf(ctx, response.Latitude, response.Longitude, req.AsOf)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to find incidents as of %s: %w", req.AsOf.Format(time.RFC3339), err)
 	}
 
-	// Сохраняем в кэш (игнорируем ошибки кэширования)
-	jsonData, err := json.Marshal(incidents)
-	if err == nil && s.redisClient != nil {
-		_ = s.redisClient.Set(ctx, cacheKey, jsonData, s.cacheTTL)
-	}
+	response.HasDanger = len(incidents) > 0
+	response.Incidents = s.convertToDomainIncidents(incidents)
 
-	return incidents, nil
+	return response, nil
 }
 
-func (s *LocationService) sendWebhookAsync(check *domain.LocationCheck, incidents []*domain.Incident) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+// ExportChecks отдает проверки за период по одной, упорядоченными по пользователю и времени
+func (s *LocationService) ExportChecks(ctx context.Context, filter *domain.LocationCheckFilter, fn func(*domain.LocationCheck) error) error {
+	if !filter.From.Before(filter.To) {
+		return fmt.Errorf("%w: from must be before to", domain.ErrInvalidFilter)
+	}
+	return s.checkRepo.ForEach(ctx, filter, fn)
+}
+
+func (s *LocationService) sendWebhookAsync(ctx context.Context, check *domain.LocationCheck, incidents []*domain.Incident) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
+	ctx, span := tracing.Start(ctx, "LocationService.sendWebhook",
+		attribute.String("user.id", check.UserID),
+		attribute.Int("incidents.count", len(incidents)),
+	)
+	defer span.End()
+
 	incidentInfos := make([]webhook.IncidentInfo, len(incidents))
 	for i, inc := range incidents {
 		incidentInfos[i] = webhook.IncidentInfo{
@@ -141,7 +180,8 @@ func (s *LocationService) sendWebhookAsync(check *domain.LocationCheck, incident
 	}
 
 	if err := s.webhookSender.Send(ctx, payload); err != nil {
-		fmt.Printf("Failed to send webhook: %v\n", err)
+		tracing.RecordError(span, err)
+		logging.FromContext(ctx).Error("failed to send webhook", "error", err)
 	}
 }
 
@@ -152,10 +192,3 @@ func (s *LocationService) convertToDomainIncidents(incidents []*domain.Incident)
 	}
 	return result
 }
-
-func (s *LocationService) InvalidateCache(ctx context.Context) error {
-	if s.redisClient == nil {
-		return nil
-	}
-	return s.redisClient.Del(ctx, "active_incidents").Err()
-}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"geo-alert-core/internal/domain"
+	"geo-alert-core/internal/tracing"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// MockLocationCheckRepository - мок для тестирования
+type MockLocationCheckRepository struct {
+	mock.Mock
+}
+
+func (m *MockLocationCheckRepository) Create(ctx context.Context, check *domain.LocationCheck) error {
+	args := m.Called(ctx, check)
+	return args.Error(0)
+}
+
+func (m *MockLocationCheckRepository) LinkToIncidents(ctx context.Context, checkID uuid.UUID, incidentIDs []uuid.UUID) error {
+	args := m.Called(ctx, checkID, incidentIDs)
+	return args.Error(0)
+}
+
+func (m *MockLocationCheckRepository) GetLatestByUser(ctx context.Context, userID string, before time.Time) (*domain.LocationCheck, error) {
+	args := m.Called(ctx, userID, before)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LocationCheck), args.Error(1)
+}
+
+func (m *MockLocationCheckRepository) ForEach(ctx context.Context, filter *domain.LocationCheckFilter, fn func(*domain.LocationCheck) error) error {
+	args := m.Called(ctx, filter, fn)
+	return args.Error(0)
+}
+
+func (m *MockLocationCheckRepository) Heatmap(ctx context.Context, filter *domain.HeatmapFilter) ([]*domain.HeatmapCell, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.HeatmapCell), args.Error(1)
+}
+
+func (m *MockLocationCheckRepository) ForEachHit(ctx context.Context, after, until time.Time, fn func(*domain.IncidentHit) error) error {
+	args := m.Called(ctx, after, until, fn)
+	if hits, ok := args.Get(0).([]*domain.IncidentHit); ok {
+		for _, hit := range hits {
+			if err := fn(hit); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+func TestLocationService_ReplayLocation(t *testing.T) {
+	asOf := time.Date(2024, 5, 1, 14, 5, 0, 0, time.UTC)
+	lat, lon := 55.7558, 37.6173
+	zone := &domain.Incident{ID: uuid.New(), Title: "Old zone", IsActive: true}
+
+	t.Run("explicit coordinates", func(t *testing.T) {
+		incidentRepo := new(MockIncidentRepository)
+		checkRepo := new(MockLocationCheckRepository)
+		service := NewLocationService(incidentRepo, checkRepo, nil)
+
+		incidentRepo.On("FindNearbyIncidentsAsOf", mock.Anything, lat, lon, asOf).Return([]*domain.Incident{zone}, nil)
+
+		resp, err := service.ReplayLocation(context.Background(), &domain.LocationReplayRequest{
+			Latitude:  &lat,
+			Longitude: &lon,
+			AsOf:      asOf,
+		})
+
+		assert.NoError(t, err)
+		assert.True(t, resp.HasDanger)
+		assert.Len(t, resp.Incidents, 1)
+		assert.Nil(t, resp.CheckedAt)
+		checkRepo.AssertNotCalled(t, "GetLatestByUser", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("last known position of user", func(t *testing.T) {
+		incidentRepo := new(MockIncidentRepository)
+		checkRepo := new(MockLocationCheckRepository)
+		service := NewLocationService(incidentRepo, checkRepo, nil)
+
+		checkedAt := asOf.Add(-3 * time.Minute)
+		checkRepo.On("GetLatestByUser", mock.Anything, "user1", asOf).Return(&domain.LocationCheck{
+			UserID:    "user1",
+			Latitude:  lat,
+			Longitude: lon,
+			CheckedAt: checkedAt,
+		}, nil)
+		incidentRepo.On("FindNearbyIncidentsAsOf", mock.Anything, lat, lon, asOf).Return([]*domain.Incident{}, nil)
+
+		resp, err := service.ReplayLocation(context.Background(), &domain.LocationReplayRequest{
+			UserID: "user1",
+			AsOf:   asOf,
+		})
+
+		assert.NoError(t, err)
+		assert.False(t, resp.HasDanger)
+		assert.Equal(t, &checkedAt, resp.CheckedAt)
+	})
+
+	t.Run("neither coordinates nor user", func(t *testing.T) {
+		service := NewLocationService(new(MockIncidentRepository), new(MockLocationCheckRepository), nil)
+
+		_, err := service.ReplayLocation(context.Background(), &domain.LocationReplayRequest{AsOf: asOf})
+
+		assert.ErrorIs(t, err, domain.ErrInvalidCoordinates)
+	})
+}
+
+func TestLocationService_CheckLocation_Tracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(tracing.NewProvider(sdktrace.NewSimpleSpanProcessor(exporter), "test"))
+	defer otel.SetTracerProvider(previous)
+
+	incidentRepo := new(MockIncidentRepository)
+	checkRepo := new(MockLocationCheckRepository)
+	service := NewLocationService(incidentRepo, checkRepo, nil)
+
+	incidentRepo.On("FindNearbyIncidents", mock.Anything, 55.75, 37.61).Return([]*domain.Incident(nil), errors.New("postgis timeout")).Once()
+
+	_, err := service.CheckLocation(context.Background(), &domain.LocationCheckRequest{
+		UserID: "user1", Latitude: 55.75, Longitude: 37.61,
+	})
+	assert.Error(t, err)
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "LocationService.CheckLocation", spans[0].Name)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	assert.Contains(t, spans[0].Attributes, attribute.String("user.id", "user1"))
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"geo-alert-core/internal/domain"
+	"geo-alert-core/internal/logging"
+	"geo-alert-core/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultRollupSettleDelay - насколько агрегаты отстают от текущего момента.
+// checked_at ставится приложением до коммита, а проверка связывается с зонами еще позже:
+// попадания, закоммиченные позже этой задержки, в агрегаты не попадут.
+const DefaultRollupSettleDelay = 10 * time.Second
+
+// за один проход обрабатываем не больше часа проверок, чтобы не держать все в памяти
+const rollupMaxStep = time.Hour
+
+// rollupWatermarkPrecision - точность, с которой хранится watermark. Границы запроса
+// округляются до нее, иначе проверка между сохраненным и настоящим watermark учтется дважды.
+const rollupWatermarkPrecision = time.Millisecond
+
+// StatsRollupStore хранит агрегаты по зонам (реализуется в infrastructure/redis).
+// Каждая минута пишется и в минутный, и в часовой агрегат.
+type StatsRollupStore interface {
+	// State возвращает начало покрытия и момент, до которого проверки учтены (нулевые, если агрегатов нет)
+	State(ctx context.Context) (since, watermark time.Time, err error)
+	// Init задает начало покрытия, если оно еще не задано
+	Init(ctx context.Context, start time.Time) error
+	// Add атомарно добавляет агрегаты и сдвигает watermark с after на until.
+	// Если watermark уже не равен after, ничего не пишет и возвращает domain.ErrRollupConflict.
+	Add(ctx context.Context, buckets []*domain.StatsBucket, after, until time.Time) error
+	// Count объединяет минутные и часовые агрегаты зон
+	Count(ctx context.Context, incidentIDs []uuid.UUID, minutes, hours []time.Time) (map[uuid.UUID]*domain.StatsCount, error)
+}
+
+// StatsRollup - фоновая задача, которая переносит попадания в зоны в агрегаты по минутам и часам.
+// Статистика за период читается из агрегатов вместо COUNT(DISTINCT) по всем проверкам.
+type StatsRollup struct {
+	checkRepo   repository.LocationCheckRepository
+	store       StatsRollupStore
+	interval    time.Duration
+	settleDelay time.Duration
+	now         func() time.Time
+}
+
+func NewStatsRollup(checkRepo repository.LocationCheckRepository, store StatsRollupStore, interval time.Duration) *StatsRollup {
+	return &StatsRollup{
+		checkRepo:   checkRepo,
+		store:       store,
+		interval:    interval,
+		settleDelay: DefaultRollupSettleDelay,
+		// watermark и границы выборки в UTC, как checked_at в базе
+		now: func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// SetSettleDelay задает отставание агрегатов от текущего момента
+func (r *StatsRollup) SetSettleDelay(delay time.Duration) {
+	r.settleDelay = delay
+}
+
+// Run обновляет агрегаты до отмены контекста
+func (r *StatsRollup) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.Rollup(ctx); err != nil {
+			logging.FromContext(ctx).Error("stats rollup failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Rollup учитывает все новые попадания и возвращает их число.
+// Агрегаты начинаются с первого запуска, более ранние периоды считаются по проверкам.
+func (r *StatsRollup) Rollup(ctx context.Context) (int, error) {
+	until := r.now().Add(-r.settleDelay).Truncate(rollupWatermarkPrecision)
+
+	_, watermark, err := r.store.State(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rollup state: %w", err)
+	}
+	if watermark.IsZero() {
+		// с начала текущей минуты, чтобы первый минутный агрегат был полным
+		start := until.Truncate(time.Minute)
+		if err := r.store.Init(ctx, start); err != nil {
+			return 0, fmt.Errorf("failed to init rollup: %w", err)
+		}
+		watermark = start
+	}
+
+	total := 0
+	for watermark.Before(until) {
+		step := until
+		if limit := watermark.Add(rollupMaxStep); limit.Before(step) {
+			step = limit
+		}
+
+		count, err := r.rollupRange(ctx, watermark, step)
+		if errors.Is(err, domain.ErrRollupConflict) {
+			// агрегаты ведет другой экземпляр, продолжим со следующего тика
+			return total, nil
+		}
+		if err != nil {
+			return total, err
+		}
+		total += count
+		watermark = step
+	}
+
+	return total, nil
+}
+
+func (r *StatsRollup) rollupRange(ctx context.Context, after, until time.Time) (int, error) {
+	type bucketKey struct {
+		incidentID uuid.UUID
+		start      int64
+	}
+	buckets := make(map[bucketKey]*domain.StatsBucket)
+	users := make(map[bucketKey]map[string]bool)
+	var order []*domain.StatsBucket
+
+	count := 0
+	err := r.checkRepo.ForEachHit(ctx, after, until, func(hit *domain.IncidentHit) error {
+		start := hit.CheckedAt.UTC().Truncate(time.Minute)
+		key := bucketKey{incidentID: hit.IncidentID, start: start.Unix()}
+
+		bucket, ok := buckets[key]
+		if !ok {
+			bucket = &domain.StatsBucket{IncidentID: hit.IncidentID, Start: start}
+			buckets[key] = bucket
+			users[key] = make(map[string]bool)
+			order = append(order, bucket)
+		}
+		bucket.Checks++
+		if !users[key][hit.UserID] {
+			users[key][hit.UserID] = true
+			bucket.Users = append(bucket.Users, hit.UserID)
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read incident hits: %w", err)
+	}
+
+	// watermark сдвигается и без попаданий, иначе следующий проход начнется с того же места
+	if err := r.store.Add(ctx, order, after, until); err != nil {
+		return 0, fmt.Errorf("failed to save rollup: %w", err)
+	}
+
+	return count, nil
+}
+
+// Counts считает пользователей и проверки зон за [from, to) по агрегатам.
+// ok=false - агрегаты период не покрывают (еще не собраны, устарели или удалены), нужно считать по проверкам.
+func (r *StatsRollup) Counts(ctx context.Context, incidentIDs []uuid.UUID, from, to time.Time) (map[uuid.UUID]*domain.StatsCount, bool, error) {
+	since, watermark, err := r.store.State(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get rollup state: %w", err)
+	}
+
+	// агрегатов из будущего нет, а проверка срока хранения ограничивает период 35 днями -
+	// списки ключей строятся только после нее
+	now := r.now()
+	to = minTime(to, now)
+	switch {
+	case watermark.IsZero() || from.Before(since) || !from.Before(to):
+		return nil, false, nil
+	// старые часовые агрегаты уже удалены
+	case from.Before(now.Add(-domain.RollupHourRetention)):
+		return nil, false, nil
+	// задача отстала или остановлена: лучше медленный точный ответ, чем устаревший
+	case watermark.Before(to.Add(-r.settleDelay - 3*r.interval)):
+		return nil, false, nil
+	}
+
+	minutes, hours := rollupBuckets(from, to)
+	// минутные агрегаты живут меньше часовых
+	if len(minutes) > 0 && minutes[0].Before(now.Add(-domain.RollupMinuteRetention)) {
+		return nil, false, nil
+	}
+
+	counts, err := r.store.Count(ctx, incidentIDs, minutes, hours)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to count rollup: %w", err)
+	}
+
+	return counts, true, nil
+}
+
+// rollupBuckets покрывает [from, to) целыми часами в середине и минутами по краям.
+// Неполные крайние минуты учитываются целиком.
+func rollupBuckets(from, to time.Time) (minutes, hours []time.Time) {
+	from, to = from.UTC(), to.UTC()
+
+	firstHour := from.Truncate(time.Hour)
+	if firstHour.Before(from) {
+		firstHour = firstHour.Add(time.Hour)
+	}
+	lastHour := to.Truncate(time.Hour)
+
+	if !firstHour.Before(lastHour) {
+		// целых часов нет - только минуты
+		for m := from.Truncate(time.Minute); m.Before(to); m = m.Add(time.Minute) {
+			minutes = append(minutes, m)
+		}
+		return minutes, nil
+	}
+
+	for m := from.Truncate(time.Minute); m.Before(firstHour); m = m.Add(time.Minute) {
+		minutes = append(minutes, m)
+	}
+	for h := firstHour; h.Before(lastHour); h = h.Add(time.Hour) {
+		hours = append(hours, h)
+	}
+	for m := lastHour; m.Before(to); m = m.Add(time.Minute) {
+		minutes = append(minutes, m)
+	}
+
+	return minutes, hours
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"geo-alert-core/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStatsRollupStore - точные множества вместо HyperLogLog
+type memoryStatsRollupStore struct {
+	since, watermark time.Time
+	users            map[string]map[string]bool
+	checks           map[string]int
+}
+
+func newMemoryStatsRollupStore() *memoryStatsRollupStore {
+	return &memoryStatsRollupStore{
+		users:  make(map[string]map[string]bool),
+		checks: make(map[string]int),
+	}
+}
+
+func memoryRollupKey(granularity string, id uuid.UUID, start time.Time) string {
+	return granularity + id.String() + start.UTC().Format(time.RFC3339)
+}
+
+func (s *memoryStatsRollupStore) State(ctx context.Context) (time.Time, time.Time, error) {
+	return s.since, s.watermark, nil
+}
+
+func (s *memoryStatsRollupStore) Init(ctx context.Context, start time.Time) error {
+	if s.since.IsZero() {
+		s.since, s.watermark = start, start
+	}
+	return nil
+}
+
+func (s *memoryStatsRollupStore) Add(ctx context.Context, buckets []*domain.StatsBucket, after, until time.Time) error {
+	if !s.watermark.Equal(after) {
+		return domain.ErrRollupConflict
+	}
+	for _, bucket := range buckets {
+		for _, key := range []string{
+			memoryRollupKey("m", bucket.IncidentID, bucket.Start),
+			memoryRollupKey("h", bucket.IncidentID, bucket.Start.Truncate(time.Hour)),
+		} {
+			if s.users[key] == nil {
+				s.users[key] = make(map[string]bool)
+			}
+			for _, user := range bucket.Users {
+				s.users[key][user] = true
+			}
+			s.checks[key] += bucket.Checks
+		}
+	}
+	s.watermark = until
+	return nil
+}
+
+func (s *memoryStatsRollupStore) Count(ctx context.Context, ids []uuid.UUID, minutes, hours []time.Time) (map[uuid.UUID]*domain.StatsCount, error) {
+	counts := make(map[uuid.UUID]*domain.StatsCount)
+	for _, id := range ids {
+		var keys []string
+		for _, m := range minutes {
+			keys = append(keys, memoryRollupKey("m", id, m))
+		}
+		for _, h := range hours {
+			keys = append(keys, memoryRollupKey("h", id, h))
+		}
+
+		users := make(map[string]bool)
+		count := &domain.StatsCount{}
+		for _, key := range keys {
+			for user := range s.users[key] {
+				users[user] = true
+			}
+			count.Checks += s.checks[key]
+		}
+		count.Users = len(users)
+		counts[id] = count
+	}
+	return counts, nil
+}
+
+func TestStatsRollup_Rollup(t *testing.T) {
+	checkRepo := new(MockLocationCheckRepository)
+	store := newMemoryStatsRollupStore()
+	rollup := NewStatsRollup(checkRepo, store, 30*time.Second)
+
+	now := time.Date(2024, 5, 1, 12, 0, 30, 0, time.UTC)
+	rollup.now = func() time.Time { return now }
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	zone := uuid.New()
+	checkRepo.On("ForEachHit", mock.Anything, start, now.Add(-DefaultRollupSettleDelay), mock.Anything).Return([]*domain.IncidentHit{
+		{IncidentID: zone, UserID: "user1", CheckedAt: start.Add(5 * time.Second)},
+		{IncidentID: zone, UserID: "user1", CheckedAt: start.Add(10 * time.Second)},
+		{IncidentID: zone, UserID: "user2", CheckedAt: start.Add(15 * time.Second)},
+	}, nil).Once()
+
+	count, err := rollup.Rollup(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, start, store.since)
+	assert.Equal(t, now.Add(-DefaultRollupSettleDelay), store.watermark)
+
+	counts, ok, err := rollup.Counts(context.Background(), []uuid.UUID{zone}, start, now)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, 2, counts[zone].Users)
+	assert.Equal(t, 3, counts[zone].Checks)
+
+	// следующий проход продолжает с watermark
+	now = now.Add(time.Minute)
+	checkRepo.On("ForEachHit", mock.Anything, store.watermark, now.Add(-DefaultRollupSettleDelay), mock.Anything).Return([]*domain.IncidentHit{
+		{IncidentID: zone, UserID: "user3", CheckedAt: start.Add(time.Minute)},
+	}, nil).Once()
+
+	count, err = rollup.Rollup(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	counts, _, err = rollup.Counts(context.Background(), []uuid.UUID{zone}, start, now)
+	require.NoError(t, err)
+	assert.Equal(t, 3, counts[zone].Users)
+	checkRepo.AssertExpectations(t)
+}
+
+func TestStatsRollup_WatermarkPrecision(t *testing.T) {
+	checkRepo := new(MockLocationCheckRepository)
+	store := newMemoryStatsRollupStore()
+	rollup := NewStatsRollup(checkRepo, store, 30*time.Second)
+	rollup.SetSettleDelay(time.Minute)
+
+	// Redis хранит watermark в миллисекундах: граница выборки должна совпадать с сохраненной,
+	// иначе проверка в 12:00:29.0015 попадет и в этот проход, и в следующий
+	now := time.Date(2024, 5, 1, 12, 1, 29, 1_500_000+750, time.UTC)
+	rollup.now = func() time.Time { return now }
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	until := time.Date(2024, 5, 1, 12, 0, 29, 1_000_000, time.UTC)
+
+	checkRepo.On("ForEachHit", mock.Anything, start, until, mock.Anything).Return([]*domain.IncidentHit{}, nil).Once()
+
+	_, err := rollup.Rollup(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, until, store.watermark)
+	checkRepo.AssertExpectations(t)
+}
+
+func TestStatsRollup_ConcurrentInstance(t *testing.T) {
+	checkRepo := new(MockLocationCheckRepository)
+	store := newMemoryStatsRollupStore()
+	rollup := NewStatsRollup(checkRepo, store, 30*time.Second)
+
+	now := time.Date(2024, 5, 1, 12, 0, 30, 0, time.UTC)
+	rollup.now = func() time.Time { return now }
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	store.since, store.watermark = start, start
+
+	zone := uuid.New()
+	checkRepo.On("ForEachHit", mock.Anything, start, mock.Anything, mock.Anything).Return([]*domain.IncidentHit{
+		{IncidentID: zone, UserID: "user1", CheckedAt: start.Add(5 * time.Second)},
+	}, nil).Run(func(args mock.Arguments) {
+		// другой экземпляр успел обработать тот же период
+		store.watermark = now.Add(-DefaultRollupSettleDelay)
+	}).Once()
+
+	_, err := rollup.Rollup(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, store.checks)
+}
+
+func TestStatsRollup_CountsFallback(t *testing.T) {
+	store := newMemoryStatsRollupStore()
+	rollup := NewStatsRollup(new(MockLocationCheckRepository), store, 30*time.Second)
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	rollup.now = func() time.Time { return now }
+	zone := []uuid.UUID{uuid.New()}
+
+	// агрегатов еще нет
+	_, ok, err := rollup.Counts(context.Background(), zone, now.Add(-time.Hour), now)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	store.since = now.Add(-2 * time.Hour)
+	store.watermark = now.Add(-DefaultRollupSettleDelay)
+
+	// период начинается до первого запуска
+	_, ok, _ = rollup.Counts(context.Background(), zone, now.Add(-3*time.Hour), now)
+	assert.False(t, ok)
+
+	_, ok, _ = rollup.Counts(context.Background(), zone, now.Add(-time.Hour), now)
+	assert.True(t, ok)
+
+	// конец периода в далеком будущем обрезается текущим моментом, ключи на века вперед не строятся
+	_, ok, _ = rollup.Counts(context.Background(), zone, now.Add(-time.Hour), time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.True(t, ok)
+
+	// часовые агрегаты за этот период уже удалены
+	store.since = now.Add(-60 * 24 * time.Hour)
+	_, ok, _ = rollup.Counts(context.Background(), zone, now.Add(-40*24*time.Hour), now)
+	assert.False(t, ok)
+
+	// задача отстала
+	store.watermark = now.Add(-10 * time.Minute)
+	_, ok, _ = rollup.Counts(context.Background(), zone, now.Add(-time.Hour), now)
+	assert.False(t, ok)
+}
+
+func TestRollupBuckets(t *testing.T) {
+	from := time.Date(2024, 5, 1, 10, 30, 30, 0, time.UTC)
+	to := time.Date(2024, 5, 1, 13, 15, 0, 0, time.UTC)
+
+	minutes, hours := rollupBuckets(from, to)
+	assert.Len(t, minutes, 30+15)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC), minutes[0])
+	assert.Equal(t, time.Date(2024, 5, 1, 13, 14, 0, 0, time.UTC), minutes[len(minutes)-1])
+	assert.Equal(t, []time.Time{
+		time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC),
+		time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}, hours)
+
+	minutes, hours = rollupBuckets(from, from.Add(10*time.Minute))
+	assert.Len(t, minutes, 11)
+	assert.Empty(t, hours)
+}
+
+func TestStatsService_GetStats_Rollup(t *testing.T) {
+	mockRepo := new(MockIncidentRepository)
+	store := newMemoryStatsRollupStore()
+	rollup := NewStatsRollup(new(MockLocationCheckRepository), store, 30*time.Second)
+	service := NewStatsService(mockRepo, 60, time.UTC)
+	service.SetRollup(rollup)
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	rollup.now = func() time.Time { return now }
+	service.now = rollup.now
+
+	quiet, busy := uuid.New(), uuid.New()
+	mockRepo.On("GetActiveIncidents", mock.Anything).Return([]*domain.Incident{{ID: quiet}, {ID: busy, Title: "busy"}}, nil)
+
+	// без агрегатов считаем по проверкам
+	mockRepo.On("GetStats", mock.Anything, now.Add(-time.Hour), now).Return([]*domain.IncidentStats{{ZoneID: busy, UserCount: 1}}, nil).Once()
+	report, err := service.GetStats(context.Background(), &domain.StatsQuery{})
+	require.NoError(t, err)
+	assert.Len(t, report.Data, 1)
+
+	store.since = now.Add(-2 * time.Hour)
+	require.NoError(t, store.Add(context.Background(), []*domain.StatsBucket{
+		{IncidentID: busy, Start: now.Add(-30 * time.Minute), Users: []string{"a", "b"}, Checks: 4},
+	}, time.Time{}, now.Add(-DefaultRollupSettleDelay)))
+
+	report, err = service.GetStats(context.Background(), &domain.StatsQuery{})
+	require.NoError(t, err)
+	require.Len(t, report.Data, 2)
+	assert.Equal(t, busy, report.Data[0].ZoneID)
+	assert.Equal(t, "busy", report.Data[0].Title)
+	assert.Equal(t, 2, report.Data[0].UserCount)
+	assert.Equal(t, 4, report.Data[0].CheckCount)
+	assert.Equal(t, 0, report.Data[1].UserCount)
+	mockRepo.AssertNumberOfCalls(t, "GetStats", 1)
+}
@@ -2,24 +2,225 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"geo-alert-core/internal/domain"
+	"geo-alert-core/internal/logging"
 	"geo-alert-core/internal/repository"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
 )
 
+// MaxTimeSeriesPoints - предел числа шагов в одном временном ряду
+const MaxTimeSeriesPoints = 10000
+
 // business logic for stats
 type StatsService struct {
-	repo repository.IncidentRepository
+	repo          repository.IncidentRepository
+	rollup        *StatsRollup
+	defaultWindow time.Duration
+	location      *time.Location // часовой пояс для today/this_week
+	now           func() time.Time
+}
+
+func NewStatsService(repo repository.IncidentRepository, defaultMinutes int, location *time.Location) *StatsService {
+	if defaultMinutes <= 0 {
+		defaultMinutes = 60 // default
+	}
+	if location == nil {
+		location = time.UTC
+	}
+	return &StatsService{
+		repo:          repo,
+		defaultWindow: time.Duration(defaultMinutes) * time.Minute,
+		location:      location,
+		now:           time.Now,
+	}
 }
 
-func NewStatsService(repo repository.IncidentRepository) *StatsService {
-	return &StatsService{repo: repo}
+// SetRollup включает чтение статистики из предварительных агрегатов
+func (s *StatsService) SetRollup(rollup *StatsRollup) {
+	s.rollup = rollup
 }
 
-// get stats for incidents for last N minutes
-func (s *StatsService) GetStats(ctx context.Context, minutes int) ([]*domain.IncidentStats, error) {
-	if minutes <= 0 {
-		minutes = 60 // default
+// get stats for active incidents for the requested period
+func (s *StatsService) GetStats(ctx context.Context, query *domain.StatsQuery) (*domain.StatsReport, error) {
+	report, err := s.resolvePeriod(query)
+	if err != nil {
+		return nil, err
+	}
+
+	// период в UTC, как checked_at в базе и ключи агрегатов
+	from, to := report.From.UTC(), report.To.UTC()
+
+	if s.rollup != nil {
+		stats, ok, err := s.getStatsFromRollup(ctx, from, to)
+		if err == nil && ok {
+			report.Data = stats
+			return report, nil
+		}
+		if err != nil {
+			logging.FromContext(ctx).Warn("stats rollup unavailable, counting from checks", "error", err)
+		}
+	}
+
+	stats, err := s.repo.GetStats(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	report.Data = stats
+
+	return report, nil
+}
+
+// resolvePeriod превращает запрос в абсолютный период [from, to)
+func (s *StatsService) resolvePeriod(query *domain.StatsQuery) (*domain.StatsReport, error) {
+	set := 0
+	for _, ok := range []bool{query.Window != "", query.Minutes != 0, query.From != nil || query.To != nil} {
+		if ok {
+			set++
+		}
+	}
+	if set > 1 {
+		return nil, fmt.Errorf("%w: use only one of window, minutes or from/to", domain.ErrInvalidFilter)
+	}
+
+	now := s.now().In(s.location)
+	report := &domain.StatsReport{Window: query.Window, To: now}
+
+	switch {
+	case query.Window != "":
+		switch query.Window {
+		case domain.StatsWindowLast15m:
+			report.From = now.Add(-15 * time.Minute)
+		case domain.StatsWindowLastHour:
+			report.From = now.Add(-time.Hour)
+		case domain.StatsWindowLast24h:
+			report.From = now.Add(-24 * time.Hour)
+		case domain.StatsWindowToday:
+			report.From = startOfDay(now)
+		case domain.StatsWindowThisWeek:
+			// неделя начинается с понедельника
+			daysSinceMonday := (int(now.Weekday()) + 6) % 7
+			report.From = startOfDay(now.AddDate(0, 0, -daysSinceMonday))
+		default:
+			return nil, fmt.Errorf("%w: window must be one of %s, %s, %s, %s, %s", domain.ErrInvalidFilter,
+				domain.StatsWindowLast15m, domain.StatsWindowLastHour, domain.StatsWindowLast24h,
+				domain.StatsWindowToday, domain.StatsWindowThisWeek)
+		}
+	case query.Minutes != 0:
+		if query.Minutes < 0 {
+			return nil, fmt.Errorf("%w: minutes must be positive", domain.ErrInvalidFilter)
+		}
+		report.From = now.Add(-time.Duration(query.Minutes) * time.Minute)
+	case query.From != nil || query.To != nil:
+		if query.From == nil {
+			return nil, fmt.Errorf("%w: from is required with to", domain.ErrInvalidFilter)
+		}
+		report.From = *query.From
+		if query.To != nil {
+			report.To = *query.To
+		}
+		if !report.From.Before(report.To) {
+			return nil, fmt.Errorf("%w: from must be before to", domain.ErrInvalidFilter)
+		}
+	default:
+		report.From = now.Add(-s.defaultWindow)
+	}
+
+	return report, nil
+}
+
+// startOfDay - полночь того же дня в часовом поясе t (с учетом перехода на летнее время)
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// getStatsFromRollup - то же, что repo.GetStats, но по агрегатам (число пользователей приблизительное)
+func (s *StatsService) getStatsFromRollup(ctx context.Context, from, to time.Time) ([]*domain.IncidentStats, bool, error) {
+	incidents, err := s.repo.GetActiveIncidents(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	ids := make([]uuid.UUID, len(incidents))
+	for i, incident := range incidents {
+		ids[i] = incident.ID
+	}
+
+	counts, ok, err := s.rollup.Counts(ctx, ids, from, to)
+	if err != nil || !ok {
+		return nil, ok, err
+	}
+
+	stats := make([]*domain.IncidentStats, len(incidents))
+	for i, incident := range incidents {
+		stats[i] = &domain.IncidentStats{ZoneID: incident.ID, Title: incident.Title}
+		if count := counts[incident.ID]; count != nil {
+			stats[i].UserCount = count.Users
+			stats[i].CheckCount = count.Checks
+		}
+	}
+	sort.SliceStable(stats, func(i, j int) bool {
+		return stats[i].UserCount > stats[j].UserCount
+	})
+
+	return stats, true, nil
+}
+
+// GetTimeSeries возвращает ряд по зоне без пропусков: шаги без проверок заполняются нулями
+func (s *StatsService) GetTimeSeries(ctx context.Context, filter *domain.TimeSeriesFilter) (*domain.IncidentTimeSeries, error) {
+	if filter.Bucket == "" {
+		filter.Bucket = domain.BucketHour
+	}
+	step := domain.BucketDuration(filter.Bucket)
+	if step == 0 {
+		return nil, fmt.Errorf("%w: bucket must be minute, hour or day", domain.ErrInvalidFilter)
+	}
+	if !filter.From.Before(filter.To) {
+		return nil, fmt.Errorf("%w: from must be before to", domain.ErrInvalidFilter)
+	}
+
+	// шаги выровнены по UTC, первый может начинаться раньше from
+	start := filter.From.UTC().Truncate(step)
+	if n := filter.To.Sub(start) / step; n >= MaxTimeSeriesPoints {
+		return nil, fmt.Errorf("%w: range covers %d %s buckets, limit is %d", domain.ErrInvalidFilter, n, filter.Bucket, MaxTimeSeriesPoints)
+	}
+
+	// Ряд по несуществующей зоне - это 404, а не пустой график
+	if _, err := s.repo.GetByID(ctx, filter.IncidentID); err != nil {
+		return nil, err
+	}
+
+	// границы в UTC, как checked_at (см. GetStats)
+	query := *filter
+	query.From, query.To = filter.From.UTC(), filter.To.UTC()
+	points, err := s.repo.GetTimeSeries(ctx, &query)
+	if err != nil {
+		return nil, err
+	}
+
+	byTime := make(map[int64]*domain.TimeSeriesPoint, len(points))
+	for _, point := range points {
+		byTime[point.Time.Unix()] = point
+	}
+
+	series := &domain.IncidentTimeSeries{
+		IncidentID: filter.IncidentID,
+		Bucket:     filter.Bucket,
+		From:       filter.From,
+		To:         filter.To,
+		Points:     []*domain.TimeSeriesPoint{},
+	}
+	for t := start; t.Before(filter.To); t = t.Add(step) {
+		point, ok := byTime[t.Unix()]
+		if !ok {
+			point = &domain.TimeSeriesPoint{Time: t}
+		}
+		series.Points = append(series.Points, point)
 	}
 
-	return s.repo.GetStats(ctx, minutes)
+	return series, nil
 }
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"geo-alert-core/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsService_GetTimeSeries_FillsGaps(t *testing.T) {
+	mockRepo := new(MockIncidentRepository)
+	service := NewStatsService(mockRepo, 60, time.UTC)
+
+	id := uuid.New()
+	from := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+	to := time.Date(2024, 5, 1, 14, 0, 0, 0, time.UTC)
+
+	mockRepo.On("GetByID", mock.Anything, id).Return(&domain.Incident{ID: id}, nil)
+	mockRepo.On("GetTimeSeries", mock.Anything, mock.MatchedBy(func(f *domain.TimeSeriesFilter) bool {
+		return f.IncidentID == id && f.Bucket == domain.BucketHour
+	})).Return([]*domain.TimeSeriesPoint{
+		{Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Users: 2, Checks: 5, Entries: 2},
+		{Time: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), Users: 1, Checks: 1, Entries: 0},
+	}, nil)
+
+	series, err := service.GetTimeSeries(context.Background(), &domain.TimeSeriesFilter{IncidentID: id, From: from, To: to})
+	require.NoError(t, err)
+
+	assert.Equal(t, domain.BucketHour, series.Bucket)
+	// шаги 10, 11, 12, 13: первый начинается до from
+	require.Len(t, series.Points, 4)
+	assert.Equal(t, 5, series.Points[0].Checks)
+	assert.Equal(t, time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC), series.Points[1].Time)
+	assert.Zero(t, series.Points[1].Users)
+	assert.Equal(t, 1, series.Points[2].Users)
+	assert.Zero(t, series.Points[3].Checks)
+}
+
+func TestStatsService_GetTimeSeries_Validation(t *testing.T) {
+	mockRepo := new(MockIncidentRepository)
+	service := NewStatsService(mockRepo, 60, time.UTC)
+
+	to := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		filter domain.TimeSeriesFilter
+	}{
+		{"unknown bucket", domain.TimeSeriesFilter{From: to.Add(-time.Hour), To: to, Bucket: "week"}},
+		{"empty range", domain.TimeSeriesFilter{From: to, To: to}},
+		{"too many buckets", domain.TimeSeriesFilter{From: to.AddDate(-1, 0, 0), To: to, Bucket: domain.BucketMinute}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.GetTimeSeries(context.Background(), &tt.filter)
+			assert.ErrorIs(t, err, domain.ErrInvalidFilter)
+		})
+	}
+	mockRepo.AssertNotCalled(t, "GetTimeSeries", mock.Anything, mock.Anything)
+}
+
+func TestStatsService_GetTimeSeries_UnknownIncident(t *testing.T) {
+	mockRepo := new(MockIncidentRepository)
+	service := NewStatsService(mockRepo, 60, time.UTC)
+
+	id := uuid.New()
+	mockRepo.On("GetByID", mock.Anything, id).Return(nil, fmt.Errorf("%w", domain.ErrIncidentNotFound))
+
+	to := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	_, err := service.GetTimeSeries(context.Background(), &domain.TimeSeriesFilter{
+		IncidentID: id, From: to.AddDate(0, 0, -7), To: to, Bucket: domain.BucketDay,
+	})
+	assert.ErrorIs(t, err, domain.ErrIncidentNotFound)
+	mockRepo.AssertNotCalled(t, "GetTimeSeries", mock.Anything, mock.Anything)
+}
+
+func TestStatsService_GetStats_Periods(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	// среда, 01:30 по Москве - в UTC еще вторник
+	now := time.Date(2024, 5, 1, 1, 30, 0, 0, moscow)
+	from := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 4, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		query    domain.StatsQuery
+		wantFrom time.Time
+		wantTo   time.Time
+	}{
+		{"default from config", domain.StatsQuery{}, now.Add(-30 * time.Minute), now},
+		{"minutes", domain.StatsQuery{Minutes: 5}, now.Add(-5 * time.Minute), now},
+		{"last 15 minutes", domain.StatsQuery{Window: domain.StatsWindowLast15m}, now.Add(-15 * time.Minute), now},
+		{"today in stats timezone", domain.StatsQuery{Window: domain.StatsWindowToday}, time.Date(2024, 5, 1, 0, 0, 0, 0, moscow), now},
+		{"week starts on monday", domain.StatsQuery{Window: domain.StatsWindowThisWeek}, time.Date(2024, 4, 29, 0, 0, 0, 0, moscow), now},
+		{"absolute range", domain.StatsQuery{From: &from, To: &to}, from, to},
+		{"from until now", domain.StatsQuery{From: &from}, from, now},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockIncidentRepository)
+			service := NewStatsService(mockRepo, 30, moscow)
+			service.now = func() time.Time { return now }
+
+			mockRepo.On("GetStats", mock.Anything, mock.Anything, mock.Anything).Return([]*domain.IncidentStats{
+				{ZoneID: uuid.New(), Title: "zone", UserCount: 2, CheckCount: 5},
+			}, nil).Once()
+
+			report, err := service.GetStats(context.Background(), &tt.query)
+			require.NoError(t, err)
+			assert.True(t, tt.wantFrom.Equal(report.From), "from %s", report.From)
+			assert.True(t, tt.wantTo.Equal(report.To), "to %s", report.To)
+			assert.Equal(t, tt.query.Window, report.Window)
+			assert.Equal(t, 5, report.Data[0].CheckCount)
+		})
+	}
+}
+
+func TestStatsService_GetStats_PeriodInUTC(t *testing.T) {
+	mockRepo := new(MockIncidentRepository)
+	moscow := time.FixedZone("MSK", 3*60*60)
+	service := NewStatsService(mockRepo, 60, moscow)
+	now := time.Date(2024, 5, 1, 1, 30, 0, 0, moscow)
+	service.now = func() time.Time { return now }
+
+	// полночь по Москве - 21:00 предыдущего дня в UTC, в репозиторий период уходит в UTC
+	wantFrom := time.Date(2024, 4, 30, 21, 0, 0, 0, time.UTC)
+	wantTo := time.Date(2024, 4, 30, 22, 30, 0, 0, time.UTC)
+	mockRepo.On("GetStats", mock.Anything, wantFrom, wantTo).Return([]*domain.IncidentStats{}, nil).Once()
+
+	report, err := service.GetStats(context.Background(), &domain.StatsQuery{Window: domain.StatsWindowToday})
+	require.NoError(t, err)
+	// в ответе период остается в поясе статистики
+	assert.Equal(t, moscow, report.From.Location())
+	mockRepo.AssertExpectations(t)
+}
+
+func TestStatsService_GetStats_InvalidPeriod(t *testing.T) {
+	mockRepo := new(MockIncidentRepository)
+	service := NewStatsService(mockRepo, 60, time.UTC)
+
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(-time.Hour)
+
+	for name, query := range map[string]domain.StatsQuery{
+		"unknown window":   {Window: "last_year"},
+		"window and range": {Window: domain.StatsWindowToday, From: &from},
+		"to without from":  {To: &to},
+		"inverted range":   {From: &from, To: &to},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := service.GetStats(context.Background(), &query)
+			assert.ErrorIs(t, err, domain.ErrInvalidFilter)
+		})
+	}
+	mockRepo.AssertNotCalled(t, "GetStats", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"geo-alert-core/internal/domain"
+	"geo-alert-core/internal/metrics"
+	"geo-alert-core/internal/repository"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// MaxTileZoom - предельный масштаб тайлов, дальше зоны все равно не детализируются
+const MaxTileZoom = 22
+
+const tileGenerationKey = "tiles:incidents:generation"
+
+// TileService отдает векторные тайлы с зонами, готовые тайлы кэшируются в Redis.
+// Ключ тайла содержит номер поколения: инвалидация только увеличивает его,
+// старые тайлы доживают свой TTL и никому не отдаются.
+// Пока Redis недоступен, тайлы кэшируются в памяти процесса.
+type TileService struct {
+	repo        repository.IncidentRepository
+	redisClient *redis.Client
+	cacheTTL    time.Duration
+	local       *localCache[[]byte]
+}
+
+func NewTileService(repo repository.IncidentRepository, redisClient *redis.Client) *TileService {
+	return &TileService{
+		repo:        repo,
+		redisClient: redisClient,
+		// ограничивает и запаздывание зон, которые начинают/перестают действовать по времени
+		cacheTTL: 5 * time.Minute,
+		local:    newLocalCache[[]byte](localCacheTTL, localCacheMaxEntries),
+	}
+}
+
+func (s *TileService) GetTile(ctx context.Context, z, x, y int) ([]byte, error) {
+	if z < 0 || z > MaxTileZoom {
+		return nil, fmt.Errorf("%w: zoom must be between 0 and %d", domain.ErrInvalidTile, MaxTileZoom)
+	}
+	if n := 1 << z; x < 0 || x >= n || y < 0 || y >= n {
+		return nil, fmt.Errorf("%w: x and y must be between 0 and %d at zoom %d", domain.ErrInvalidTile, n-1, z)
+	}
+
+	// Если Redis не настроен, кэшируем в памяти
+	if s.redisClient == nil {
+		return s.getTileLocal(ctx, z, x, y)
+	}
+
+	generation, err := s.redisClient.Get(ctx, tileGenerationKey).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		metrics.CacheResult("tiles", "error")
+		return s.getTileLocal(ctx, z, x, y)
+	}
+
+	cacheKey := fmt.Sprintf("tiles:incidents:%d:%d/%d/%d", generation, z, x, y)
+	cached, err := s.redisClient.Get(ctx, cacheKey).Bytes()
+	switch {
+	case err == nil:
+		metrics.CacheResult("tiles", "hit")
+		return cached, nil
+	case errors.Is(err, redis.Nil):
+		metrics.CacheResult("tiles", "miss")
+	default:
+		metrics.CacheResult("tiles", "error")
+		return s.getTileLocal(ctx, z, x, y)
+	}
+
+	tile, err := s.repo.GetTile(ctx, z, x, y)
+	if err != nil {
+		return nil, err
+	}
+
+	// пустые тайлы тоже кэшируем, их большинство (игнорируем ошибки кэширования)
+	_ = s.redisClient.Set(ctx, cacheKey, tile, s.cacheTTL)
+
+	return tile, nil
+}
+
+// getTileLocal - тайл из кэша в памяти процесса
+func (s *TileService) getTileLocal(ctx context.Context, z, x, y int) ([]byte, error) {
+	key := fmt.Sprintf("%d/%d/%d", z, x, y)
+	if tile, ok := s.local.Get(key); ok {
+		metrics.CacheResult("tiles_local", "hit")
+		return tile, nil
+	}
+	metrics.CacheResult("tiles_local", "miss")
+
+	generation := s.local.Generation()
+	tile, err := s.repo.GetTile(ctx, z, x, y)
+	if err != nil {
+		return nil, err
+	}
+	s.local.Set(key, tile, generation)
+
+	return tile, nil
+}
+
+// InvalidateCache сбрасывает все тайлы, вызывается при любом изменении зон
+func (s *TileService) InvalidateCache(ctx context.Context) error {
+	s.local.Clear()
+	if s.redisClient == nil {
+		return nil
+	}
+	return s.redisClient.Incr(ctx, tileGenerationKey).Err()
+}
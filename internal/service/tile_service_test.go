@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"geo-alert-core/internal/domain"
+	"geo-alert-core/internal/metrics"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTileService_GetTile_Validation(t *testing.T) {
+	mockRepo := new(MockIncidentRepository)
+	service := NewTileService(mockRepo, nil)
+
+	tests := []struct {
+		name    string
+		z, x, y int
+	}{
+		{"negative zoom", -1, 0, 0},
+		{"zoom too deep", MaxTileZoom + 1, 0, 0},
+		{"x outside grid", 2, 4, 0},
+		{"negative y", 3, 0, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.GetTile(context.Background(), tt.z, tt.x, tt.y)
+			assert.ErrorIs(t, err, domain.ErrInvalidTile)
+		})
+	}
+	mockRepo.AssertNotCalled(t, "GetTile", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTileService_GetTile_WithoutCache(t *testing.T) {
+	mockRepo := new(MockIncidentRepository)
+	service := NewTileService(mockRepo, nil)
+
+	mockRepo.On("GetTile", mock.Anything, 3, 4, 2).Return([]byte{0x1a, 0x02}, nil).Once()
+	hits := testutil.ToFloat64(metrics.CacheRequests.WithLabelValues("tiles_local", "hit"))
+	misses := testutil.ToFloat64(metrics.CacheRequests.WithLabelValues("tiles_local", "miss"))
+
+	tile, err := service.GetTile(context.Background(), 3, 4, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x1a, 0x02}, tile)
+
+	// второй раз - из памяти процесса, обращения видны в метриках
+	tile, err = service.GetTile(context.Background(), 3, 4, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x1a, 0x02}, tile)
+	assert.Equal(t, hits+1, testutil.ToFloat64(metrics.CacheRequests.WithLabelValues("tiles_local", "hit")))
+	assert.Equal(t, misses+1, testutil.ToFloat64(metrics.CacheRequests.WithLabelValues("tiles_local", "miss")))
+	assert.NoError(t, service.InvalidateCache(context.Background()))
+	mockRepo.AssertExpectations(t)
+}
+
+type countingInvalidator struct {
+	calls int
+}
+
+func (c *countingInvalidator) InvalidateCache(ctx context.Context) error {
+	c.calls++
+	return nil
+}
+
+func TestIncidentService_InvalidatesAllCaches(t *testing.T) {
+	mockRepo := new(MockIncidentRepository)
+	service := NewIncidentService(mockRepo)
+
+	tiles, other := &countingInvalidator{}, &countingInvalidator{}
+	service.AddCacheInvalidator(tiles)
+	service.AddCacheInvalidator(other)
+
+	mockRepo.On("Create", mock.Anything, mock.Anything).Return(nil).Once()
+	_, err := service.CreateIncident(context.Background(), &domain.CreateIncidentRequest{
+		Title: "zone", Latitude: 55.7, Longitude: 37.6, Radius: 100,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, tiles.calls)
+	assert.Equal(t, 1, other.calls)
+}
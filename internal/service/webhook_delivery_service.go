@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"geo-alert-core/internal/domain"
+	"geo-alert-core/internal/logging"
+	"geo-alert-core/internal/repository"
+	"time"
+)
+
+// Размер выборки журнала доставок по умолчанию и наибольший
+const (
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 500
+)
+
+// deliveryPurgeInterval - как часто из журнала удаляются старые доставки
+const deliveryPurgeInterval = time.Hour
+
+// WebhookDeliveryService отдает журнал доставки вебхуков и чистит его от старых записей
+type WebhookDeliveryService struct {
+	repo      repository.WebhookDeliveryRepository
+	retention time.Duration
+	now       func() time.Time
+}
+
+// NewWebhookDeliveryService - retention 0 означает, что журнал не чистится
+func NewWebhookDeliveryService(repo repository.WebhookDeliveryRepository, retention time.Duration) *WebhookDeliveryService {
+	return &WebhookDeliveryService{repo: repo, retention: retention, now: time.Now}
+}
+
+// ListDeliveries возвращает доставки, новые первыми
+func (s *WebhookDeliveryService) ListDeliveries(ctx context.Context, filter *domain.WebhookDeliveryFilter) ([]*domain.WebhookDelivery, error) {
+	switch filter.Status {
+	case "", domain.DeliveryPending, domain.DeliveryDelivered, domain.DeliveryFailed:
+	default:
+		return nil, fmt.Errorf("%w: status must be one of pending, delivered, failed", domain.ErrInvalidFilter)
+	}
+	if filter.Limit < 1 || filter.Limit > maxDeliveryLimit {
+		filter.Limit = defaultDeliveryLimit
+	}
+
+	return s.repo.List(ctx, filter)
+}
+
+// PurgeDeliveries удаляет доставки старше срока хранения
+func (s *WebhookDeliveryService) PurgeDeliveries(ctx context.Context) (int64, error) {
+	if s.retention <= 0 {
+		return 0, nil
+	}
+
+	purged, err := s.repo.Purge(ctx, s.now().Add(-s.retention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge webhook deliveries: %w", err)
+	}
+	return purged, nil
+}
+
+// Run чистит журнал до отмены контекста
+func (s *WebhookDeliveryService) Run(ctx context.Context) {
+	ticker := time.NewTicker(deliveryPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		if purged, err := s.PurgeDeliveries(ctx); err != nil {
+			logging.FromContext(ctx).Error("webhook delivery purge failed", "error", err)
+		} else if purged > 0 {
+			logging.FromContext(ctx).Info("webhook deliveries purged", "count", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"geo-alert-core/internal/domain"
+	"geo-alert-core/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookDeliveryService_ListDeliveries(t *testing.T) {
+	repo := repository.NewMemoryWebhookDeliveryRepository(repository.NewMemoryStore())
+	service := NewWebhookDeliveryService(repo, 0)
+	ctx := context.Background()
+
+	for _, status := range []string{domain.DeliveryDelivered, domain.DeliveryFailed} {
+		require.NoError(t, repo.Create(ctx, &domain.WebhookDelivery{UserID: "user1", Status: status}))
+	}
+
+	// без лимита - значение по умолчанию
+	filter := &domain.WebhookDeliveryFilter{}
+	deliveries, err := service.ListDeliveries(ctx, filter)
+	require.NoError(t, err)
+	assert.Len(t, deliveries, 2)
+	assert.Equal(t, defaultDeliveryLimit, filter.Limit)
+
+	deliveries, err = service.ListDeliveries(ctx, &domain.WebhookDeliveryFilter{Status: domain.DeliveryFailed})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, domain.DeliveryFailed, deliveries[0].Status)
+
+	_, err = service.ListDeliveries(ctx, &domain.WebhookDeliveryFilter{Status: "lost"})
+	assert.ErrorIs(t, err, domain.ErrInvalidFilter)
+}
+
+func TestWebhookDeliveryService_PurgeDeliveries(t *testing.T) {
+	repo := repository.NewMemoryWebhookDeliveryRepository(repository.NewMemoryStore())
+	ctx := context.Background()
+	require.NoError(t, repo.Create(ctx, &domain.WebhookDelivery{UserID: "user1", Status: domain.DeliveryDelivered}))
+
+	// срок хранения 0 - журнал не чистится
+	purged, err := NewWebhookDeliveryService(repo, 0).PurgeDeliveries(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+
+	service := NewWebhookDeliveryService(repo, time.Hour)
+	purged, err = service.PurgeDeliveries(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+
+	service.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	purged, err = service.PurgeDeliveries(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+}
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS trg_incidents_version ON incidents;
+DROP FUNCTION IF EXISTS record_incident_version();
+DROP TABLE IF EXISTS incident_versions;
\ No newline at end of file
@@ -0,0 +1,37 @@
+-- История версий инцидентов: каждая запись - полный снимок строки incidents
+-- с интервалом действия [valid_from, valid_to). valid_to IS NULL - текущая версия.
+CREATE TABLE incident_versions (
+    incident_id UUID NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    version INT NOT NULL,
+    data JSONB NOT NULL,
+    valid_from TIMESTAMP NOT NULL,
+    valid_to TIMESTAMP,
+    PRIMARY KEY (incident_id, version)
+);
+
+CREATE INDEX idx_incident_versions_validity ON incident_versions(valid_from, valid_to);
+
+-- Версии пишутся триггером, чтобы история не зависела от того, кто меняет таблицу
+CREATE OR REPLACE FUNCTION record_incident_version() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE incident_versions
+    SET valid_to = NEW.updated_at
+    WHERE incident_id = NEW.id AND valid_to IS NULL;
+
+    INSERT INTO incident_versions (incident_id, version, data, valid_from)
+    SELECT NEW.id, COALESCE(MAX(version), 0) + 1, to_jsonb(NEW), NEW.updated_at
+    FROM incident_versions
+    WHERE incident_id = NEW.id;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_incidents_version
+AFTER INSERT OR UPDATE ON incidents
+FOR EACH ROW EXECUTE FUNCTION record_incident_version();
+
+-- Существующие инциденты получают первую версию с момента создания
+INSERT INTO incident_versions (incident_id, version, data, valid_from)
+SELECT i.id, 1, to_jsonb(i), i.created_at
+FROM incidents i;
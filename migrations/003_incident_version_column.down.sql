@@ -0,0 +1,3 @@
+UPDATE incident_versions SET data = data - 'version';
+
+ALTER TABLE incidents DROP COLUMN IF EXISTS version;
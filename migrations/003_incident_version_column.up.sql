@@ -0,0 +1,21 @@
+-- Версия строки для оптимистичной блокировки (ETag / If-Match)
+ALTER TABLE incidents ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+-- Текущая версия строки - номер ее последнего снимка в истории. Иначе первый ETag
+-- после миграции ушел бы назад, а CAP-сообщения -vN ссылались бы на неопубликованные версии.
+-- Триггер на время выравнивания выключен: это не изменение зоны, новый снимок не нужен
+ALTER TABLE incidents DISABLE TRIGGER trg_incidents_version;
+UPDATE incidents i
+SET version = v.version
+FROM (
+    SELECT incident_id, MAX(version) AS version
+    FROM incident_versions
+    GROUP BY incident_id
+) v
+WHERE v.incident_id = i.id;
+ALTER TABLE incidents ENABLE TRIGGER trg_incidents_version;
+
+-- Снимки, сделанные до появления колонки, получают номер своей версии
+UPDATE incident_versions
+SET data = data || jsonb_build_object('version', version)
+WHERE NOT data ? 'version';
@@ -0,0 +1,9 @@
+UPDATE incident_versions SET data = data - 'category' - 'severity';
+
+DROP INDEX IF EXISTS idx_incidents_updated_at_id;
+DROP INDEX IF EXISTS idx_incidents_created_at_id;
+DROP INDEX IF EXISTS idx_incidents_severity;
+DROP INDEX IF EXISTS idx_incidents_category;
+
+ALTER TABLE incidents DROP COLUMN IF EXISTS severity;
+ALTER TABLE incidents DROP COLUMN IF EXISTS category;
@@ -0,0 +1,16 @@
+-- Категория и уровень опасности для фильтрации списка инцидентов
+ALTER TABLE incidents
+    ADD COLUMN category VARCHAR(100) NOT NULL DEFAULT '',
+    ADD COLUMN severity VARCHAR(20) NOT NULL DEFAULT 'medium'
+        CHECK (severity IN ('low', 'medium', 'high', 'critical'));
+
+CREATE INDEX idx_incidents_category ON incidents(category);
+CREATE INDEX idx_incidents_severity ON incidents(severity);
+
+-- Индексы под keyset-пагинацию (значение сортировки + id)
+CREATE INDEX idx_incidents_created_at_id ON incidents(created_at, id);
+CREATE INDEX idx_incidents_updated_at_id ON incidents(updated_at, id);
+
+UPDATE incident_versions
+SET data = data || jsonb_build_object('category', '', 'severity', 'medium')
+WHERE NOT data ? 'severity';
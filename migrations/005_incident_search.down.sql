@@ -0,0 +1,18 @@
+CREATE OR REPLACE FUNCTION record_incident_version() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE incident_versions
+    SET valid_to = NEW.updated_at
+    WHERE incident_id = NEW.id AND valid_to IS NULL;
+
+    INSERT INTO incident_versions (incident_id, version, data, valid_from)
+    SELECT NEW.id, COALESCE(MAX(version), 0) + 1, to_jsonb(NEW), NEW.updated_at
+    FROM incident_versions
+    WHERE incident_id = NEW.id;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP INDEX IF EXISTS idx_incidents_search;
+
+ALTER TABLE incidents DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,26 @@
+-- Полнотекстовый поиск по названию и описанию.
+-- Названия бывают и на русском, и на английском, поэтому вектор строится по обеим конфигурациям.
+ALTER TABLE incidents ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('russian', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('russian', coalesce(description, '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(description, '')), 'B')
+) STORED;
+
+CREATE INDEX idx_incidents_search ON incidents USING GIN (search_vector);
+
+-- Вектор вычисляется из других колонок, в истории версий он не нужен
+CREATE OR REPLACE FUNCTION record_incident_version() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE incident_versions
+    SET valid_to = NEW.updated_at
+    WHERE incident_id = NEW.id AND valid_to IS NULL;
+
+    INSERT INTO incident_versions (incident_id, version, data, valid_from)
+    SELECT NEW.id, COALESCE(MAX(version), 0) + 1, to_jsonb(NEW) - 'search_vector', NEW.updated_at
+    FROM incident_versions
+    WHERE incident_id = NEW.id;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,5 @@
+UPDATE incident_versions SET data = data - 'area';
+
+DROP INDEX IF EXISTS idx_incidents_area;
+
+ALTER TABLE incidents DROP COLUMN IF EXISTS area;
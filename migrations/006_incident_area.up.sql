@@ -0,0 +1,5 @@
+-- Полигональные зоны. Для них latitude/longitude - центр, а radius - радиус
+-- описанной окружности, поэтому быстрый отбор по ST_DWithin продолжает работать.
+ALTER TABLE incidents ADD COLUMN area geography(Polygon, 4326);
+
+CREATE INDEX idx_incidents_area ON incidents USING GIST (area) WHERE area IS NOT NULL;
@@ -0,0 +1,12 @@
+UPDATE incident_versions
+SET data = data - 'source' - 'external_id' - 'effective_at' - 'expires_at' - 'urgency' - 'certainty';
+
+DROP INDEX IF EXISTS idx_incidents_external;
+
+ALTER TABLE incidents
+    DROP COLUMN IF EXISTS certainty,
+    DROP COLUMN IF EXISTS urgency,
+    DROP COLUMN IF EXISTS expires_at,
+    DROP COLUMN IF EXISTS effective_at,
+    DROP COLUMN IF EXISTS external_id,
+    DROP COLUMN IF EXISTS source;
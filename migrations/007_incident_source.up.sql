@@ -0,0 +1,15 @@
+-- Инциденты из внешних источников (CAP): ключ во внешней системе и период действия
+ALTER TABLE incidents
+    ADD COLUMN source VARCHAR(50) NOT NULL DEFAULT 'manual',
+    ADD COLUMN external_id VARCHAR(255),
+    -- внешние источники присылают время со смещением, храним с часовым поясом
+    ADD COLUMN effective_at TIMESTAMPTZ,
+    ADD COLUMN expires_at TIMESTAMPTZ,
+    ADD COLUMN urgency VARCHAR(20) NOT NULL DEFAULT '',
+    ADD COLUMN certainty VARCHAR(20) NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX idx_incidents_external ON incidents(source, external_id) WHERE external_id IS NOT NULL;
+
+UPDATE incident_versions
+SET data = data || jsonb_build_object('source', 'manual', 'urgency', '', 'certainty', '')
+WHERE NOT data ? 'source';
@@ -0,0 +1,8 @@
+-- Удаленные зоны при откате становятся выключенными
+UPDATE incidents SET is_active = false WHERE deleted_at IS NOT NULL;
+
+UPDATE incident_versions SET data = data - 'deleted_at';
+
+DROP INDEX IF EXISTS idx_incidents_deleted_at;
+
+ALTER TABLE incidents DROP COLUMN IF EXISTS deleted_at;
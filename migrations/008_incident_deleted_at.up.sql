@@ -0,0 +1,6 @@
+-- Удаление инцидента отделено от деактивации: удаленная зона пропадает из списков,
+-- но остается в базе (с историей и связями с проверками) до очистки по сроку хранения.
+-- Ранее удаленные зоны неотличимы от выключенных и остаются выключенными.
+ALTER TABLE incidents ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX idx_incidents_deleted_at ON incidents(deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,10 @@
+-- Документы внешних источников, замененные более поздними (CAP Update/Cancel с references).
+-- Хранятся отдельно от зон: отмена может прийти раньше самого предупреждения,
+-- и повторная доставка замененного документа не должна включать его зоны снова.
+CREATE TABLE external_superseded (
+    source VARCHAR(50) NOT NULL,
+    external_id VARCHAR(255) NOT NULL,
+    superseded_by VARCHAR(255) NOT NULL,
+    superseded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (source, external_id)
+);
@@ -0,0 +1,10 @@
+-- Ключи API, выданные через POST /api/v1/apikeys (в дополнение к ключу из API_KEY).
+-- Сам ключ не хранится: только SHA-256 для проверки и первые символы, чтобы узнать ключ в списке.
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP
+);
@@ -0,0 +1,15 @@
+-- Журнал доставки вебхуков: одна строка на отправку, попытки повтора обновляют ее же.
+-- Строки старше WEBHOOK_DELIVERY_RETENTION_DAYS удаляются фоновой задачей.
+CREATE TABLE webhook_deliveries (
+    id UUID PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    incident_ids JSONB NOT NULL DEFAULT '[]',
+    url TEXT NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX idx_webhook_deliveries_created_at ON webhook_deliveries(created_at);
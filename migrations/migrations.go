@@ -0,0 +1,8 @@
+// Package migrations встраивает SQL-миграции в бинарник.
+// Файлы называются NNN_описание.up.sql и NNN_описание.down.sql.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS